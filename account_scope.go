@@ -0,0 +1,380 @@
+package tradovate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Entities an AccountScope holds for orders it hasn't seen; past this the
+// oldest are dropped
+const maxHeldEntities = 1000
+
+// ErrWrongAccount is returned placing an order through an AccountScope
+// for an account other than the scope's
+var ErrWrongAccount = errors.New("order is for another account")
+
+// AccountScope is a view of a socket limited to one account, for users
+// with several. Orders placed through it are filled in with the account
+// and refused if they name another; lists and entities are filtered to
+// the account
+type AccountScope struct {
+	s    *WS
+	id   int
+	name string
+
+	mu        sync.Mutex
+	orders    map[uint]bool // order ID -> the account's, for fills and commands
+	positions map[int]bool
+	pending   map[uint][]heldEntity // entities for orders not seen yet
+	held      int                   // entities in pending
+	seq       uint64                // the next held entity's
+}
+
+type heldEntity struct {
+	e   *EntityMsg
+	fn  func(*EntityMsg)
+	seq uint64
+}
+
+// Scope the socket to an account, by its ID or name
+func NewAccountScope(ctx context.Context, s *WS, account string) (*AccountScope, error) {
+	accounts, err := s.ListAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing accounts: %w", err)
+	}
+
+	for _, v := range accounts {
+		if v.Name == account || strconv.Itoa(v.ID) == account {
+			return &AccountScope{
+				s:         s,
+				id:        v.ID,
+				name:      v.Name,
+				orders:    map[uint]bool{},
+				positions: map[int]bool{},
+				pending:   map[uint][]heldEntity{},
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no account %q", account)
+}
+
+func (a *AccountScope) ID() int      { return a.id }
+func (a *AccountScope) Name() string { return a.name }
+func (a *AccountScope) WS() *WS      { return a.s }
+
+// The scope's account
+func (a *AccountScope) ListAccounts(ctx context.Context) ([]*Account, error) {
+	x, err := a.s.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter(x, func(v *Account) bool { return v.ID == a.id }), nil
+}
+
+func (a *AccountScope) ListOrders(ctx context.Context) ([]*Order, error) {
+	a.mu.Lock()
+	since := a.seq
+	a.mu.Unlock()
+
+	x, err := a.s.ListOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ready []heldEntity
+	a.mu.Lock()
+	for _, v := range x {
+		ready = append(ready, a.setOrder(v.ID, v.AccountID == uint(a.id))...)
+	}
+
+	// held since before the list and still not in it, so the order's
+	// another login's
+	for id, v := range a.pending {
+		kept := slices.DeleteFunc(v, func(h heldEntity) bool { return h.seq < since })
+		a.held -= len(v) - len(kept)
+		if len(kept) == 0 {
+			delete(a.pending, id)
+		} else {
+			a.pending[id] = kept
+		}
+	}
+	a.mu.Unlock()
+
+	release(ready)
+	return filter(x, func(v *Order) bool { return v.AccountID == uint(a.id) }), nil
+}
+
+func (a *AccountScope) ListPositions(ctx context.Context) ([]*Position, error) {
+	x, err := a.s.ListPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, v := range x {
+		a.positions[v.ID] = v.AccountID == a.id
+	}
+
+	return filter(x, func(v *Position) bool { return v.AccountID == a.id }), nil
+}
+
+// Fills don't carry an account, so this lists orders too to tell which
+// are the account's
+func (a *AccountScope) ListFills(ctx context.Context) ([]*Fill, error) {
+	if _, err := a.ListOrders(ctx); err != nil {
+		return nil, fmt.Errorf("failed listing orders: %w", err)
+	}
+
+	x, err := a.s.ListFills(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return filter(x, func(v *Fill) bool { return a.orders[v.OrderID] }), nil
+}
+
+func (a *AccountScope) PlaceOrder(ctx context.Context, o *OrderReq) (uint, error) {
+	if err := a.bind(&o.AccountSpec, &o.AccountID); err != nil {
+		return 0, err
+	}
+
+	id, err := a.s.PlaceOrder(ctx, o)
+	if err != nil {
+		return 0, err
+	}
+
+	a.own(id)
+	return id, nil
+}
+
+func (a *AccountScope) OCO(ctx context.Context, o *OcoReq) (*OcoResp, error) {
+	if err := a.bind(&o.AccountSpec, &o.AccountID); err != nil {
+		return nil, err
+	}
+
+	x, err := a.s.OCO(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	a.own(x.OrderID, x.OcoID)
+	return x, nil
+}
+
+func (a *AccountScope) OSO(ctx context.Context, o *OsoReq) (*OsoResp, error) {
+	if err := a.bind(&o.AccountSpec, &o.AccountID); err != nil {
+		return nil, err
+	}
+
+	x, err := a.s.OSO(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	a.own(x.OrderID, x.Oso1ID, x.Oso2ID)
+	return x, nil
+}
+
+// Refused unless the order's known to be the account's: listed, placed
+// through the scope or seen in an order entity
+func (a *AccountScope) ModifyOrder(ctx context.Context, r *ModifyOrderReq) (uint, error) {
+	if err := a.mine(r.OrderID); err != nil {
+		return 0, err
+	}
+
+	return a.s.ModifyOrder(ctx, r)
+}
+
+// Refused unless the order's known to be the account's. See ModifyOrder
+func (a *AccountScope) CancelOrder(ctx context.Context, orderID uint) (uint, error) {
+	if err := a.mine(orderID); err != nil {
+		return 0, err
+	}
+
+	return a.s.CancelOrder(ctx, orderID)
+}
+
+// Wrap an entity handler so it only sees the account's entities, for
+// WithEntityHandler. Entities that aren't tied to an account, like
+// contracts, pass through. Fills and commands are tied to the account
+// through their order.
+//
+// Entities are handled concurrently, so they don't arrive in the order
+// the server sent them: a fill or command can come before its order, or
+// before PlaceOrder returns the order's ID. Those are held until the
+// order's listed, placed through the scope or seen in an order entity,
+// then passed on if it's the account's and dropped if it isn't. Ones
+// whose order never shows, such as other accounts' on a login with
+// several, are dropped when ListOrders doesn't have it, or once too many
+// are held
+func (a *AccountScope) Filter(fn func(*EntityMsg)) func(*EntityMsg) {
+	return func(e *EntityMsg) {
+		mine, ready := a.owns(e, fn)
+		if mine {
+			fn(e)
+		}
+		release(ready)
+	}
+}
+
+// Whether an entity is the account's or isn't tied to one. Fills and
+// commands for orders the scope hasn't seen aren't. See Filter
+func (a *AccountScope) Owns(e *EntityMsg) bool {
+	mine, ready := a.owns(e, nil)
+	release(ready)
+	return mine
+}
+
+// whether the entity's the account's, and anything held that its order
+// releases. With fn, entities for unknown orders are held for it
+func (a *AccountScope) owns(e *EntityMsg, fn func(*EntityMsg)) (bool, []heldEntity) {
+	var x struct {
+		ID         int  `json:"id"`
+		AccountID  *int `json:"accountId"`
+		OrderID    uint `json:"orderId"`
+		PositionID int  `json:"positionId"`
+	}
+
+	if err := json.Unmarshal(e.Data, &x); err != nil {
+		return false, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case accountEntity(e.Type):
+		return x.ID == a.id, nil
+	case x.AccountID != nil:
+		mine := *x.AccountID == a.id
+		switch e.Type {
+		case EntityTypeOrder:
+			return mine, a.setOrder(uint(x.ID), mine)
+		case EntityTypePosition:
+			a.positions[x.ID] = mine
+		}
+		return mine, nil
+	case x.OrderID != 0:
+		mine, ok := a.orders[x.OrderID]
+		if !ok && fn != nil {
+			a.hold(x.OrderID, heldEntity{e: e, fn: fn})
+		}
+		return mine, nil
+	case x.PositionID != 0:
+		return a.positions[x.PositionID], nil
+	default:
+		return true, nil
+	}
+}
+
+// entities keyed by the account's ID rather than carrying an accountId
+func accountEntity(t EntityType) bool {
+	switch t {
+	case EntityTypeAccount,
+		EntityTypeAccountRiskStatus,
+		EntityTypeMarginSnapshot,
+		EntityTypeUserAccountAutoLiq,
+		EntityTypePermissionedAccountAutoLiq,
+		EntityTypeUserAccountRiskParameter:
+		return true
+	default:
+		return false
+	}
+}
+
+// hold an entity until its order's seen, dropping the oldest held if
+// there are too many. Call with mu held
+func (a *AccountScope) hold(orderID uint, h heldEntity) {
+	h.seq = a.seq
+	a.seq++
+	a.pending[orderID] = append(a.pending[orderID], h)
+
+	if a.held++; a.held <= maxHeldEntities {
+		return
+	}
+
+	oldest := orderID
+	for id, v := range a.pending {
+		if v[0].seq < a.pending[oldest][0].seq {
+			oldest = id
+		}
+	}
+
+	if a.pending[oldest] = a.pending[oldest][1:]; len(a.pending[oldest]) == 0 {
+		delete(a.pending, oldest)
+	}
+	a.held--
+}
+
+// record whether an order is the account's, returning what was held for it
+// if it is. Call with mu held
+func (a *AccountScope) setOrder(id uint, mine bool) []heldEntity {
+	a.orders[id] = mine
+	x := a.pending[id]
+	delete(a.pending, id)
+	a.held -= len(x)
+
+	if !mine {
+		return nil
+	}
+	return x
+}
+
+func release(x []heldEntity) {
+	for _, v := range x {
+		v.fn(v.e)
+	}
+}
+
+// fill in the account, or refuse an order naming another
+func (a *AccountScope) bind(spec *string, id *uint) error {
+	if (*id != 0 && *id != uint(a.id)) || (*spec != "" && *spec != a.name) {
+		return fmt.Errorf("%w: scoped to %s (%d), got %q (%d)", ErrWrongAccount, a.name, a.id, *spec, *id)
+	}
+
+	*spec, *id = a.name, uint(a.id)
+	return nil
+}
+
+func (a *AccountScope) mine(orderID uint) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.orders[orderID] {
+		return fmt.Errorf("%w: order %d isn't known to be %s's", ErrWrongAccount, orderID, a.name)
+	}
+	return nil
+}
+
+func (a *AccountScope) own(ids ...uint) {
+	var ready []heldEntity
+	a.mu.Lock()
+	for _, v := range ids {
+		if v != 0 {
+			ready = append(ready, a.setOrder(v, true)...)
+		}
+	}
+	a.mu.Unlock()
+
+	release(ready)
+}
+
+func filter[T any](x []*T, keep func(*T) bool) []*T {
+	var kept []*T
+	for _, v := range x {
+		if keep(v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,210 @@
+package tradovate_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestAccountScope(mainTest *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	srv.SetAccounts(&tradovate.Account{ID: 1, Name: "DEMO1"}, &tradovate.Account{ID: 2, Name: "DEMO2"})
+	srv.SetOrders(&tradovate.Order{ID: 10, AccountID: 1}, &tradovate.Order{ID: 20, AccountID: 2})
+	srv.SetPositions(&tradovate.Position{ID: 100, AccountID: 1}, &tradovate.Position{ID: 200, AccountID: 2})
+	srv.SetFills(&tradovate.Fill{ID: 1, OrderID: 10}, &tradovate.Fill{ID: 2, OrderID: 20})
+	srv.Respond("order/placeorder", http.StatusOK, map[string]any{"orderId": 11})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		mainTest.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	if _, err = tradovate.NewAccountScope(ctx, ws, "DEMO3"); err == nil {
+		mainTest.Error("unknown accounts should error")
+	}
+
+	byID, err := tradovate.NewAccountScope(ctx, ws, "2")
+	if err != nil || byID.Name() != "DEMO2" {
+		mainTest.Fatalf("should scope by ID, got %v", err)
+	}
+
+	scope, err := tradovate.NewAccountScope(ctx, ws, "DEMO1")
+	if err != nil {
+		mainTest.Fatal(err)
+	}
+
+	orders, err := scope.ListOrders(ctx)
+	if err != nil || len(orders) != 1 || orders[0].ID != 10 {
+		mainTest.Errorf("should only list the account's orders, got %v %v", orders, err)
+	}
+
+	positions, err := scope.ListPositions(ctx)
+	if err != nil || len(positions) != 1 || positions[0].ID != 100 {
+		mainTest.Errorf("should only list the account's positions, got %v %v", positions, err)
+	}
+
+	fills, err := scope.ListFills(ctx)
+	if err != nil || len(fills) != 1 || fills[0].ID != 1 {
+		mainTest.Errorf("should only list fills on the account's orders, got %v %v", fills, err)
+	}
+
+	req := &tradovate.OrderReq{Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeMarket}
+	if _, err = scope.PlaceOrder(ctx, req); err != nil {
+		mainTest.Fatal(err)
+	}
+
+	var sent tradovate.OrderReq
+	if err = srv.RequestsFor("order/placeorder")[0].Decode(&sent); err != nil {
+		mainTest.Fatal(err)
+	}
+
+	if sent.AccountID != 1 || sent.AccountSpec != "DEMO1" {
+		mainTest.Errorf("account should be filled in, got %q %d", sent.AccountSpec, sent.AccountID)
+	}
+
+	for _, v := range []*tradovate.OrderReq{{AccountID: 2}, {AccountSpec: "DEMO2"}} {
+		if _, err = scope.PlaceOrder(ctx, v); !errors.Is(err, tradovate.ErrWrongAccount) {
+			mainTest.Errorf("orders for another account should be refused, got %v", err)
+		}
+	}
+
+	if n := len(srv.RequestsFor("order/placeorder")); n != 1 {
+		mainTest.Errorf("refused orders shouldn't be sent, got %d requests", n)
+	}
+
+	testCases := []struct {
+		name     string
+		kind     tradovate.EntityType
+		entity   any
+		expected bool
+	}{
+		{name: "account", kind: tradovate.EntityTypeAccount, entity: &tradovate.Account{ID: 1}, expected: true},
+		{name: "other account", kind: tradovate.EntityTypeAccount, entity: &tradovate.Account{ID: 2}},
+		{name: "order", kind: tradovate.EntityTypeOrder, entity: &tradovate.Order{ID: 30, AccountID: 1}, expected: true},
+		{name: "other order", kind: tradovate.EntityTypeOrder, entity: &tradovate.Order{ID: 40, AccountID: 2}},
+		{name: "fill on listed order", kind: tradovate.EntityTypeFill, entity: &tradovate.Fill{ID: 3, OrderID: 10}, expected: true},
+		{name: "fill on placed order", kind: tradovate.EntityTypeFill, entity: &tradovate.Fill{ID: 4, OrderID: 11}, expected: true},
+		{name: "fill on order entity", kind: tradovate.EntityTypeFill, entity: &tradovate.Fill{ID: 5, OrderID: 30}, expected: true},
+		{name: "fill on other order", kind: tradovate.EntityTypeFill, entity: &tradovate.Fill{ID: 6, OrderID: 40}},
+		{name: "fill on unknown order", kind: tradovate.EntityTypeFill, entity: &tradovate.Fill{ID: 7, OrderID: 50}},
+		{name: "command", kind: tradovate.EntityTypeCommand, entity: &tradovate.Command{ID: 1, OrderID: 20}},
+		{name: "not an account's", kind: tradovate.EntityTypeContract, entity: &tradovate.Contract{ID: 1}, expected: true},
+		{name: "risk status", kind: tradovate.EntityTypeAccountRiskStatus, entity: map[string]any{"id": 1}, expected: true},
+		{name: "other risk status", kind: tradovate.EntityTypeAccountRiskStatus, entity: map[string]any{"id": 2}},
+		{name: "other margin snapshot", kind: tradovate.EntityTypeMarginSnapshot, entity: map[string]any{"id": 2}},
+		{name: "other auto liq", kind: tradovate.EntityTypeUserAccountAutoLiq, entity: map[string]any{"id": 2}},
+		{name: "other permissioned auto liq", kind: tradovate.EntityTypePermissionedAccountAutoLiq, entity: map[string]any{"id": 2}},
+		{name: "other risk parameter", kind: tradovate.EntityTypeUserAccountRiskParameter, entity: map[string]any{"id": 2}},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			buf, err := json.Marshal(tc.entity)
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			var called bool
+			scope.Filter(func(*tradovate.EntityMsg) { called = true })(&tradovate.EntityMsg{Type: tc.kind, Data: buf})
+			if called != tc.expected {
+				tt.Errorf("want %v got %v", tc.expected, called)
+			}
+		})
+	}
+}
+
+func TestAccountScopeHeld(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	srv.SetAccounts(&tradovate.Account{ID: 1, Name: "DEMO1"}, &tradovate.Account{ID: 2, Name: "DEMO2"})
+	srv.Respond("order/placeorder", http.StatusOK, map[string]any{"orderId": 80})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	scope, err := tradovate.NewAccountScope(ctx, ws, "DEMO1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	handle := scope.Filter(func(e *tradovate.EntityMsg) {
+		var x struct{ ID int }
+		json.Unmarshal(e.Data, &x)
+		got = append(got, e.Type.String()+":"+strconv.Itoa(x.ID))
+	})
+
+	entity := func(kind tradovate.EntityType, x any) *tradovate.EntityMsg {
+		buf, err := json.Marshal(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &tradovate.EntityMsg{Type: kind, Data: buf}
+	}
+
+	// a fill before its order, for this account and another
+	handle(entity(tradovate.EntityTypeFill, &tradovate.Fill{ID: 1, OrderID: 60}))
+	handle(entity(tradovate.EntityTypeFill, &tradovate.Fill{ID: 2, OrderID: 70}))
+	if len(got) != 0 {
+		t.Fatalf("fills for unseen orders should be held, got %v", got)
+	}
+
+	handle(entity(tradovate.EntityTypeOrder, &tradovate.Order{ID: 60, AccountID: 1}))
+	handle(entity(tradovate.EntityTypeOrder, &tradovate.Order{ID: 70, AccountID: 2}))
+
+	// a command before PlaceOrder returns the order's ID
+	handle(entity(tradovate.EntityTypeCommand, &tradovate.Command{ID: 3, OrderID: 80}))
+	req := &tradovate.OrderReq{Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeMarket}
+	if _, err = scope.PlaceOrder(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"Order:60", "Fill:1", "Command:3"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("held entities should follow their order, and other accounts' be dropped: want %v got %v", want, got)
+	}
+
+	// another login's order never shows, so listing without it drops what's held
+	got = nil
+	handle(entity(tradovate.EntityTypeFill, &tradovate.Fill{ID: 4, OrderID: 90}))
+	if _, err = scope.ListOrders(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	handle(entity(tradovate.EntityTypeOrder, &tradovate.Order{ID: 90, AccountID: 1}))
+	if want := []string{"Order:90"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("fills for orders missing from the list should be dropped: want %v got %v", want, got)
+	}
+
+	// past the cap the oldest go
+	got = nil
+	for i := range 1001 {
+		handle(entity(tradovate.EntityTypeFill, &tradovate.Fill{ID: uint(1000 + i), OrderID: uint(1000 + i)}))
+	}
+
+	handle(entity(tradovate.EntityTypeOrder, &tradovate.Order{ID: 1000, AccountID: 1}))
+	handle(entity(tradovate.EntityTypeOrder, &tradovate.Order{ID: 2000, AccountID: 1}))
+	if want := []string{"Order:1000", "Order:2000", "Fill:2000"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("the oldest held should be dropped past the cap: want %v got %v", want, got)
+	}
+}
@@ -0,0 +1,100 @@
+// Code generated by "enumer -type Adjustment -trimprefix Adjustment -json"; DO NOT EDIT.
+
+package tradovate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const _AdjustmentName = "NoneDifferenceRatio"
+
+var _AdjustmentIndex = [...]uint8{0, 4, 14, 19}
+
+const _AdjustmentLowerName = "nonedifferenceratio"
+
+func (i Adjustment) String() string {
+	if i >= Adjustment(len(_AdjustmentIndex)-1) {
+		return fmt.Sprintf("Adjustment(%d)", i)
+	}
+	return _AdjustmentName[_AdjustmentIndex[i]:_AdjustmentIndex[i+1]]
+}
+
+// An "invalid array index" compiler error signifies that the constant values have changed.
+// Re-run the stringer command to generate them again.
+func _AdjustmentNoOp() {
+	var x [1]struct{}
+	_ = x[AdjustmentNone-(0)]
+	_ = x[AdjustmentDifference-(1)]
+	_ = x[AdjustmentRatio-(2)]
+}
+
+var _AdjustmentValues = []Adjustment{AdjustmentNone, AdjustmentDifference, AdjustmentRatio}
+
+var _AdjustmentNameToValueMap = map[string]Adjustment{
+	_AdjustmentName[0:4]:        AdjustmentNone,
+	_AdjustmentLowerName[0:4]:   AdjustmentNone,
+	_AdjustmentName[4:14]:       AdjustmentDifference,
+	_AdjustmentLowerName[4:14]:  AdjustmentDifference,
+	_AdjustmentName[14:19]:      AdjustmentRatio,
+	_AdjustmentLowerName[14:19]: AdjustmentRatio,
+}
+
+var _AdjustmentNames = []string{
+	_AdjustmentName[0:4],
+	_AdjustmentName[4:14],
+	_AdjustmentName[14:19],
+}
+
+// AdjustmentString retrieves an enum value from the enum constants string name.
+// Throws an error if the param is not part of the enum.
+func AdjustmentString(s string) (Adjustment, error) {
+	if val, ok := _AdjustmentNameToValueMap[s]; ok {
+		return val, nil
+	}
+
+	if val, ok := _AdjustmentNameToValueMap[strings.ToLower(s)]; ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("%s does not belong to Adjustment values", s)
+}
+
+// AdjustmentValues returns all values of the enum
+func AdjustmentValues() []Adjustment {
+	return _AdjustmentValues
+}
+
+// AdjustmentStrings returns a slice of all String values of the enum
+func AdjustmentStrings() []string {
+	strs := make([]string, len(_AdjustmentNames))
+	copy(strs, _AdjustmentNames)
+	return strs
+}
+
+// IsAAdjustment returns "true" if the value is listed in the enum definition. "false" otherwise
+func (i Adjustment) IsAAdjustment() bool {
+	for _, v := range _AdjustmentValues {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON implements the json.Marshaler interface for Adjustment
+func (i Adjustment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Adjustment
+func (i *Adjustment) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Adjustment should be a string, got %s", data)
+	}
+
+	var err error
+	*i, err = AdjustmentString(s)
+	return err
+}
@@ -0,0 +1,151 @@
+package tradovate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Concurrency cap for batch subscribes unless set with WithBatchConcurrency
+const defaultBatchConcurrency = 8
+
+type BatchOpt func(b *Batch)
+
+// Max requests a batch has in flight at once. Defaults to 8
+func WithBatchConcurrency(n int) BatchOpt {
+	return func(b *Batch) { b.concurrency = max(n, 1) }
+}
+
+// If any symbol fails to subscribe, unsubscribe the ones that worked so
+// the batch either fully happens or doesn't
+func WithAllOrNothing() BatchOpt {
+	return func(b *Batch) { b.allOrNothing = true }
+}
+
+// Batch subscribes to many symbols at once, pipelining the requests
+// instead of waiting on each round trip in turn
+type Batch struct {
+	s            *WS
+	concurrency  int
+	allOrNothing bool
+}
+
+// SubResult is the outcome of subscribing to one symbol in a batch
+type SubResult struct {
+	Symbol     string
+	Quotes     []*Quote // initial quotes; SubscribeQuotes only
+	Err        error
+	RolledBack bool // subscribed, then unsubscribed because another symbol failed
+}
+
+// Configure a batch. s.SubscribeQuotes and friends use the defaults
+func (s *WS) Batch(opts ...BatchOpt) *Batch {
+	b := &Batch{s: s, concurrency: defaultBatchConcurrency}
+	for _, v := range opts {
+		v(b)
+	}
+
+	return b
+}
+
+// Subscribe to quotes for every symbol using the default batch settings.
+// See Batch.SubscribeQuotes
+func (s *WS) SubscribeQuotes(ctx context.Context, symbols ...string) ([]SubResult, error) {
+	return s.Batch().SubscribeQuotes(ctx, symbols...)
+}
+
+// Subscribe to DOMs for every symbol using the default batch settings.
+// See Batch.SubscribeDOMs
+func (s *WS) SubscribeDOMs(ctx context.Context, symbols ...string) ([]SubResult, error) {
+	return s.Batch().SubscribeDOMs(ctx, symbols...)
+}
+
+// Subscribe to histograms for every symbol using the default batch
+// settings. See Batch.SubscribeHistograms
+func (s *WS) SubscribeHistograms(ctx context.Context, symbols ...string) ([]SubResult, error) {
+	return s.Batch().SubscribeHistograms(ctx, symbols...)
+}
+
+// Subscribe to quotes for every symbol. Results line up with symbols;
+// the error joins every symbol's failure, nil if they all worked
+func (b *Batch) SubscribeQuotes(ctx context.Context, symbols ...string) ([]SubResult, error) {
+	return b.run(ctx, symbols, b.s.SubscribeQuoteSymbol, b.s.UnsubscribeQuoteSymbol)
+}
+
+// Subscribe to DOMs for every symbol. Results line up with symbols;
+// the error joins every symbol's failure, nil if they all worked
+func (b *Batch) SubscribeDOMs(ctx context.Context, symbols ...string) ([]SubResult, error) {
+	return b.run(ctx, symbols, noQuotes(b.s.SubscribeDOMSymbol), b.s.UnsubscribeDOMSymbol)
+}
+
+// Subscribe to histograms for every symbol. Results line up with
+// symbols; the error joins every symbol's failure, nil if they all worked
+func (b *Batch) SubscribeHistograms(ctx context.Context, symbols ...string) ([]SubResult, error) {
+	return b.run(ctx, symbols, noQuotes(b.s.SubscribeHistogramSymbol), b.s.UnsubscribeHistogramSymbol)
+}
+
+type subscribeFn func(ctx context.Context, symbol string) ([]*Quote, error)
+
+func noQuotes(fn func(context.Context, string) error) subscribeFn {
+	return func(ctx context.Context, symbol string) ([]*Quote, error) {
+		return nil, fn(ctx, symbol)
+	}
+}
+
+func (b *Batch) run(ctx context.Context, symbols []string, sub subscribeFn, unsub func(context.Context, string) error) ([]SubResult, error) {
+	x := make([]SubResult, len(symbols))
+	b.each(symbols, func(i int) {
+		x[i].Symbol = symbols[i]
+		x[i].Quotes, x[i].Err = sub(ctx, symbols[i])
+	})
+
+	var errs []error
+	for _, v := range x {
+		if v.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", v.Symbol, v.Err))
+		}
+	}
+
+	if len(errs) == 0 || !b.allOrNothing {
+		return x, errors.Join(errs...)
+	}
+
+	// the caller giving up shouldn't leave half the batch subscribed
+	rollback := context.WithoutCancel(ctx)
+
+	var mu sync.Mutex
+	b.each(symbols, func(i int) {
+		if x[i].Err != nil {
+			return
+		}
+
+		if err := unsub(rollback, symbols[i]); err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("failed rolling back %s: %w", symbols[i], err))
+			mu.Unlock()
+			return
+		}
+
+		x[i].Quotes, x[i].RolledBack = nil, true
+	})
+
+	return x, errors.Join(errs...)
+}
+
+// call fn for every index, at most concurrency at a time
+func (b *Batch) each(symbols []string, fn func(i int)) {
+	sem := make(chan struct{}, b.concurrency)
+
+	var wg sync.WaitGroup
+	for i := range symbols {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			fn(i)
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,109 @@
+package tradovate_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestBatchSubscribe(mainTest *testing.T) {
+	testCases := []struct {
+		name               string
+		symbols            []string
+		opts               []tradovate.BatchOpt
+		expectedFailed     []string
+		expectedRolledBack []string
+	}{
+		{
+			name:    "all subscribe",
+			symbols: []string{"ESZ5", "NQZ5", "CLZ5"},
+		},
+		{
+			name:           "failures are per symbol",
+			symbols:        []string{"ESZ5", "BAD", "CLZ5"},
+			opts:           []tradovate.BatchOpt{tradovate.WithBatchConcurrency(1)},
+			expectedFailed: []string{"BAD"},
+		},
+		{
+			name:               "all or nothing rolls back",
+			symbols:            []string{"ESZ5", "BAD", "CLZ5"},
+			opts:               []tradovate.BatchOpt{tradovate.WithAllOrNothing()},
+			expectedFailed:     []string{"BAD"},
+			expectedRolledBack: []string{"CLZ5", "ESZ5"},
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			srv := tradovatetest.NewServer()
+			defer srv.Close()
+
+			srv.Handle("md/subscribeQuote", func(r *tradovatetest.Request) tradovatetest.Response {
+				var x struct{ Symbol string }
+				if r.Decode(&x); x.Symbol == "BAD" {
+					return tradovatetest.Response{Status: http.StatusBadRequest, Body: "unknown symbol"}
+				}
+				return tradovatetest.Response{Status: http.StatusOK, Body: []map[string]any{}}
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			ws, err := srv.NewSocket(ctx)
+			if err != nil {
+				tt.Fatalf("failed connecting: %v", err)
+			}
+			defer ws.Close()
+
+			results, err := ws.Batch(tc.opts...).SubscribeQuotes(ctx, tc.symbols...)
+			if (err != nil) != (len(tc.expectedFailed) > 0) {
+				tt.Errorf("wanted failures %v, got err %v", tc.expectedFailed, err)
+			}
+
+			var failed, rolledBack []string
+			for i, v := range results {
+				if v.Symbol != tc.symbols[i] {
+					tt.Errorf("results out of order: want %s got %s", tc.symbols[i], v.Symbol)
+				}
+
+				if v.Err != nil {
+					failed = append(failed, v.Symbol)
+				}
+
+				if v.RolledBack {
+					rolledBack = append(rolledBack, v.Symbol)
+				}
+			}
+
+			if !reflect.DeepEqual(tc.expectedFailed, failed) {
+				tt.Errorf("wrong failures: want %v got %v", tc.expectedFailed, failed)
+			}
+
+			if !reflect.DeepEqual(tc.expectedRolledBack, sorted(rolledBack)) {
+				tt.Errorf("wrong rollbacks: want %v got %v", tc.expectedRolledBack, rolledBack)
+			}
+
+			var unsubbed []string
+			for _, v := range srv.RequestsFor("md/unsubscribeQuote") {
+				var x struct{ Symbol string }
+				v.Decode(&x)
+				unsubbed = append(unsubbed, x.Symbol)
+			}
+
+			if !reflect.DeepEqual(tc.expectedRolledBack, sorted(unsubbed)) {
+				tt.Errorf("wrong unsubscribes sent: want %v got %v", tc.expectedRolledBack, unsubbed)
+			}
+		})
+	}
+}
+
+func sorted(x []string) []string {
+	slices.Sort(x)
+	return x
+}
@@ -0,0 +1,176 @@
+package tradovate_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestBracketOrderJSON(mainTest *testing.T) {
+	expire := time.Date(2025, 3, 10, 21, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		arg      any
+		expected string
+	}{
+		{
+			name: "oco with a market leg",
+			arg: &tradovate.OcoReq{
+				AccountID: 1,
+				Action:    tradovate.ActionSell,
+				Symbol:    "ESH5",
+				OrderQty:  1,
+				OrderType: tradovate.OrderTypeLimit,
+				Price:     5010,
+				Other:     &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeStop, StopPrice: 4990},
+			},
+			expected: `{"accountId":1,"action":"Sell","symbol":"ESH5","orderQty":1,"orderType":"Limit","price":5010,` +
+				`"other":{"action":"Sell","orderType":"Stop","stopPrice":4990}}`,
+		},
+		{
+			name: "oso with one bracket",
+			arg: &tradovate.OsoReq{
+				AccountSpec: "DEMO1",
+				ClientID:    "entry-1",
+				Action:      tradovate.ActionBuy,
+				Symbol:      "ESH5",
+				OrderQty:    2,
+				OrderType:   tradovate.OrderTypeMarket,
+				Bracket1: &tradovate.OtherOrder{
+					Action:      tradovate.ActionSell,
+					OrderType:   tradovate.OrderTypeLimit,
+					Price:       5020,
+					TimeInForce: tradovate.TifGTD,
+					ExpireTime:  expire,
+				},
+			},
+			expected: `{"accountSpec":"DEMO1","clOrdId":"entry-1","action":"Buy","symbol":"ESH5","orderQty":2,"orderType":"Market",` +
+				`"bracket1":{"action":"Sell","orderType":"Limit","price":5020,"timeInForce":"GTD","expireTime":"2025-03-10T21:00:00Z"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			buf, err := json.Marshal(tc.arg)
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			if got := string(buf); got != tc.expected {
+				tt.Errorf("wrong JSON\nwant %s\ngot  %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestBracketOrderValidate(mainTest *testing.T) {
+	stop := &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeStop, StopPrice: 4990}
+
+	oso := func(fn func(*tradovate.OsoReq)) *tradovate.OsoReq {
+		o := &tradovate.OsoReq{Action: tradovate.ActionBuy, Symbol: "ESH5", OrderQty: 1, OrderType: tradovate.OrderTypeMarket, Bracket1: stop}
+		fn(o)
+		return o
+	}
+
+	testCases := []struct {
+		name    string
+		arg     interface{ Validate() error }
+		invalid bool
+	}{
+		{name: "valid oso", arg: oso(func(*tradovate.OsoReq) {})},
+		{name: "limit without price", invalid: true, arg: oso(func(o *tradovate.OsoReq) { o.OrderType = tradovate.OrderTypeLimit })},
+		{name: "stop limit without stop", invalid: true, arg: oso(func(o *tradovate.OsoReq) {
+			o.OrderType, o.Price = tradovate.OrderTypeStopLimit, 5000
+		})},
+		{name: "bracket stop without stop", invalid: true, arg: oso(func(o *tradovate.OsoReq) {
+			o.Bracket1 = &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeStop}
+		})},
+		{name: "expire time without GTD", invalid: true, arg: oso(func(o *tradovate.OsoReq) { o.ExpireTime = time.Now() })},
+		{name: "GTD without expire time", invalid: true, arg: oso(func(o *tradovate.OsoReq) { o.TimeInForce = tradovate.TifGTD })},
+		{name: "bracket on the same side", invalid: true, arg: oso(func(o *tradovate.OsoReq) {
+			o.Bracket2 = &tradovate.OtherOrder{Action: tradovate.ActionBuy, OrderType: tradovate.OrderTypeLimit, Price: 4980}
+		})},
+		{name: "no brackets", invalid: true, arg: oso(func(o *tradovate.OsoReq) { o.Bracket1 = nil })},
+		{name: "oco without other", invalid: true, arg: &tradovate.OcoReq{
+			Action: tradovate.ActionSell, Symbol: "ESH5", OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: 5010,
+		}},
+		{name: "valid oco", arg: &tradovate.OcoReq{
+			Action: tradovate.ActionSell, Symbol: "ESH5", OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: 5010, Other: stop,
+		}},
+		{name: "MIT at its stop price", arg: &tradovate.OrderReq{
+			Action: tradovate.ActionBuy, Symbol: "ESH5", OrderQty: 1, OrderType: tradovate.OrderTypeMIT, StopPrice: 4990,
+		}},
+		{name: "MIT with only a price", invalid: true, arg: &tradovate.OrderReq{
+			Action: tradovate.ActionBuy, Symbol: "ESH5", OrderQty: 1, OrderType: tradovate.OrderTypeMIT, Price: 4990,
+		}},
+		{name: "order without quantity", invalid: true, arg: &tradovate.OrderReq{
+			Action: tradovate.ActionBuy, Symbol: "ESH5", OrderType: tradovate.OrderTypeMarket,
+		}},
+		{name: "day order with an expire time", invalid: true, arg: &tradovate.OrderReq{
+			Action: tradovate.ActionBuy, Symbol: "ESH5", OrderQty: 1, OrderType: tradovate.OrderTypeMarket, TimeInForce: tradovate.TifDay, ExpireTime: time.Now(),
+		}},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			err := tc.arg.Validate()
+			if tc.invalid != errors.Is(err, tradovate.ErrInvalidOrder) {
+				tt.Errorf("want invalid %v, got %v", tc.invalid, err)
+			}
+
+			if !tc.invalid && err != nil {
+				tt.Errorf("should be valid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOSO(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	req := &tradovate.OsoReq{
+		Action:    tradovate.ActionBuy,
+		Symbol:    "ESH5",
+		OrderQty:  1,
+		OrderType: tradovate.OrderTypeMarket,
+		Bracket1:  &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeLimit, Price: 5020},
+	}
+
+	srv.Respond("order/placeoso", http.StatusOK, map[string]any{"orderId": 1, "osoId1": 2})
+	resp, err := ws.OSO(ctx, req)
+	if err != nil || resp.OrderID != 1 || resp.Oso1ID != 2 {
+		t.Errorf("should succeed, got %+v %v", resp, err)
+	}
+
+	srv.Respond("order/placeoso", http.StatusOK, map[string]any{"failureReason": "TradingLocked", "failureText": "locked"})
+	var orderErr *tradovate.OrderErr
+	if _, err = ws.OSO(ctx, req); !errors.As(err, &orderErr) || orderErr.Reason != tradovate.OrderErrReasonTradingLocked {
+		t.Errorf("should fail with the order error, got %v", err)
+	}
+
+	req.Bracket1.Action = tradovate.ActionBuy
+	if _, err = ws.OSO(ctx, req); !errors.Is(err, tradovate.ErrInvalidOrder) {
+		t.Errorf("invalid orders shouldn't be sent, got %v", err)
+	}
+
+	if n := len(srv.RequestsFor("order/placeoso")); n != 2 {
+		t.Errorf("wanted 2 requests sent, got %d", n)
+	}
+}
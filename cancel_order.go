@@ -1,10 +1,18 @@
 package tradovate
 
-import "context"
+import (
+	"context"
+	"log/slog"
+)
 
 const cancelOrderURL = "order/cancelorder"
 
 func (s *WS) CancelOrder(ctx context.Context, orderID uint) (commandID uint, err error) {
+	ctx, sp := s.traceOrder(ctx, "CancelOrder", "", slog.Uint64("order.id", uint64(orderID)))
+	sp.ackOn("Canceled", "Rejected")
+	sp.watch(orderID)
+	defer func() { sp.done(err, slog.Uint64("command.id", uint64(commandID))) }()
+
 	type cancelResp struct {
 		Fail OrderErrReason `json:"failureReason"`
 		Text string         `json:"failureText"`
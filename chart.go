@@ -104,6 +104,14 @@ func (c *Chart) UnmarshalJSON(b []byte) error {
 		ID   int   `json:"id"`
 		Td   int   `json:"td"` // timestamp as an int. very interesting choice here
 		Bars []Bar `json:"bars"`
+		Eoh  bool  `json:"eoh"`
+
+		// tick charts
+		Source   string  `json:"s"`
+		Bp       int     `json:"bp"`
+		Bt       int64   `json:"bt"` // unix millis
+		TickSize float64 `json:"ts"`
+		Ticks    []Tick  `json:"tks"`
 	}
 
 	var cc chart
@@ -120,7 +128,16 @@ func (c *Chart) UnmarshalJSON(b []byte) error {
 			cc.Td%100,
 			0, 0, 0, 0, time.UTC,
 		),
-		Bars: cc.Bars,
+		Bars:         cc.Bars,
+		EndOfHistory: cc.Eoh,
+		Source:       cc.Source,
+		BasePrice:    cc.Bp,
+		TickSize:     cc.TickSize,
+		Ticks:        cc.Ticks,
+	}
+
+	if cc.Bt != 0 {
+		c.BaseTimestamp = time.UnixMilli(cc.Bt)
 	}
 	return nil
 }
@@ -134,6 +151,16 @@ func (s *WS) GetChartID(ctx context.Context, id int, r *ChartReq) (ChartResp, er
 }
 
 func (s *WS) getChart(ctx context.Context, x string, r *ChartReq) (ChartResp, error) {
+	resp, err := s.requestChart(ctx, false, x, r)
+	if err != nil {
+		return ChartResp{}, err
+	}
+
+	s.md.addChart(x, r, resp)
+	return resp, nil
+}
+
+func (s *WS) requestChart(ctx context.Context, skipDrain bool, x string, r *ChartReq) (ChartResp, error) {
 	type chartDesc struct {
 		UnderlyingType  ChartType `json:"underlyingType,omitzero"`
 		ElementSize     uint32    `json:"elementSize,omitzero"`
@@ -179,7 +206,7 @@ func (s *WS) getChart(ctx context.Context, x string, r *ChartReq) (ChartResp, er
 	}
 
 	var resp chartResp
-	if err := s.do(ctx, getChart, nil, &c, &resp); err != nil {
+	if err := s.send(ctx, skipDrain, getChart, nil, &c, &resp); err != nil {
 		return ChartResp{}, err
 	}
 
@@ -195,5 +222,9 @@ func (s *WS) getChart(ctx context.Context, x string, r *ChartReq) (ChartResp, er
 
 // Cancel a chart subscription given the historicalId from ChartResp
 func (s *WS) CancelChart(ctx context.Context, id int) error {
-	return s.do(ctx, cancelChart, nil, map[string]any{"subscriptionId": id}, nil)
+	cur, ok := s.md.removeChart(id)
+	if !ok {
+		return nil
+	}
+	return s.do(ctx, cancelChart, nil, map[string]any{"subscriptionId": cur}, nil)
 }
@@ -0,0 +1,183 @@
+package tradovate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	listCommandsPath = "command/list"
+
+	maxClOrdID = 64 // tradovate's limit
+)
+
+// ClOrdIDGen makes client order IDs. IDs must be unique and at most 64
+// characters, and it must be safe for concurrent use
+type ClOrdIDGen func() string
+
+// Generate clOrdIds like ema-cross-3f9a1c2e-42: the strategy, a random
+// session so IDs don't repeat across restarts, and a sequence. The
+// strategy's optional and is cut short to fit 64 characters. Parse them
+// with ParseClOrdID
+func NewClOrdIDGen(strategy string) ClOrdIDGen {
+	var b [4]byte
+	rand.Read(b[:])
+	prefix := hex.EncodeToString(b[:]) + "-"
+
+	// leave room for the session, the separators and any uint64
+	if n := maxClOrdID - len(prefix) - 1 - len(strconv.FormatUint(^uint64(0), 10)); len(strategy) > n {
+		strategy = strategy[:n]
+	}
+
+	if strategy != "" {
+		prefix = strategy + "-" + prefix
+	}
+
+	var seq atomic.Uint64
+	return func() string { return prefix + strconv.FormatUint(seq.Add(1), 10) }
+}
+
+// Split an ID made by NewClOrdIDGen into its strategy and sequence
+func ParseClOrdID(id string) (strategy string, seq uint64, ok bool) {
+	rest, n, found := cutLast(id, "-")
+	if !found {
+		return "", 0, false
+	}
+
+	seq, err := strconv.ParseUint(n, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	strategy, session, found := cutLast(rest, "-")
+	if !found {
+		strategy, session = "", rest
+	}
+
+	if _, err = hex.DecodeString(session); err != nil || len(session) != 8 {
+		return "", 0, false
+	}
+
+	return strategy, seq, true
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+// Give orders placed without a clOrdId one from gen, e.g.
+// NewClOrdIDGen("my-strategy"); the ID is written back to the request.
+// Off by default, so orders are sent as they're given
+func WithClOrdIDs(gen ClOrdIDGen) WSOpt {
+	return func(s *WS) { s.clOrdGen = gen }
+}
+
+// Order ID of the order placed with a clOrdId. Known from the orders
+// this socket placed, Command entities, and ListCommands, until an Order
+// entity says it's finished
+func (s *WS) OrderIDFor(clOrdID string) (uint, bool) {
+	s.clOrds.mu.Lock()
+	defer s.clOrds.mu.Unlock()
+	id, ok := s.clOrds.byClOrd[clOrdID]
+	return id, ok
+}
+
+// The clOrdId an order was placed with. See OrderIDFor
+func (s *WS) ClOrdIDFor(orderID uint) (string, bool) {
+	s.clOrds.mu.Lock()
+	defer s.clOrds.mu.Unlock()
+	id, ok := s.clOrds.byOrder[orderID]
+	return id, ok
+}
+
+// Every command the server has for the session: places, modifies and
+// cancels. Their clOrdIds are added to the index, so after a reconnect
+// this is how to find out what became of orders placed under your IDs
+func (s *WS) ListCommands(ctx context.Context) ([]*Command, error) {
+	var x []*Command
+	if err := s.do(ctx, listCommandsPath, nil, nil, &x); err != nil {
+		return nil, err
+	}
+
+	for _, v := range x {
+		s.clOrds.command(v)
+	}
+	return x, nil
+}
+
+// fill in an empty clOrdId
+func (s *WS) assignClOrdID(id *string) {
+	if *id == "" && s.clOrdGen != nil {
+		*id = s.clOrdGen()
+	}
+}
+
+// clOrdId <-> order ID, both ways
+type clOrdIndex struct {
+	mu      sync.Mutex
+	byClOrd map[string]uint
+	byOrder map[uint]string // the clOrdId it was placed with
+}
+
+func (c *clOrdIndex) add(clOrdID string, orderID uint, placed bool) {
+	if clOrdID == "" || orderID == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.byClOrd == nil {
+		c.byClOrd, c.byOrder = map[string]uint{}, map[uint]string{}
+	}
+
+	c.byClOrd[clOrdID] = orderID
+	if _, ok := c.byOrder[orderID]; placed || !ok {
+		c.byOrder[orderID] = clOrdID
+	}
+}
+
+// modifies and cancels can carry their own clOrdIds; those find the
+// order but don't replace the one it was placed with
+func (c *clOrdIndex) command(x *Command) {
+	c.add(x.ClOrdID, x.OrderID, x.CommandType == "New")
+}
+
+func (c *clOrdIndex) entity(e *EntityMsg) {
+	switch e.Type {
+	case EntityTypeCommand:
+		if x, err := e.Command(); err == nil {
+			c.command(x)
+		}
+	case EntityTypeOrder:
+		if x, err := e.Order(); err == nil && finalStatus(x.Status) {
+			c.remove(x.ID)
+		}
+	}
+}
+
+// a finished order, along with every clOrdId that found it
+func (c *clOrdIndex) remove(orderID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.byOrder[orderID]; !ok {
+		return
+	}
+
+	delete(c.byOrder, orderID)
+	for k, v := range c.byClOrd {
+		if v == orderID {
+			delete(c.byClOrd, k)
+		}
+	}
+}
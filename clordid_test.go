@@ -0,0 +1,154 @@
+package tradovate_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestClOrdIDGen(mainTest *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy string
+		expected string
+	}{
+		{name: "no strategy"},
+		{name: "strategy", strategy: "ema-cross", expected: "ema-cross"},
+		{name: "long strategy is cut", strategy: strings.Repeat("x", 100), expected: strings.Repeat("x", 34)},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			gen := tradovate.NewClOrdIDGen(tc.strategy)
+
+			seen := map[string]bool{}
+			for i := range 3 {
+				id := gen()
+				if len(id) > 64 || seen[id] {
+					tt.Fatalf("IDs should be unique and at most 64 chars, got %q", id)
+				}
+				seen[id] = true
+
+				strategy, seq, ok := tradovate.ParseClOrdID(id)
+				if !ok || strategy != tc.expected || seq != uint64(i+1) {
+					tt.Errorf("wrong parse of %q: %q %d %v", id, strategy, seq, ok)
+				}
+			}
+		})
+	}
+
+	if _, _, ok := tradovate.ParseClOrdID("asdjoisad"); ok {
+		mainTest.Error("IDs from elsewhere shouldn't parse")
+	}
+}
+
+func TestClOrdIndex(t *testing.T) {
+	srv := tradovatetest.NewServer(
+		tradovatetest.WithHandler("command/list", func(*tradovatetest.Request) tradovatetest.Response {
+			return tradovatetest.Response{Status: http.StatusOK, Body: []*tradovate.Command{
+				{ID: 1, OrderID: 30, ClOrdID: "before-reconnect", CommandType: "New"},
+				{ID: 2, OrderID: 30, ClOrdID: "modify-1", CommandType: "Modify"},
+			}}
+		}),
+	)
+	defer srv.Close()
+	srv.Respond("order/placeorder", http.StatusOK, map[string]any{"orderId": 10})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entities := make(chan *tradovate.EntityMsg, 1)
+	plain, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer plain.Close()
+
+	req := &tradovate.OrderReq{Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeMarket}
+	if _, err = plain.PlaceOrder(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.ClientOrderID != "" {
+		t.Fatalf("clOrdIds are opt in, got %q", req.ClientOrderID)
+	}
+
+	ws, err := srv.NewSocket(ctx,
+		tradovate.WithClOrdIDs(tradovate.NewClOrdIDGen("test")),
+		tradovate.WithEntityHandler(func(e *tradovate.EntityMsg) { entities <- e }),
+	)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	if _, err = ws.PlaceOrder(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.ClientOrderID == "" {
+		t.Fatal("clOrdId should be assigned")
+	}
+
+	if id, ok := ws.OrderIDFor(req.ClientOrderID); !ok || id != 10 {
+		t.Errorf("placed order should be indexed, got %d %v", id, ok)
+	}
+
+	err = srv.PushEntity(tradovate.EntityTypeCommand, tradovate.EventTypeCreated, &tradovate.Command{ID: 3, OrderID: 20, ClOrdID: "from-elsewhere", CommandType: "New"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("never received command")
+	case <-entities:
+	}
+
+	if id, ok := ws.ClOrdIDFor(20); !ok || id != "from-elsewhere" {
+		t.Errorf("command entities should be indexed, got %q %v", id, ok)
+	}
+
+	if _, err = ws.ListCommands(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if id, ok := ws.OrderIDFor("modify-1"); !ok || id != 30 {
+		t.Errorf("modify clOrdIds should find the order, got %d %v", id, ok)
+	}
+
+	if id, _ := ws.ClOrdIDFor(30); id != "before-reconnect" {
+		t.Errorf("orders should keep the clOrdId they were placed with, got %q", id)
+	}
+
+	// finished orders are dropped, with every clOrdId that found them
+	err = srv.PushEntity(tradovate.EntityTypeOrder, tradovate.EventTypeUpdated, &tradovate.Order{ID: 30, Status: tradovate.OrderStatusFilled})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("never received order")
+	case <-entities:
+	}
+
+	for _, v := range []string{"before-reconnect", "modify-1"} {
+		if id, ok := ws.OrderIDFor(v); ok {
+			t.Errorf("%s should be dropped once its order's filled, got %d", v, id)
+		}
+	}
+
+	if _, ok := ws.ClOrdIDFor(30); ok {
+		t.Error("a filled order should be dropped from the index")
+	}
+
+	if _, ok := ws.OrderIDFor(req.ClientOrderID); !ok {
+		t.Error("orders still working should stay indexed")
+	}
+}
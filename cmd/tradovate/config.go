@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+type config struct {
+	Env        string        `yaml:"env"` // demo or live, defaults to demo
+	Timeout    time.Duration `yaml:"timeout"`
+	TokenCache string        `yaml:"token-cache"`
+	URLs       urls          `yaml:"urls"`
+	Account    account       `yaml:"account"`
+	Creds      creds         `yaml:"creds"`
+}
+
+// overrides for the env's URLs
+type urls struct {
+	REST       string `yaml:"rest"`
+	API        string `yaml:"api"`
+	MarketData string `yaml:"md"`
+}
+
+type account struct {
+	Spec string `yaml:"spec"`
+	ID   uint   `yaml:"id"`
+}
+
+type creds struct {
+	Name     string    `yaml:"name"`
+	App      string    `yaml:"appId"`
+	Version  string    `yaml:"appVersion"`
+	DeviceID uuid.UUID `yaml:"deviceID"`
+	Password string    `yaml:"password"`
+	ClientID string    `yaml:"client-id"`
+	Secret   uuid.UUID `yaml:"secret"`
+}
+
+func defaultConfigPath() string {
+	if x := os.Getenv("TRADOVATE_CONFIG"); x != "" {
+		return x
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.yaml"
+	}
+
+	return filepath.Join(dir, "tradovate", "config.yaml")
+}
+
+func readConfig(path string) (*config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading config: %w", err)
+	}
+
+	var c config
+	if err = yaml.Unmarshal(buf, &c); err != nil {
+		return nil, fmt.Errorf("config %s is invalid: %w", path, err)
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+
+	if c.TokenCache == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			dir = os.TempDir()
+		}
+		c.TokenCache = filepath.Join(dir, "tradovate", "token.json")
+	}
+
+	var u urls
+	switch c.Env {
+	case "", "demo":
+		u = urls{tradovate.RESTStage, tradovate.WSSSandboxURL, tradovate.WSSMarketDataSandboxURL}
+	case "live":
+		u = urls{tradovate.RESTProd, tradovate.WSSLiveURL, tradovate.WSSMarketDataURL}
+	default:
+		return nil, fmt.Errorf("env must be demo or live, got %s", c.Env)
+	}
+
+	if c.URLs.REST == "" {
+		c.URLs.REST = u.REST
+	}
+
+	if c.URLs.API == "" {
+		c.URLs.API = u.API
+	}
+
+	if c.URLs.MarketData == "" {
+		c.URLs.MarketData = u.MarketData
+	}
+
+	return &c, nil
+}
+
+func (c *config) rest() *tradovate.REST {
+	return tradovate.NewREST(c.URLs.REST, &http.Client{Timeout: c.Timeout}, &tradovate.Creds{
+		Name:       c.Creds.Name,
+		Password:   c.Creds.Password,
+		AppID:      c.Creds.App,
+		AppVersion: c.Creds.Version,
+		ClientID:   c.Creds.ClientID,
+		DeviceID:   c.Creds.DeviceID,
+		Secret:     c.Creds.Secret,
+	})
+}
+
+func (c *config) readToken() *tradovate.Token {
+	buf, err := os.ReadFile(c.TokenCache)
+	if err != nil {
+		return nil
+	}
+
+	var t tradovate.Token
+	if err = json.Unmarshal(buf, &t); err != nil || t.Expired() {
+		return nil
+	}
+
+	return &t
+}
+
+func (c *config) writeToken(t *tradovate.Token) error {
+	buf, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(c.TokenCache), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.TokenCache, buf, 0600)
+}
+
+// cache whatever token the client ended up with, if it's new
+func (c *config) saveToken(r *tradovate.REST, old *tradovate.Token) error {
+	t := r.CachedToken()
+	if t == nil || t.Expired() {
+		return nil // never fetched one, nothing to save
+	}
+
+	if old != nil && old.AccessToken == t.AccessToken {
+		return nil
+	}
+
+	if err := c.writeToken(t); err != nil {
+		return fmt.Errorf("failed caching token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+// connect to the API, run fn and print the list it returns
+func list[T any](ctx context.Context, a *app, name string, args []string, fn func(*tradovate.WS, context.Context) ([]T, error), cols []column[T]) error {
+	fs := a.flags(name)
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+
+	p, err := a.printer()
+	if err != nil {
+		return err
+	}
+
+	ws, err := a.api(ctx)
+	if err != nil {
+		return err
+	}
+
+	x, err := fn(ws, ctx)
+	if err != nil {
+		return err
+	}
+
+	return printAll(p, x, cols)
+}
+
+func listAccounts(ctx context.Context, a *app, args []string) error {
+	return list(ctx, a, "accounts", args, (*tradovate.WS).ListAccounts, []column[*tradovate.Account]{
+		{"id", func(x *tradovate.Account) string { return num(x.ID) }},
+		{"name", func(x *tradovate.Account) string { return x.Name }},
+		{"type", func(x *tradovate.Account) string { return x.AccountType.String() }},
+		{"margin", func(x *tradovate.Account) string { return x.MarginAccountType.String() }},
+		{"active", func(x *tradovate.Account) string { return fmt.Sprint(x.Active) }},
+		{"readonly", func(x *tradovate.Account) string { return fmt.Sprint(x.Readonly) }},
+	})
+}
+
+func listPositions(ctx context.Context, a *app, args []string) error {
+	return list(ctx, a, "positions", args, (*tradovate.WS).ListPositions, []column[*tradovate.Position]{
+		{"id", func(x *tradovate.Position) string { return num(x.ID) }},
+		{"account", func(x *tradovate.Position) string { return num(x.AccountID) }},
+		{"contract", func(x *tradovate.Position) string { return num(x.ContractID) }},
+		{"net pos", func(x *tradovate.Position) string { return num(x.NetPos) }},
+		{"net price", func(x *tradovate.Position) string { return float(x.NetPrice) }},
+		{"bought", func(x *tradovate.Position) string { return num(x.Bought) }},
+		{"sold", func(x *tradovate.Position) string { return num(x.Sold) }},
+		{"trade date", func(x *tradovate.Position) string { return date(x.TradeDate) }},
+	})
+}
+
+func listOrders(ctx context.Context, a *app, args []string) error {
+	return list(ctx, a, "orders", args, (*tradovate.WS).ListOrders, []column[*tradovate.Order]{
+		{"id", func(x *tradovate.Order) string { return num(x.ID) }},
+		{"account", func(x *tradovate.Order) string { return num(x.AccountID) }},
+		{"contract", func(x *tradovate.Order) string { return num(x.ContractID) }},
+		{"action", func(x *tradovate.Order) string { return x.Action.String() }},
+		{"status", func(x *tradovate.Order) string { return x.Status.String() }},
+		{"timestamp", func(x *tradovate.Order) string { return ts(x.Timestamp) }},
+	})
+}
+
+func listFills(ctx context.Context, a *app, args []string) error {
+	return list(ctx, a, "fills", args, (*tradovate.WS).ListFills, []column[*tradovate.Fill]{
+		{"id", func(x *tradovate.Fill) string { return num(x.ID) }},
+		{"order", func(x *tradovate.Fill) string { return num(x.OrderID) }},
+		{"contract", func(x *tradovate.Fill) string { return num(x.ContractID) }},
+		{"action", func(x *tradovate.Fill) string { return x.Action.String() }},
+		{"qty", func(x *tradovate.Fill) string { return num(x.Qty) }},
+		{"price", func(x *tradovate.Fill) string { return float(x.Price) }},
+		{"timestamp", func(x *tradovate.Fill) string { return ts(x.Timestamp) }},
+	})
+}
+
+func tailEvents(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("tail-events")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+
+	p, err := a.printer()
+	if err != nil {
+		return err
+	}
+
+	type event struct {
+		Received time.Time            `json:"received"`
+		Event    tradovate.EventType  `json:"eventType"`
+		Type     tradovate.EntityType `json:"entityType"`
+		Entity   json.RawMessage      `json:"entity"`
+	}
+
+	events := make(chan *tradovate.EntityMsg, 64)
+	if _, err = a.api(ctx, tradovate.WithEntityHandler(func(e *tradovate.EntityMsg) {
+		select {
+		case events <- e:
+		case <-ctx.Done():
+		}
+	})); err != nil {
+		return err
+	}
+
+	cols := []column[event]{
+		{"received", func(e event) string { return ts(e.Received) }},
+		{"event", func(e event) string { return e.Event.String() }},
+		{"type", func(e event) string { return e.Type.String() }},
+		{"entity", func(e event) string { return string(e.Entity) }},
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-events:
+			err = printOne(p, event{time.Now(), e.Event, e.Type, e.Data}, cols)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Command tradovate runs everyday account operations against the tradovate
+// API: listing accounts, positions, orders and fills, placing and canceling
+// orders, and streaming market data and account events.
+//
+// Configuration is read from a YAML file; see config.template.yaml. Every
+// command accepts -o to pick table, json or csv output, and every order
+// command accepts -dry-run to print what would be sent without sending it
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+type command struct {
+	usage string
+	desc  string
+	run   func(ctx context.Context, a *app, args []string) error
+}
+
+func commands() map[string]command {
+	return map[string]command{
+		"login":       {"login", "fetch a fresh token and cache it", login},
+		"accounts":    {"accounts", "list accounts", listAccounts},
+		"positions":   {"positions", "list positions", listPositions},
+		"orders":      {"orders", "list orders", listOrders},
+		"fills":       {"fills", "list fills", listFills},
+		"place":       {"place -symbol SYM -action buy|sell -qty N [-type T] [-price P] [-stop P] [-tif T]", "place an order", place},
+		"cancel":      {"cancel ORDER_ID", "cancel an order", cancel},
+		"modify":      {"modify ORDER_ID -qty N -type T [-price P] [-stop P] [-tif T]", "modify a working order", modify},
+		"flatten":     {"flatten [SYMBOL]", "liquidate the position in SYMBOL, or every open position", flatten},
+		"quote":       {"quote SYMBOL", "stream quotes", quote},
+		"dom":         {"dom SYMBOL [-depth N]", "stream depth of market", dom},
+		"bars":        {"bars SYMBOL -from T -to T [-interval D]", "fetch historical bars", bars},
+		"tail-events": {"tail-events", "print account events as they happen", tailEvents},
+	}
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "tradovate:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	a := &app{stdout: stdout, stderr: stderr}
+
+	fs := a.flags("tradovate")
+	fs.Usage = func() { usage(stderr, fs) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	cmd, ok := commands()[fs.Arg(0)]
+	if !ok {
+		fs.Usage()
+		return fmt.Errorf("unknown command %s", fs.Arg(0))
+	}
+
+	defer a.close()
+	return cmd.run(ctx, a, fs.Args()[1:])
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "usage: tradovate [-config FILE] [-o table|json|csv] COMMAND\n\ncommands:\n")
+
+	cmds := commands()
+	names := make([]string, 0, len(cmds))
+	for k := range cmds {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, v := range names {
+		fmt.Fprintf(w, "  %s\n    \t%s\n", cmds[v].usage, cmds[v].desc)
+	}
+
+	fmt.Fprintln(w, "\nflags:")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// state shared by all commands
+type app struct {
+	stdout, stderr io.Writer
+	configPath     string
+	format         string
+
+	cfg   *config
+	rest  *tradovate.REST
+	token *tradovate.Token // token read from the cache, if any
+	socks []*tradovate.WS
+	errMu sync.Mutex // socket error handlers run concurrently
+}
+
+// flag set with the flags every command accepts
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+
+	if a.configPath == "" {
+		a.configPath = defaultConfigPath()
+	}
+
+	if a.format == "" {
+		a.format = formatTable
+	}
+
+	fs.StringVar(&a.configPath, "config", a.configPath, "config file; defaults to $TRADOVATE_CONFIG")
+	fs.StringVar(&a.format, "o", a.format, "output format: table, json or csv")
+	return fs
+}
+
+// parse flags that may come before or after positional args, e.g.
+// bars ESZ5 -from 2025-01-02
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func (a *app) printer() (*printer, error) { return newPrinter(a.stdout, a.format) }
+
+func (a *app) config() (*config, error) {
+	if a.cfg != nil {
+		return a.cfg, nil
+	}
+
+	c, err := readConfig(a.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	a.cfg = c
+	return c, nil
+}
+
+func (a *app) client() (*tradovate.REST, error) {
+	if a.rest != nil {
+		return a.rest, nil
+	}
+
+	c, err := a.config()
+	if err != nil {
+		return nil, err
+	}
+
+	a.rest = c.rest()
+	if a.token = c.readToken(); a.token != nil {
+		a.rest.SetToken(a.token)
+	}
+
+	return a.rest, nil
+}
+
+// socket to the trading API
+func (a *app) api(ctx context.Context, opts ...tradovate.WSOpt) (*tradovate.WS, error) {
+	return a.socket(ctx, func(c *config) string { return c.URLs.API }, opts...)
+}
+
+// socket to the market data API
+func (a *app) md(ctx context.Context, opts ...tradovate.WSOpt) (*tradovate.WS, error) {
+	return a.socket(ctx, func(c *config) string { return c.URLs.MarketData }, opts...)
+}
+
+func (a *app) socket(ctx context.Context, uri func(*config) string, opts ...tradovate.WSOpt) (*tradovate.WS, error) {
+	r, err := a.client()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append([]tradovate.WSOpt{
+		tradovate.WithTimeout(a.cfg.Timeout),
+		tradovate.WithErrHandler(func(err error) {
+			a.errMu.Lock()
+			defer a.errMu.Unlock()
+			fmt.Fprintln(a.stderr, "socket error:", err)
+		}),
+	}, opts...)
+
+	ws, err := tradovate.NewSocket(ctx, uri(a.cfg), nil, r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	a.socks = append(a.socks, ws)
+	return ws, nil
+}
+
+func (a *app) close() {
+	for _, v := range a.socks {
+		v.Close()
+	}
+
+	if a.rest != nil {
+		if err := a.cfg.saveToken(a.rest, a.token); err != nil {
+			fmt.Fprintln(a.stderr, err)
+		}
+	}
+}
+
+func login(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("login")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+
+	c, err := a.config()
+	if err != nil {
+		return err
+	}
+
+	// skip the cache, the point is a fresh token
+	r := c.rest()
+	t, err := r.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = c.writeToken(t); err != nil {
+		return fmt.Errorf("failed caching token: %w", err)
+	}
+
+	p, err := a.printer()
+	if err != nil {
+		return err
+	}
+
+	// don't print the token itself
+	type session struct {
+		User    string    `json:"user"`
+		UserID  int       `json:"userId"`
+		Status  string    `json:"status"`
+		Expires time.Time `json:"expires"`
+	}
+
+	return printAll(p, []session{{t.Name, t.UserID, t.UserStatus, t.ExpirationTime}}, []column[session]{
+		{"user", func(s session) string { return s.User }},
+		{"user id", func(s session) string { return num(s.UserID) }},
+		{"status", func(s session) string { return s.Status }},
+		{"expires", func(s session) string { return ts(s.Expires) }},
+	})
+}
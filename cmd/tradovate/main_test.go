@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestRun(mainTest *testing.T) {
+	s := tradovatetest.NewServer()
+	defer s.Close()
+
+	s.SetAccounts(&tradovate.Account{ID: 1, Name: "DEMO1", Active: true})
+	s.SetContracts(&tradovate.Contract{ID: 7, Name: "ESZ5"})
+	s.SetPositions(
+		&tradovate.Position{ID: 1, AccountID: 1, ContractID: 7, NetPos: 2},
+		&tradovate.Position{ID: 2, AccountID: 1, ContractID: 8, NetPos: -1},
+		&tradovate.Position{ID: 3, AccountID: 2, ContractID: 7, NetPos: 5},
+	)
+	s.SetFills(&tradovate.Fill{ID: 4, OrderID: 3, ContractID: 7, Action: tradovate.ActionBuy, Qty: 2, Price: 5000.25})
+	s.Respond("order/liquidateposition", 200, map[string]any{"orderId": 99})
+
+	dir := mainTest.TempDir()
+	cfg := filepath.Join(dir, "config.yaml")
+	err := os.WriteFile(cfg, fmt.Appendf(nil, `
+timeout: 2s
+token-cache: %s
+urls:
+  rest: %s
+  api: %s
+  md: %s
+account:
+  spec: DEMO1
+  id: 1
+`, filepath.Join(dir, "token.json"), s.URL(), s.WSURL(), s.WSURL()), 0600)
+	if err != nil {
+		mainTest.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		args        []string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "accounts as csv",
+			args:     []string{"-o", "csv", "accounts"},
+			expected: "id,name,type,margin,active,readonly\n1,DEMO1,Unspecified,Unspecified,true,false\n",
+		},
+		{
+			name:     "fills as a table",
+			args:     []string{"fills"},
+			expected: "id  order  contract  action  qty  price    timestamp\n4   3      7         Buy     2    5000.25  \n",
+		},
+		{
+			name:     "output flag after the command",
+			args:     []string{"fills", "-o", "json"},
+			expected: `"price": 5000.25`,
+		},
+		{
+			name:     "dry run doesn't send the order",
+			args:     []string{"place", "-symbol", "ESZ5", "-action", "buy", "-qty", "1", "-type", "limit", "-price", "5000", "-dry-run"},
+			expected: `dry run, not sending order/placeorder {"accountSpec":"DEMO1","accountId":1,"action":"Buy","symbol":"ESZ5","orderQty":1,"orderType":"Limit","price":5000,"timeInForce":"Day","isAutomated":true}` + "\n",
+		},
+		{
+			name:     "flatten one symbol only touches that contract in the configured account",
+			args:     []string{"flatten", "ESZ5", "-dry-run"},
+			expected: `dry run, not sending order/liquidateposition {"accountId":1,"admin":false,"contractId":7}` + "\n",
+		},
+		{
+			name:     "flatten everything",
+			args:     []string{"-o", "csv", "flatten"},
+			expected: "order id\n99\n99\n",
+		},
+		{
+			name:        "place validates before connecting",
+			args:        []string{"place", "-symbol", "ESZ5", "-action", "hold", "-qty", "1"},
+			expectedErr: `-action must be buy or sell, got "hold"`,
+		},
+		{
+			name:        "unknown command",
+			args:        []string{"nope"},
+			expectedErr: "unknown command nope",
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			var stdout, stderr bytes.Buffer
+			actualErr := run(ctx, append([]string{"-config", cfg}, tc.args...), &stdout, &stderr)
+
+			if tc.expectedErr != "" {
+				if actualErr == nil || actualErr.Error() != tc.expectedErr {
+					tt.Errorf("wanted err %v but got %v", tc.expectedErr, actualErr)
+				}
+				return
+			}
+
+			if actualErr != nil {
+				tt.Errorf("wanted no error, but got %v (stderr: %s)", actualErr, stderr.String())
+				return
+			}
+
+			if actual := stdout.String(); actual != tc.expected && !strings.Contains(actual, tc.expected) {
+				tt.Errorf("want: %q\n got: %q", tc.expected, actual)
+			}
+		})
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "token.json")); err != nil {
+		mainTest.Errorf("token should have been cached: %v", err)
+	}
+}
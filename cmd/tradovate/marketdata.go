@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %s, use RFC3339 or YYYY-MM-DD", s)
+}
+
+func symbol(pos []string) (string, error) {
+	if len(pos) != 1 {
+		return "", fmt.Errorf("expected exactly one symbol, got %v", pos)
+	}
+	return pos[0], nil
+}
+
+// subscribe to market data and print every update fn pulls out of it
+// until ctx is canceled
+func stream[T any](ctx context.Context, a *app, sub func(*tradovate.WS) error, fn func(*tradovate.MarketData) []T, cols []column[T]) error {
+	p, err := a.printer()
+	if err != nil {
+		return err
+	}
+
+	updates := make(chan *tradovate.MarketData, 64)
+	ws, err := a.md(ctx, tradovate.WithMarketDataHandler(func(md *tradovate.MarketData) {
+		select {
+		case updates <- md:
+		case <-ctx.Done():
+		}
+	}))
+	if err != nil {
+		return err
+	}
+
+	if err = sub(ws); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case md := <-updates:
+			for _, v := range fn(md) {
+				if err = printOne(p, v, cols); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func quote(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("quote")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	sym, err := symbol(pos)
+	if err != nil {
+		return err
+	}
+
+	return stream(ctx, a,
+		func(ws *tradovate.WS) error {
+			_, err := ws.SubscribeQuoteSymbol(ctx, sym)
+			return err
+		},
+		func(md *tradovate.MarketData) []*tradovate.Quote { return md.Quotes },
+		[]column[*tradovate.Quote]{
+			{"timestamp", func(q *tradovate.Quote) string { return ts(q.Timestamp) }},
+			{"contract", func(q *tradovate.Quote) string { return num(q.ContractID) }},
+			{"bid", func(q *tradovate.Quote) string { return float(q.Bid.Price) }},
+			{"bid size", func(q *tradovate.Quote) string { return float(q.Bid.Size) }},
+			{"offer", func(q *tradovate.Quote) string { return float(q.Offer.Price) }},
+			{"offer size", func(q *tradovate.Quote) string { return float(q.Offer.Size) }},
+			{"trade", func(q *tradovate.Quote) string { return float(q.Trade.Price) }},
+			{"trade size", func(q *tradovate.Quote) string { return float(q.Trade.Size) }},
+			{"volume", func(q *tradovate.Quote) string { return float(q.TotalTradeVolume) }},
+		},
+	)
+}
+
+func dom(ctx context.Context, a *app, args []string) error {
+	var depth int
+	fs := a.flags("dom")
+	fs.IntVar(&depth, "depth", 5, "levels to print on each side")
+
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	sym, err := symbol(pos)
+	if err != nil {
+		return err
+	}
+
+	// one row per level, best first
+	type level struct {
+		Timestamp  time.Time          `json:"timestamp"`
+		ContractID int                `json:"contractId"`
+		Level      int                `json:"level"`
+		Bid        tradovate.PriceQty `json:"bid"`
+		Offer      tradovate.PriceQty `json:"offer"`
+	}
+
+	return stream(ctx, a,
+		func(ws *tradovate.WS) error { return ws.SubscribeDOMSymbol(ctx, sym) },
+		func(md *tradovate.MarketData) []level {
+			var x []level
+			for _, d := range md.DOMs {
+				for i := 0; i < depth && (i < len(d.Bids) || i < len(d.Offers)); i++ {
+					l := level{Timestamp: d.Timestamp, ContractID: d.ContractID, Level: i + 1}
+					if i < len(d.Bids) {
+						l.Bid = d.Bids[i]
+					}
+					if i < len(d.Offers) {
+						l.Offer = d.Offers[i]
+					}
+					x = append(x, l)
+				}
+			}
+			return x
+		},
+		[]column[level]{
+			{"timestamp", func(l level) string { return ts(l.Timestamp) }},
+			{"contract", func(l level) string { return num(l.ContractID) }},
+			{"level", func(l level) string { return num(l.Level) }},
+			{"bid size", func(l level) string { return float(l.Bid.Size) }},
+			{"bid", func(l level) string { return float(l.Bid.Price) }},
+			{"offer", func(l level) string { return float(l.Offer.Price) }},
+			{"offer size", func(l level) string { return float(l.Offer.Size) }},
+		},
+	)
+}
+
+func bars(ctx context.Context, a *app, args []string) error {
+	var (
+		from, to string
+		interval time.Duration
+	)
+
+	fs := a.flags("bars")
+	fs.StringVar(&from, "from", "", "start time, RFC3339 or YYYY-MM-DD")
+	fs.StringVar(&to, "to", "", "end time, RFC3339 or YYYY-MM-DD; defaults to now")
+	fs.DurationVar(&interval, "interval", time.Minute, "bar size: minutes, or a multiple of 24h for daily bars")
+
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	sym, err := symbol(pos)
+	if err != nil {
+		return err
+	}
+
+	req, start, end, err := barsReq(from, to, interval)
+	if err != nil {
+		return err
+	}
+
+	p, err := a.printer()
+	if err != nil {
+		return err
+	}
+
+	charts := make(chan *tradovate.Chart, 64)
+	ws, err := a.md(ctx, tradovate.WithChartHandler(func(c *tradovate.Chart) {
+		select {
+		case charts <- c:
+		case <-ctx.Done():
+		}
+	}))
+	if err != nil {
+		return err
+	}
+
+	resp, err := ws.GetChartSymbol(ctx, sym, req)
+	if err != nil {
+		return err
+	}
+	defer ws.CancelChart(context.WithoutCancel(ctx), resp.HistoricalID)
+
+	var x []tradovate.Bar
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(a.cfg.Timeout):
+			return fmt.Errorf("timed out waiting for bars after %s", a.cfg.Timeout)
+		case c := <-charts:
+			if c.ID != resp.HistoricalID {
+				continue
+			}
+
+			for _, b := range c.Bars {
+				if !b.Timestamp.Before(start) && !b.Timestamp.After(end) {
+					x = append(x, b)
+				}
+			}
+			done = c.EndOfHistory
+		}
+	}
+
+	return printAll(p, x, []column[tradovate.Bar]{
+		{"timestamp", func(b tradovate.Bar) string { return ts(b.Timestamp) }},
+		{"open", func(b tradovate.Bar) string { return float(b.Open) }},
+		{"high", func(b tradovate.Bar) string { return float(b.High) }},
+		{"low", func(b tradovate.Bar) string { return float(b.Low) }},
+		{"close", func(b tradovate.Bar) string { return float(b.Close) }},
+		{"volume", func(b tradovate.Bar) string { return float(b.UpVolume + b.DownVolume) }},
+	})
+}
+
+func barsReq(from, to string, interval time.Duration) (r *tradovate.ChartReq, start, end time.Time, err error) {
+	if from == "" {
+		return nil, start, end, fmt.Errorf("-from is required")
+	}
+
+	if start, err = parseTime(from); err != nil {
+		return nil, start, end, err
+	}
+
+	end = time.Now()
+	if to != "" {
+		if end, err = parseTime(to); err != nil {
+			return nil, start, end, err
+		}
+	}
+
+	if !start.Before(end) {
+		return nil, start, end, fmt.Errorf("-from must be before -to")
+	}
+
+	r = &tradovate.ChartReq{
+		ElementSizeUnit:  tradovate.SizeUnitUnderlyingUnits,
+		ClosestTimestamp: end,
+		AsFarAsTimestamp: start,
+	}
+
+	switch day := 24 * time.Hour; {
+	case interval >= day && interval%day == 0:
+		r.UnderlyingType, r.ElementSize = tradovate.ChartTypeDailyBar, uint32(interval/day)
+	case interval >= time.Minute && interval%time.Minute == 0:
+		r.UnderlyingType, r.ElementSize = tradovate.ChartTypeMinuteBar, uint32(interval/time.Minute)
+	default:
+		return nil, start, end, fmt.Errorf("-interval must be whole minutes or whole days, got %s", interval)
+	}
+
+	return r, start, end, nil
+}
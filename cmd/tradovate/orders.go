@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+// flags shared by place and modify
+type orderFlags struct {
+	qty       uint
+	orderType string
+	price     float64
+	stop      float64
+	tif       string
+	expire    string
+	dryRun    bool
+}
+
+func (o *orderFlags) register(a *app, name string) *flag.FlagSet {
+	fs := a.flags(name)
+	fs.UintVar(&o.qty, "qty", 0, "order quantity")
+	fs.StringVar(&o.orderType, "type", "Market", "order type: Market, Limit, Stop, StopLimit, MIT, ...")
+	fs.Float64Var(&o.price, "price", 0, "limit price")
+	fs.Float64Var(&o.stop, "stop", 0, "stop price, also where an MIT triggers")
+	fs.StringVar(&o.tif, "tif", "Day", "time in force: Day, GTC, GTD, IOC, FOK")
+	fs.StringVar(&o.expire, "expire", "", "expire time for GTD orders, RFC3339")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print the request instead of sending it")
+	return fs
+}
+
+func (o *orderFlags) parse() (t tradovate.OrderType, tif tradovate.Tif, expire time.Time, err error) {
+	if o.qty == 0 {
+		return t, tif, expire, fmt.Errorf("-qty is required")
+	}
+
+	if t, err = tradovate.OrderTypeString(o.orderType); err != nil {
+		return t, tif, expire, err
+	}
+
+	if tif, err = tradovate.TifString(o.tif); err != nil {
+		return t, tif, expire, err
+	}
+
+	if o.expire != "" {
+		expire, err = parseTime(o.expire)
+	}
+
+	return t, tif, expire, err
+}
+
+// print what would have been sent
+func (a *app) dryRun(path string, body any) error {
+	if a.format == formatJSON {
+		return json.NewEncoder(a.stdout).Encode(map[string]any{"dryRun": true, "path": path, "body": body})
+	}
+
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(a.stdout, "dry run, not sending %s %s\n", path, buf)
+	return err
+}
+
+type orderResult struct {
+	OrderID   uint `json:"orderId,omitempty"`
+	CommandID uint `json:"commandId,omitempty"`
+}
+
+func (a *app) printResult(r orderResult) error {
+	p, err := a.printer()
+	if err != nil {
+		return err
+	}
+
+	return printAll(p, []orderResult{r}, []column[orderResult]{
+		{"order id", func(r orderResult) string { return num(r.OrderID) }},
+		{"command id", func(r orderResult) string { return num(r.CommandID) }},
+	})
+}
+
+func place(ctx context.Context, a *app, args []string) error {
+	var (
+		o                    orderFlags
+		symbol, action, text string
+	)
+
+	fs := o.register(a, "place")
+	fs.StringVar(&symbol, "symbol", "", "contract symbol, e.g. ESZ5")
+	fs.StringVar(&action, "action", "", "buy or sell")
+	fs.StringVar(&text, "text", "", "order text")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if symbol == "" {
+		return fmt.Errorf("-symbol is required")
+	}
+
+	act, err := tradovate.ActionString(action)
+	if err != nil || act == tradovate.ActionUnspecified {
+		return fmt.Errorf("-action must be buy or sell, got %q", action)
+	}
+
+	t, tif, expire, err := o.parse()
+	if err != nil {
+		return err
+	}
+
+	c, err := a.config()
+	if err != nil {
+		return err
+	}
+
+	req := &tradovate.OrderReq{
+		AccountSpec: c.Account.Spec,
+		AccountID:   c.Account.ID,
+		Action:      act,
+		Symbol:      symbol,
+		OrderQty:    uint32(o.qty),
+		OrderType:   t,
+		Price:       o.price,
+		StopPrice:   o.stop,
+		TimeInForce: tif,
+		ExpireTime:  expire,
+		Text:        text,
+		IsAutomated: true,
+	}
+
+	if o.dryRun {
+		return a.dryRun("order/placeorder", req)
+	}
+
+	ws, err := a.api(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := ws.PlaceOrder(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return a.printResult(orderResult{OrderID: id})
+}
+
+func cancel(ctx context.Context, a *app, args []string) error {
+	var dryRun bool
+	fs := a.flags("cancel")
+	fs.BoolVar(&dryRun, "dry-run", false, "print the request instead of sending it")
+
+	id, err := orderID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return a.dryRun("order/cancelorder", map[string]uint{"orderId": id})
+	}
+
+	ws, err := a.api(ctx)
+	if err != nil {
+		return err
+	}
+
+	cmd, err := ws.CancelOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return a.printResult(orderResult{OrderID: id, CommandID: cmd})
+}
+
+func modify(ctx context.Context, a *app, args []string) error {
+	var o orderFlags
+	fs := o.register(a, "modify")
+
+	id, err := orderID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	t, tif, expire, err := o.parse()
+	if err != nil {
+		return err
+	}
+
+	req := &tradovate.ModifyOrderReq{
+		OrderID:     id,
+		OrderQty:    uint32(o.qty),
+		OrderType:   t,
+		Price:       o.price,
+		StopPrice:   o.stop,
+		TimeInForce: tif,
+		ExpireTime:  expire,
+		IsAutomated: true,
+	}
+
+	if o.dryRun {
+		return a.dryRun("order/modifyorder", req)
+	}
+
+	ws, err := a.api(ctx)
+	if err != nil {
+		return err
+	}
+
+	cmd, err := ws.ModifyOrder(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return a.printResult(orderResult{OrderID: id, CommandID: cmd})
+}
+
+func flatten(ctx context.Context, a *app, args []string) error {
+	var dryRun bool
+	fs := a.flags("flatten")
+	fs.BoolVar(&dryRun, "dry-run", false, "print the requests instead of sending them")
+
+	symbols, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(symbols) > 1 {
+		return fmt.Errorf("flatten takes at most one symbol, got %v", symbols)
+	}
+
+	c, err := a.config()
+	if err != nil {
+		return err
+	}
+
+	if c.Account.ID == 0 {
+		return fmt.Errorf("account.id must be set in the config to flatten")
+	}
+
+	ws, err := a.api(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the contract to flatten, or 0 for all of them
+	var contractID int
+	if len(symbols) == 1 {
+		contract, err := ws.FindContract(ctx, symbols[0])
+		if err != nil {
+			return fmt.Errorf("failed finding %s: %w", symbols[0], err)
+		}
+		contractID = contract.ID
+	}
+
+	positions, err := ws.ListPositions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var results []orderResult
+	for _, v := range positions {
+		if v.NetPos == 0 || uint(v.AccountID) != c.Account.ID || (contractID != 0 && v.ContractID != contractID) {
+			continue
+		}
+
+		if dryRun {
+			body := map[string]any{"accountId": c.Account.ID, "contractId": v.ContractID, "admin": false}
+			if err = a.dryRun("order/liquidateposition", body); err != nil {
+				return err
+			}
+			continue
+		}
+
+		id, err := ws.LiquidatePosition(ctx, c.Account.ID, v.ContractID)
+		if err != nil {
+			return fmt.Errorf("failed flattening contract %d: %w", v.ContractID, err)
+		}
+
+		results = append(results, orderResult{OrderID: id})
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	p, err := a.printer()
+	if err != nil {
+		return err
+	}
+
+	return printAll(p, results, []column[orderResult]{
+		{"order id", func(r orderResult) string { return num(r.OrderID) }},
+	})
+}
+
+func orderID(fs *flag.FlagSet, args []string) (uint, error) {
+	pos, err := parse(fs, args)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(pos) != 1 {
+		return 0, fmt.Errorf("expected exactly one order ID, got %v", pos)
+	}
+
+	id, err := strconv.ParseUint(pos[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid order ID %s: %w", pos[0], err)
+	}
+
+	return uint(id), nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+type column[T any] struct {
+	name  string
+	value func(T) string
+}
+
+type printer struct {
+	w      io.Writer
+	format string
+
+	// streaming output only prints the header once
+	wroteHeader bool
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return &printer{w: w, format: format}, nil
+	default:
+		return nil, fmt.Errorf("output format must be table, json or csv, got %s", format)
+	}
+}
+
+// print a whole list at once. JSON output is the items as-is, table and
+// CSV use the columns
+func printAll[T any](p *printer, items []T, cols []column[T]) error {
+	switch p.format {
+	case formatJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		if items == nil {
+			items = []T{}
+		}
+		return enc.Encode(items)
+	case formatCSV:
+		w := csv.NewWriter(p.w)
+		w.Write(header(cols))
+		for _, v := range items {
+			w.Write(row(v, cols))
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(header(cols), "\t"))
+		for _, v := range items {
+			fmt.Fprintln(w, strings.Join(row(v, cols), "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// print a single item of a stream. JSON output is one object per line
+func printOne[T any](p *printer, item T, cols []column[T]) error {
+	switch p.format {
+	case formatJSON:
+		return json.NewEncoder(p.w).Encode(item)
+	case formatCSV:
+		w := csv.NewWriter(p.w)
+		if !p.wroteHeader {
+			w.Write(header(cols))
+			p.wroteHeader = true
+		}
+		w.Write(row(item, cols))
+		w.Flush()
+		return w.Error()
+	default:
+		if !p.wroteHeader {
+			fmt.Fprintln(p.w, pad(header(cols)))
+			p.wroteHeader = true
+		}
+		_, err := fmt.Fprintln(p.w, pad(row(item, cols)))
+		return err
+	}
+}
+
+// tabwriter can't align a stream, so give every cell a fixed width
+func pad(cells []string) string {
+	var sb strings.Builder
+	for i, v := range cells {
+		if i != 0 {
+			sb.WriteString("  ")
+		}
+		fmt.Fprintf(&sb, "%-12s", v)
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+func header[T any](cols []column[T]) []string {
+	x := make([]string, len(cols))
+	for i, v := range cols {
+		x[i] = v.name
+	}
+	return x
+}
+
+func row[T any](item T, cols []column[T]) []string {
+	x := make([]string, len(cols))
+	for i, v := range cols {
+		x[i] = v.value(item)
+	}
+	return x
+}
+
+func num[N int | uint | uint32 | int64](n N) string { return strconv.FormatInt(int64(n), 10) }
+
+func float(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+
+func ts(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
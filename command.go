@@ -0,0 +1,25 @@
+package tradovate
+
+import "time"
+
+// A request to place, modify or cancel an order, as the server saw it.
+// This is the only order entity carrying the clOrdId
+type Command struct {
+	ID            uint      `json:"id"`
+	OrderID       uint      `json:"orderId"`
+	Timestamp     time.Time `json:"timestamp"`
+	ClOrdID       string    `json:"clOrdId"`
+	CommandType   string    `json:"commandType"`   // New, Modify, Cancel
+	CommandStatus string    `json:"commandStatus"` // PendingExecution, RiskRejected...
+	IsAutomated   bool      `json:"isAutomated"`
+}
+
+func (e *EntityMsg) Command() (*Command, error) { return decode[Command](e) }
+func (e *EntityMsg) MustCommand() *Command {
+	c, err := e.Command()
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
@@ -0,0 +1,207 @@
+package tradovate
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// How a continuous series closes the price gap at each roll
+//
+//go:generate enumer -type Adjustment -trimprefix Adjustment -json
+type Adjustment byte
+
+const (
+	AdjustmentNone       Adjustment = iota // leave the gaps in
+	AdjustmentDifference                   // shift older bars by the gap in points
+	AdjustmentRatio                        // scale older bars by the ratio of the prices
+)
+
+// RollPoint is where a continuous series moved from one contract to the
+// next
+type RollPoint struct {
+	At   time.Time // first bar taken from To
+	From string
+	To   string
+
+	// closes of the last bar before At both contracts have, which the gap
+	// is measured from. Zero for AdjustmentNone if they never overlapped
+	FromPrice float64
+	ToPrice   float64
+
+	// points added to (AdjustmentDifference) or factor multiplied into
+	// (AdjustmentRatio) every price before At, on top of later rolls'
+	Adjustment float64
+}
+
+// ContinuousSeries is bars from consecutive contracts stitched together
+type ContinuousSeries struct {
+	Method Adjustment
+	Bars   []Bar
+	Rolls  []RollPoint
+}
+
+// The usual roll date for a maturity: n days before it expires
+func RollDate(m *ContractMaturity, daysBefore int) time.Time {
+	return m.ExpirationDate.AddDate(0, 0, -daysBefore)
+}
+
+// ContinuousBuilder stitches historical bars from consecutive contracts
+// into one back-adjusted series, so backtests don't see a jump at every
+// roll. Add contracts oldest first
+type ContinuousBuilder struct {
+	method   Adjustment
+	segments []segment
+}
+
+type segment struct {
+	contract string
+	bars     []Bar
+	rollAt   time.Time
+}
+
+func NewContinuousBuilder(method Adjustment) *ContinuousBuilder {
+	return &ContinuousBuilder{method: method}
+}
+
+// Add a contract's bars. The series uses them until rollAt, then moves
+// to the next contract added; the last contract's rollAt is ignored
+func (b *ContinuousBuilder) Add(contract string, bars []Bar, rollAt time.Time) *ContinuousBuilder {
+	bars = slices.Clone(bars)
+	slices.SortFunc(bars, func(a, b Bar) int { return a.Timestamp.Compare(b.Timestamp) })
+
+	b.segments = append(b.segments, segment{contract: contract, bars: bars, rollAt: rollAt})
+	return b
+}
+
+// Build the series. Difference and ratio adjustments need both contracts
+// to have a bar at the same time before each roll to measure the gap from
+func (b *ContinuousBuilder) Build() (*ContinuousSeries, error) {
+	if len(b.segments) == 0 {
+		return nil, fmt.Errorf("no contracts added")
+	}
+
+	for i, v := range b.segments[:len(b.segments)-1] {
+		if v.rollAt.IsZero() {
+			return nil, fmt.Errorf("%s needs a roll date, it isn't the last contract", v.contract)
+		}
+
+		if i > 0 && !v.rollAt.After(b.segments[i-1].rollAt) {
+			return nil, fmt.Errorf("roll dates out of order: %s rolls at %s, before %s does", v.contract, v.rollAt, b.segments[i-1].contract)
+		}
+	}
+
+	rolls := make([]RollPoint, len(b.segments)-1)
+	for i := range rolls {
+		r, err := b.rollPoint(b.segments[i], b.segments[i+1])
+		if err != nil {
+			return nil, err
+		}
+		rolls[i] = r
+	}
+
+	// adjust from the newest contract back, so each roll's adjustment
+	// applies to everything before it
+	parts := make([][]Bar, len(b.segments))
+	diff, ratio := 0.0, 1.0
+	for i := len(b.segments) - 1; i >= 0; i-- {
+		if i < len(rolls) {
+			switch b.method {
+			case AdjustmentDifference:
+				diff += rolls[i].Adjustment
+			case AdjustmentRatio:
+				ratio *= rolls[i].Adjustment
+			}
+		}
+
+		parts[i] = b.window(i)
+		for j := range parts[i] {
+			adjust(&parts[i][j], diff, ratio)
+		}
+	}
+
+	return &ContinuousSeries{Method: b.method, Bars: slices.Concat(parts...), Rolls: rolls}, nil
+}
+
+// the bars segment i contributes, copied
+func (b *ContinuousBuilder) window(i int) []Bar {
+	s := b.segments[i]
+
+	var from time.Time
+	if i > 0 {
+		from = b.segments[i-1].rollAt
+	}
+
+	last := i == len(b.segments)-1
+	var x []Bar
+	for _, v := range s.bars {
+		if v.Timestamp.Before(from) || !last && !v.Timestamp.Before(s.rollAt) {
+			continue
+		}
+		x = append(x, v)
+	}
+
+	return x
+}
+
+func (b *ContinuousBuilder) rollPoint(from, to segment) (RollPoint, error) {
+	r := RollPoint{At: from.rollAt, From: from.contract, To: to.contract}
+
+	f, t, ok := overlap(from.bars, to.bars, from.rollAt)
+	if ok {
+		r.FromPrice, r.ToPrice = f.Close, t.Close
+	}
+
+	switch b.method {
+	case AdjustmentNone:
+		return r, nil
+	case AdjustmentDifference, AdjustmentRatio:
+		if !ok {
+			return r, fmt.Errorf("%s and %s have no bar at the same time before %s to measure the roll from", from.contract, to.contract, from.rollAt)
+		}
+	default:
+		return r, fmt.Errorf("unknown adjustment %s", b.method)
+	}
+
+	if b.method == AdjustmentDifference {
+		r.Adjustment = t.Close - f.Close
+		return r, nil
+	}
+
+	if f.Close == 0 {
+		return r, fmt.Errorf("%s closed at 0 before the roll, can't take a ratio", from.contract)
+	}
+
+	r.Adjustment = t.Close / f.Close
+	return r, nil
+}
+
+// the latest bars before at that both contracts have
+func overlap(from, to []Bar, at time.Time) (Bar, Bar, bool) {
+	byTime := map[int64]Bar{}
+	for _, v := range to {
+		if v.Timestamp.Before(at) {
+			byTime[v.Timestamp.UnixNano()] = v
+		}
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		f := from[i]
+		if !f.Timestamp.Before(at) {
+			continue
+		}
+
+		if t, ok := byTime[f.Timestamp.UnixNano()]; ok {
+			return f, t, true
+		}
+	}
+
+	return Bar{}, Bar{}, false
+}
+
+func adjust(b *Bar, diff, ratio float64) {
+	b.Open = b.Open*ratio + diff
+	b.High = b.High*ratio + diff
+	b.Low = b.Low*ratio + diff
+	b.Close = b.Close*ratio + diff
+}
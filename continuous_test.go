@@ -0,0 +1,112 @@
+package tradovate
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestContinuousBuilder(mainTest *testing.T) {
+	t0 := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(day int) time.Time { return t0.AddDate(0, 0, day) }
+	bars := func(start int, closes ...float64) []Bar {
+		x := make([]Bar, len(closes))
+		for i, v := range closes {
+			x[i] = Bar{Timestamp: at(start + i), Open: v, High: v, Low: v, Close: v}
+		}
+		return x
+	}
+
+	h := bars(0, 100, 101, 102) // days 0-2
+	m := bars(1, 111, 112, 113) // days 1-3
+
+	testCases := []struct {
+		name               string
+		method             Adjustment
+		hBars              []Bar
+		hRoll              time.Time
+		expected           []float64
+		expectedAdjustment float64
+		expectErr          bool
+	}{
+		{
+			name:               "none leaves the gap",
+			method:             AdjustmentNone,
+			hBars:              h,
+			hRoll:              at(2),
+			expected:           []float64{100, 101, 112, 113},
+			expectedAdjustment: 0,
+		},
+		{
+			name:               "difference shifts older bars",
+			method:             AdjustmentDifference,
+			hBars:              h,
+			hRoll:              at(2),
+			expected:           []float64{110, 111, 112, 113},
+			expectedAdjustment: 10,
+		},
+		{
+			name:               "ratio scales older bars",
+			method:             AdjustmentRatio,
+			hBars:              h,
+			hRoll:              at(2),
+			expected:           []float64{100 * 111. / 101, 111, 112, 113},
+			expectedAdjustment: 111. / 101,
+		},
+		{
+			name:      "nothing to measure the gap from",
+			method:    AdjustmentDifference,
+			hBars:     bars(0, 100),
+			hRoll:     at(1),
+			expectErr: true,
+		},
+		{
+			name:      "missing roll date",
+			method:    AdjustmentNone,
+			hBars:     h,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			s, err := NewContinuousBuilder(tc.method).
+				Add("NQH5", tc.hBars, tc.hRoll).
+				Add("NQM5", m, time.Time{}).
+				Build()
+
+			if (err != nil) != tc.expectErr {
+				tt.Fatalf("wanted error %v, got %v", tc.expectErr, err)
+			}
+
+			if tc.expectErr {
+				return
+			}
+
+			var closes []float64
+			for _, v := range s.Bars {
+				closes = append(closes, v.Close)
+			}
+
+			if !slices.EqualFunc(tc.expected, closes, approx) {
+				tt.Errorf("wrong closes: want %v got %v", tc.expected, closes)
+			}
+
+			if len(s.Rolls) != 1 {
+				tt.Fatalf("wanted one roll point, got %+v", s.Rolls)
+			}
+
+			r := s.Rolls[0]
+			if !r.At.Equal(tc.hRoll) || r.From != "NQH5" || r.To != "NQM5" || r.FromPrice != 101 || r.ToPrice != 111 {
+				tt.Errorf("wrong roll point: %+v", r)
+			}
+
+			if !approx(r.Adjustment, tc.expectedAdjustment) {
+				tt.Errorf("wrong adjustment: want %v got %v", tc.expectedAdjustment, r.Adjustment)
+			}
+		})
+	}
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
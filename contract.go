@@ -0,0 +1,96 @@
+package tradovate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+const (
+	contractFindURL = "contract/find"
+	contractItemURL = "contract/item"
+)
+
+type Contract struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"` // symbol, e.g. ESZ5
+	ContractMaturityID int    `json:"contractMaturityId"`
+}
+
+func (e *EntityMsg) Contract() (*Contract, error) { return decode[Contract](e) }
+
+// Find a contract by its symbol, e.g. ESZ5
+func (s *WS) FindContract(ctx context.Context, symbol string) (*Contract, error) {
+	if symbol == "" {
+		return nil, fmt.Errorf("no symbol passed to find contract")
+	}
+
+	var c Contract
+	if err := s.do(ctx, contractFindURL, url.Values{"name": {symbol}}, nil, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Get a contract by its ID
+func (s *WS) GetContract(ctx context.Context, id int) (*Contract, error) {
+	var c Contract
+	if err := s.do(ctx, contractItemURL, url.Values{"id": {fmt.Sprint(id)}}, nil, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// ContractSpec is what it takes to turn a contract's prices into money
+type ContractSpec struct {
+	ValuePerPoint float64 // e.g. 20 for NQ
+	TickSize      float64
+	Currency      string // e.g. USD
+}
+
+// Look up a contract's spec through its maturity, product and currency
+func (s *WS) ContractSpec(ctx context.Context, contractID int) (ContractSpec, error) {
+	c, err := s.GetContract(ctx, contractID)
+	if err != nil {
+		return ContractSpec{}, fmt.Errorf("failed getting contract %d: %w", contractID, err)
+	}
+
+	m, err := s.GetContractMaturity(ctx, c.ContractMaturityID)
+	if err != nil {
+		return ContractSpec{}, fmt.Errorf("failed getting maturity of %s: %w", c.Name, err)
+	}
+
+	p, err := s.GetProduct(ctx, m.ProductID)
+	if err != nil {
+		return ContractSpec{}, fmt.Errorf("failed getting product of %s: %w", c.Name, err)
+	}
+
+	cur, err := s.GetCurrency(ctx, p.CurrencyID)
+	if err != nil {
+		return ContractSpec{}, fmt.Errorf("failed getting currency of %s: %w", c.Name, err)
+	}
+
+	return ContractSpec{ValuePerPoint: p.ValuePerPoint, TickSize: p.TickSize, Currency: cur.Name}, nil
+}
+
+// Look up a contract's tick size by its symbol, from its product
+func (s *WS) TickSize(ctx context.Context, symbol string) (float64, error) {
+	c, err := s.FindContract(ctx, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed finding %s: %w", symbol, err)
+	}
+
+	m, err := s.GetContractMaturity(ctx, c.ContractMaturityID)
+	if err != nil {
+		return 0, fmt.Errorf("failed getting maturity of %s: %w", symbol, err)
+	}
+
+	p, err := s.GetProduct(ctx, m.ProductID)
+	if err != nil {
+		return 0, fmt.Errorf("failed getting product of %s: %w", symbol, err)
+	}
+
+	return p.TickSize, nil
+}
@@ -0,0 +1,54 @@
+package tradovate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	contractMaturityDepsURL = "contractMaturity/deps"
+	contractMaturityItemURL = "contractMaturity/item"
+	contractDepsURL         = "contract/deps"
+)
+
+// ContractMaturity is one expiry of a product, e.g. March 2025 for NQ
+type ContractMaturity struct {
+	ID              int       `json:"id"`
+	ProductID       int       `json:"productId"`
+	ExpirationMonth int       `json:"expirationMonth"` // YYYYMM
+	ExpirationDate  time.Time `json:"expirationDate"`
+	IsFront         bool      `json:"isFront"`
+	Archived        bool      `json:"archived"`
+}
+
+// Get a maturity by its ID
+func (s *WS) GetContractMaturity(ctx context.Context, id int) (*ContractMaturity, error) {
+	var m ContractMaturity
+	if err := s.do(ctx, contractMaturityItemURL, url.Values{"id": {fmt.Sprint(id)}}, nil, &m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// List every maturity of a product
+func (s *WS) ListContractMaturities(ctx context.Context, productID int) ([]*ContractMaturity, error) {
+	var x []*ContractMaturity
+	if err := s.do(ctx, contractMaturityDepsURL, url.Values{"masterid": {fmt.Sprint(productID)}}, nil, &x); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+// List the contracts listed for a maturity
+func (s *WS) ListMaturityContracts(ctx context.Context, maturityID int) ([]*Contract, error) {
+	var x []*Contract
+	if err := s.do(ctx, contractDepsURL, url.Values{"masterid": {fmt.Sprint(maturityID)}}, nil, &x); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
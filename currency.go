@@ -0,0 +1,25 @@
+package tradovate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+const currencyItemURL = "currency/item"
+
+type Currency struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"` // e.g. USD
+	Symbol string `json:"symbol"`
+}
+
+// Get a currency by its ID
+func (s *WS) GetCurrency(ctx context.Context, id int) (*Currency, error) {
+	var c Currency
+	if err := s.do(ctx, currencyItemURL, url.Values{"id": {fmt.Sprint(id)}}, nil, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,100 @@
+// Code generated by "enumer -type Direction -json -trimprefix Direction -transform lower"; DO NOT EDIT.
+
+package tradovate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const _DirectionName = "unspecifiedinout"
+
+var _DirectionIndex = [...]uint8{0, 11, 13, 16}
+
+const _DirectionLowerName = "unspecifiedinout"
+
+func (i Direction) String() string {
+	if i >= Direction(len(_DirectionIndex)-1) {
+		return fmt.Sprintf("Direction(%d)", i)
+	}
+	return _DirectionName[_DirectionIndex[i]:_DirectionIndex[i+1]]
+}
+
+// An "invalid array index" compiler error signifies that the constant values have changed.
+// Re-run the stringer command to generate them again.
+func _DirectionNoOp() {
+	var x [1]struct{}
+	_ = x[DirectionUnspecified-(0)]
+	_ = x[DirectionIn-(1)]
+	_ = x[DirectionOut-(2)]
+}
+
+var _DirectionValues = []Direction{DirectionUnspecified, DirectionIn, DirectionOut}
+
+var _DirectionNameToValueMap = map[string]Direction{
+	_DirectionName[0:11]:       DirectionUnspecified,
+	_DirectionLowerName[0:11]:  DirectionUnspecified,
+	_DirectionName[11:13]:      DirectionIn,
+	_DirectionLowerName[11:13]: DirectionIn,
+	_DirectionName[13:16]:      DirectionOut,
+	_DirectionLowerName[13:16]: DirectionOut,
+}
+
+var _DirectionNames = []string{
+	_DirectionName[0:11],
+	_DirectionName[11:13],
+	_DirectionName[13:16],
+}
+
+// DirectionString retrieves an enum value from the enum constants string name.
+// Throws an error if the param is not part of the enum.
+func DirectionString(s string) (Direction, error) {
+	if val, ok := _DirectionNameToValueMap[s]; ok {
+		return val, nil
+	}
+
+	if val, ok := _DirectionNameToValueMap[strings.ToLower(s)]; ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("%s does not belong to Direction values", s)
+}
+
+// DirectionValues returns all values of the enum
+func DirectionValues() []Direction {
+	return _DirectionValues
+}
+
+// DirectionStrings returns a slice of all String values of the enum
+func DirectionStrings() []string {
+	strs := make([]string, len(_DirectionNames))
+	copy(strs, _DirectionNames)
+	return strs
+}
+
+// IsADirection returns "true" if the value is listed in the enum definition. "false" otherwise
+func (i Direction) IsADirection() bool {
+	for _, v := range _DirectionValues {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON implements the json.Marshaler interface for Direction
+func (i Direction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Direction
+func (i *Direction) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Direction should be a string, got %s", data)
+	}
+
+	var err error
+	*i, err = DirectionString(s)
+	return err
+}
@@ -26,7 +26,12 @@ func (s *WS) SubscribeDOMID(ctx context.Context, id int) error {
 }
 
 func (s *WS) subscribeDOM(ctx context.Context, x any) error {
-	return s.do(ctx, subscribeDOMs, nil, map[string]any{"symbol": x}, nil)
+	if err := s.do(ctx, subscribeDOMs, nil, map[string]any{"symbol": x}, nil); err != nil {
+		return err
+	}
+
+	s.md.add(subscribeDOMs, x)
+	return nil
 }
 
 func (s *WS) UnsubscribeDOMSymbol(ctx context.Context, symbol string) error {
@@ -38,5 +43,10 @@ func (s *WS) UnsubscribeDOMID(ctx context.Context, id int) error {
 }
 
 func (s *WS) unsubscribeDOM(ctx context.Context, x any) error {
-	return s.do(ctx, unsubscribeDOMs, nil, map[string]any{"symbol": x}, nil)
+	if err := s.do(ctx, unsubscribeDOMs, nil, map[string]any{"symbol": x}, nil); err != nil {
+		return err
+	}
+
+	s.md.remove(subscribeDOMs, x)
+	return nil
 }
@@ -14,7 +14,7 @@ const (
 	EventTypeDeleted
 )
 
-//go:generate enumer -type EntityType -trimprefix EntityMsg -json
+//go:generate enumer -type EntityType -trimprefix EntityType -json
 type EntityType byte
 
 // TODO this list is way too long but I have no list of entity types
@@ -0,0 +1,48 @@
+package tradovate
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEntityMsgUnmarshal(mainTest *testing.T) {
+	testCases := []struct {
+		name         string
+		arg          string
+		expected     EntityType
+		expectedType string
+	}{
+		{name: "order", arg: `{"eventType":"Created","entityType":"order","entity":{}}`, expected: EntityTypeOrder, expectedType: "Order"},
+		{name: "camel case", arg: `{"eventType":"Updated","entityType":"executionReport","entity":{}}`, expected: EntityTypeExecutionReport, expectedType: "ExecutionReport"},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			var e EntityMsg
+			if err := json.Unmarshal([]byte(tc.arg), &e); err != nil {
+				tt.Fatalf("failed unmarshaling: %v", err)
+			}
+
+			if e.Type != tc.expected || e.Type.String() != tc.expectedType {
+				tt.Errorf("wrong entity type: want %s got %s", tc.expectedType, e.Type)
+			}
+		})
+	}
+}
+
+func TestEntityMsgDecode(t *testing.T) {
+	e := EntityMsg{Type: EntityTypeOrder, Data: json.RawMessage(`{"id":5,"accountId":2}`)}
+
+	o, err := e.Order()
+	if err != nil {
+		t.Fatalf("failed decoding order: %v", err)
+	}
+
+	if o.ID != 5 || o.AccountID != 2 {
+		t.Errorf("wrong order: %+v", o)
+	}
+
+	if _, err = (&EntityMsg{Data: json.RawMessage(`{"id":`)}).Position(); err == nil {
+		t.Error("bad entities should fail to decode")
+	}
+}
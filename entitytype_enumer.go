@@ -1,4 +1,4 @@
-// Code generated by "enumer -type EntityType -trimprefix EntityMsg -json"; DO NOT EDIT.
+// Code generated by "enumer -type EntityType -trimprefix EntityType -json"; DO NOT EDIT.
 
 package tradovate
 
@@ -8,11 +8,11 @@ import (
 	"strings"
 )
 
-const _EntityTypeName = "EntityTypeUnspecifiedEntityTypeAccountEntityTypeAccountRiskStatusEntityTypeAdminAlertEntityTypeAdminAlertSignalEntityTypeCashBalanceEntityTypeCashBalanceLogEntityTypeChatEntityTypeChatMessageEntityTypeClearingHouseEntityTypeCommandEntityTypeCommandReportEntityTypeContactInfoEntityTypeContractEntityTypeContractGroupEntityTypeContractMarginEntityTypeContractMaturityEntityTypeCurrencyEntityTypeCurrencyRateEntityTypeEntitlementEntityTypeExchangeEntityTypeExecutionReportEntityTypeFillEntityTypeFillFeeEntityTypeFillPairEntityTypeMarginSnapshotEntityTypeMarketDataSubscriptionEntityTypeMarketDataSubscriptionExchangeScopeEntityTypeMarketDataSubscriptionPlanEntityTypeOrderEntityTypeOrderStrategyEntityTypeOrderStrategyLinkEntityTypeOrderStrategyTypeEntityTypeOrderVersionEntityTypeOrganizationEntityTypePermissionedAccountAutoLiqEntityTypePositionEntityTypeProductEntityTypeProductMarginEntityTypeProductSessionEntityTypePropertyEntityTypeSecondMarketDataSubscriptionEntityTypeSpreadDefinitionEntityTypeTradingPermissionEntityTypeTradovateSubscriptionEntityTypeTradovateSubscriptionPlanEntityTypeUserEntityTypeUserAccountAutoLiqEntityTypeUserAccountPositionLimitEntityTypeUserAccountRiskParameterEntityTypeUserPluginEntityTypeUserPropertyEntityTypeUserSessionEntityTypeUserSessionStats"
+const _EntityTypeName = "UnspecifiedAccountAccountRiskStatusAdminAlertAdminAlertSignalCashBalanceCashBalanceLogChatChatMessageClearingHouseCommandCommandReportContactInfoContractContractGroupContractMarginContractMaturityCurrencyCurrencyRateEntitlementExchangeExecutionReportFillFillFeeFillPairMarginSnapshotMarketDataSubscriptionMarketDataSubscriptionExchangeScopeMarketDataSubscriptionPlanOrderOrderStrategyOrderStrategyLinkOrderStrategyTypeOrderVersionOrganizationPermissionedAccountAutoLiqPositionProductProductMarginProductSessionPropertySecondMarketDataSubscriptionSpreadDefinitionTradingPermissionTradovateSubscriptionTradovateSubscriptionPlanUserUserAccountAutoLiqUserAccountPositionLimitUserAccountRiskParameterUserPluginUserPropertyUserSessionUserSessionStats"
 
-var _EntityTypeIndex = [...]uint16{0, 21, 38, 65, 85, 111, 132, 156, 170, 191, 214, 231, 254, 275, 293, 316, 340, 366, 384, 406, 427, 445, 470, 484, 501, 519, 543, 575, 620, 656, 671, 694, 721, 748, 770, 792, 828, 846, 863, 886, 910, 928, 966, 992, 1019, 1050, 1085, 1099, 1127, 1161, 1195, 1215, 1237, 1258, 1284}
+var _EntityTypeIndex = [...]uint16{0, 11, 18, 35, 45, 61, 72, 86, 90, 101, 114, 121, 134, 145, 153, 166, 180, 196, 204, 216, 227, 235, 250, 254, 261, 269, 283, 305, 340, 366, 371, 384, 401, 418, 430, 442, 468, 476, 483, 496, 510, 518, 546, 562, 579, 600, 625, 629, 647, 671, 695, 705, 717, 728, 744}
 
-const _EntityTypeLowerName = "entitytypeunspecifiedentitytypeaccountentitytypeaccountriskstatusentitytypeadminalertentitytypeadminalertsignalentitytypecashbalanceentitytypecashbalancelogentitytypechatentitytypechatmessageentitytypeclearinghouseentitytypecommandentitytypecommandreportentitytypecontactinfoentitytypecontractentitytypecontractgroupentitytypecontractmarginentitytypecontractmaturityentitytypecurrencyentitytypecurrencyrateentitytypeentitlemententitytypeexchangeentitytypeexecutionreportentitytypefillentitytypefillfeeentitytypefillpairentitytypemarginsnapshotentitytypemarketdatasubscriptionentitytypemarketdatasubscriptionexchangescopeentitytypemarketdatasubscriptionplanentitytypeorderentitytypeorderstrategyentitytypeorderstrategylinkentitytypeorderstrategytypeentitytypeorderversionentitytypeorganizationentitytypepermissionedaccountautoliqentitytypepositionentitytypeproductentitytypeproductmarginentitytypeproductsessionentitytypepropertyentitytypesecondmarketdatasubscriptionentitytypespreaddefinitionentitytypetradingpermissionentitytypetradovatesubscriptionentitytypetradovatesubscriptionplanentitytypeuserentitytypeuseraccountautoliqentitytypeuseraccountpositionlimitentitytypeuseraccountriskparameterentitytypeuserpluginentitytypeuserpropertyentitytypeusersessionentitytypeusersessionstats"
+const _EntityTypeLowerName = "unspecifiedaccountaccountriskstatusadminalertadminalertsignalcashbalancecashbalancelogchatchatmessageclearinghousecommandcommandreportcontactinfocontractcontractgroupcontractmargincontractmaturitycurrencycurrencyrateentitlementexchangeexecutionreportfillfillfeefillpairmarginsnapshotmarketdatasubscriptionmarketdatasubscriptionexchangescopemarketdatasubscriptionplanorderorderstrategyorderstrategylinkorderstrategytypeorderversionorganizationpermissionedaccountautoliqpositionproductproductmarginproductsessionpropertysecondmarketdatasubscriptionspreaddefinitiontradingpermissiontradovatesubscriptiontradovatesubscriptionplanuseruseraccountautoliquseraccountpositionlimituseraccountriskparameteruserpluginuserpropertyusersessionusersessionstats"
 
 func (i EntityType) String() string {
 	if i >= EntityType(len(_EntityTypeIndex)-1) {
@@ -84,171 +84,171 @@ func _EntityTypeNoOp() {
 var _EntityTypeValues = []EntityType{EntityTypeUnspecified, EntityTypeAccount, EntityTypeAccountRiskStatus, EntityTypeAdminAlert, EntityTypeAdminAlertSignal, EntityTypeCashBalance, EntityTypeCashBalanceLog, EntityTypeChat, EntityTypeChatMessage, EntityTypeClearingHouse, EntityTypeCommand, EntityTypeCommandReport, EntityTypeContactInfo, EntityTypeContract, EntityTypeContractGroup, EntityTypeContractMargin, EntityTypeContractMaturity, EntityTypeCurrency, EntityTypeCurrencyRate, EntityTypeEntitlement, EntityTypeExchange, EntityTypeExecutionReport, EntityTypeFill, EntityTypeFillFee, EntityTypeFillPair, EntityTypeMarginSnapshot, EntityTypeMarketDataSubscription, EntityTypeMarketDataSubscriptionExchangeScope, EntityTypeMarketDataSubscriptionPlan, EntityTypeOrder, EntityTypeOrderStrategy, EntityTypeOrderStrategyLink, EntityTypeOrderStrategyType, EntityTypeOrderVersion, EntityTypeOrganization, EntityTypePermissionedAccountAutoLiq, EntityTypePosition, EntityTypeProduct, EntityTypeProductMargin, EntityTypeProductSession, EntityTypeProperty, EntityTypeSecondMarketDataSubscription, EntityTypeSpreadDefinition, EntityTypeTradingPermission, EntityTypeTradovateSubscription, EntityTypeTradovateSubscriptionPlan, EntityTypeUser, EntityTypeUserAccountAutoLiq, EntityTypeUserAccountPositionLimit, EntityTypeUserAccountRiskParameter, EntityTypeUserPlugin, EntityTypeUserProperty, EntityTypeUserSession, EntityTypeUserSessionStats}
 
 var _EntityTypeNameToValueMap = map[string]EntityType{
-	_EntityTypeName[0:21]:           EntityTypeUnspecified,
-	_EntityTypeLowerName[0:21]:      EntityTypeUnspecified,
-	_EntityTypeName[21:38]:          EntityTypeAccount,
-	_EntityTypeLowerName[21:38]:     EntityTypeAccount,
-	_EntityTypeName[38:65]:          EntityTypeAccountRiskStatus,
-	_EntityTypeLowerName[38:65]:     EntityTypeAccountRiskStatus,
-	_EntityTypeName[65:85]:          EntityTypeAdminAlert,
-	_EntityTypeLowerName[65:85]:     EntityTypeAdminAlert,
-	_EntityTypeName[85:111]:         EntityTypeAdminAlertSignal,
-	_EntityTypeLowerName[85:111]:    EntityTypeAdminAlertSignal,
-	_EntityTypeName[111:132]:        EntityTypeCashBalance,
-	_EntityTypeLowerName[111:132]:   EntityTypeCashBalance,
-	_EntityTypeName[132:156]:        EntityTypeCashBalanceLog,
-	_EntityTypeLowerName[132:156]:   EntityTypeCashBalanceLog,
-	_EntityTypeName[156:170]:        EntityTypeChat,
-	_EntityTypeLowerName[156:170]:   EntityTypeChat,
-	_EntityTypeName[170:191]:        EntityTypeChatMessage,
-	_EntityTypeLowerName[170:191]:   EntityTypeChatMessage,
-	_EntityTypeName[191:214]:        EntityTypeClearingHouse,
-	_EntityTypeLowerName[191:214]:   EntityTypeClearingHouse,
-	_EntityTypeName[214:231]:        EntityTypeCommand,
-	_EntityTypeLowerName[214:231]:   EntityTypeCommand,
-	_EntityTypeName[231:254]:        EntityTypeCommandReport,
-	_EntityTypeLowerName[231:254]:   EntityTypeCommandReport,
-	_EntityTypeName[254:275]:        EntityTypeContactInfo,
-	_EntityTypeLowerName[254:275]:   EntityTypeContactInfo,
-	_EntityTypeName[275:293]:        EntityTypeContract,
-	_EntityTypeLowerName[275:293]:   EntityTypeContract,
-	_EntityTypeName[293:316]:        EntityTypeContractGroup,
-	_EntityTypeLowerName[293:316]:   EntityTypeContractGroup,
-	_EntityTypeName[316:340]:        EntityTypeContractMargin,
-	_EntityTypeLowerName[316:340]:   EntityTypeContractMargin,
-	_EntityTypeName[340:366]:        EntityTypeContractMaturity,
-	_EntityTypeLowerName[340:366]:   EntityTypeContractMaturity,
-	_EntityTypeName[366:384]:        EntityTypeCurrency,
-	_EntityTypeLowerName[366:384]:   EntityTypeCurrency,
-	_EntityTypeName[384:406]:        EntityTypeCurrencyRate,
-	_EntityTypeLowerName[384:406]:   EntityTypeCurrencyRate,
-	_EntityTypeName[406:427]:        EntityTypeEntitlement,
-	_EntityTypeLowerName[406:427]:   EntityTypeEntitlement,
-	_EntityTypeName[427:445]:        EntityTypeExchange,
-	_EntityTypeLowerName[427:445]:   EntityTypeExchange,
-	_EntityTypeName[445:470]:        EntityTypeExecutionReport,
-	_EntityTypeLowerName[445:470]:   EntityTypeExecutionReport,
-	_EntityTypeName[470:484]:        EntityTypeFill,
-	_EntityTypeLowerName[470:484]:   EntityTypeFill,
-	_EntityTypeName[484:501]:        EntityTypeFillFee,
-	_EntityTypeLowerName[484:501]:   EntityTypeFillFee,
-	_EntityTypeName[501:519]:        EntityTypeFillPair,
-	_EntityTypeLowerName[501:519]:   EntityTypeFillPair,
-	_EntityTypeName[519:543]:        EntityTypeMarginSnapshot,
-	_EntityTypeLowerName[519:543]:   EntityTypeMarginSnapshot,
-	_EntityTypeName[543:575]:        EntityTypeMarketDataSubscription,
-	_EntityTypeLowerName[543:575]:   EntityTypeMarketDataSubscription,
-	_EntityTypeName[575:620]:        EntityTypeMarketDataSubscriptionExchangeScope,
-	_EntityTypeLowerName[575:620]:   EntityTypeMarketDataSubscriptionExchangeScope,
-	_EntityTypeName[620:656]:        EntityTypeMarketDataSubscriptionPlan,
-	_EntityTypeLowerName[620:656]:   EntityTypeMarketDataSubscriptionPlan,
-	_EntityTypeName[656:671]:        EntityTypeOrder,
-	_EntityTypeLowerName[656:671]:   EntityTypeOrder,
-	_EntityTypeName[671:694]:        EntityTypeOrderStrategy,
-	_EntityTypeLowerName[671:694]:   EntityTypeOrderStrategy,
-	_EntityTypeName[694:721]:        EntityTypeOrderStrategyLink,
-	_EntityTypeLowerName[694:721]:   EntityTypeOrderStrategyLink,
-	_EntityTypeName[721:748]:        EntityTypeOrderStrategyType,
-	_EntityTypeLowerName[721:748]:   EntityTypeOrderStrategyType,
-	_EntityTypeName[748:770]:        EntityTypeOrderVersion,
-	_EntityTypeLowerName[748:770]:   EntityTypeOrderVersion,
-	_EntityTypeName[770:792]:        EntityTypeOrganization,
-	_EntityTypeLowerName[770:792]:   EntityTypeOrganization,
-	_EntityTypeName[792:828]:        EntityTypePermissionedAccountAutoLiq,
-	_EntityTypeLowerName[792:828]:   EntityTypePermissionedAccountAutoLiq,
-	_EntityTypeName[828:846]:        EntityTypePosition,
-	_EntityTypeLowerName[828:846]:   EntityTypePosition,
-	_EntityTypeName[846:863]:        EntityTypeProduct,
-	_EntityTypeLowerName[846:863]:   EntityTypeProduct,
-	_EntityTypeName[863:886]:        EntityTypeProductMargin,
-	_EntityTypeLowerName[863:886]:   EntityTypeProductMargin,
-	_EntityTypeName[886:910]:        EntityTypeProductSession,
-	_EntityTypeLowerName[886:910]:   EntityTypeProductSession,
-	_EntityTypeName[910:928]:        EntityTypeProperty,
-	_EntityTypeLowerName[910:928]:   EntityTypeProperty,
-	_EntityTypeName[928:966]:        EntityTypeSecondMarketDataSubscription,
-	_EntityTypeLowerName[928:966]:   EntityTypeSecondMarketDataSubscription,
-	_EntityTypeName[966:992]:        EntityTypeSpreadDefinition,
-	_EntityTypeLowerName[966:992]:   EntityTypeSpreadDefinition,
-	_EntityTypeName[992:1019]:       EntityTypeTradingPermission,
-	_EntityTypeLowerName[992:1019]:  EntityTypeTradingPermission,
-	_EntityTypeName[1019:1050]:      EntityTypeTradovateSubscription,
-	_EntityTypeLowerName[1019:1050]: EntityTypeTradovateSubscription,
-	_EntityTypeName[1050:1085]:      EntityTypeTradovateSubscriptionPlan,
-	_EntityTypeLowerName[1050:1085]: EntityTypeTradovateSubscriptionPlan,
-	_EntityTypeName[1085:1099]:      EntityTypeUser,
-	_EntityTypeLowerName[1085:1099]: EntityTypeUser,
-	_EntityTypeName[1099:1127]:      EntityTypeUserAccountAutoLiq,
-	_EntityTypeLowerName[1099:1127]: EntityTypeUserAccountAutoLiq,
-	_EntityTypeName[1127:1161]:      EntityTypeUserAccountPositionLimit,
-	_EntityTypeLowerName[1127:1161]: EntityTypeUserAccountPositionLimit,
-	_EntityTypeName[1161:1195]:      EntityTypeUserAccountRiskParameter,
-	_EntityTypeLowerName[1161:1195]: EntityTypeUserAccountRiskParameter,
-	_EntityTypeName[1195:1215]:      EntityTypeUserPlugin,
-	_EntityTypeLowerName[1195:1215]: EntityTypeUserPlugin,
-	_EntityTypeName[1215:1237]:      EntityTypeUserProperty,
-	_EntityTypeLowerName[1215:1237]: EntityTypeUserProperty,
-	_EntityTypeName[1237:1258]:      EntityTypeUserSession,
-	_EntityTypeLowerName[1237:1258]: EntityTypeUserSession,
-	_EntityTypeName[1258:1284]:      EntityTypeUserSessionStats,
-	_EntityTypeLowerName[1258:1284]: EntityTypeUserSessionStats,
+	_EntityTypeName[0:11]:         EntityTypeUnspecified,
+	_EntityTypeLowerName[0:11]:    EntityTypeUnspecified,
+	_EntityTypeName[11:18]:        EntityTypeAccount,
+	_EntityTypeLowerName[11:18]:   EntityTypeAccount,
+	_EntityTypeName[18:35]:        EntityTypeAccountRiskStatus,
+	_EntityTypeLowerName[18:35]:   EntityTypeAccountRiskStatus,
+	_EntityTypeName[35:45]:        EntityTypeAdminAlert,
+	_EntityTypeLowerName[35:45]:   EntityTypeAdminAlert,
+	_EntityTypeName[45:61]:        EntityTypeAdminAlertSignal,
+	_EntityTypeLowerName[45:61]:   EntityTypeAdminAlertSignal,
+	_EntityTypeName[61:72]:        EntityTypeCashBalance,
+	_EntityTypeLowerName[61:72]:   EntityTypeCashBalance,
+	_EntityTypeName[72:86]:        EntityTypeCashBalanceLog,
+	_EntityTypeLowerName[72:86]:   EntityTypeCashBalanceLog,
+	_EntityTypeName[86:90]:        EntityTypeChat,
+	_EntityTypeLowerName[86:90]:   EntityTypeChat,
+	_EntityTypeName[90:101]:       EntityTypeChatMessage,
+	_EntityTypeLowerName[90:101]:  EntityTypeChatMessage,
+	_EntityTypeName[101:114]:      EntityTypeClearingHouse,
+	_EntityTypeLowerName[101:114]: EntityTypeClearingHouse,
+	_EntityTypeName[114:121]:      EntityTypeCommand,
+	_EntityTypeLowerName[114:121]: EntityTypeCommand,
+	_EntityTypeName[121:134]:      EntityTypeCommandReport,
+	_EntityTypeLowerName[121:134]: EntityTypeCommandReport,
+	_EntityTypeName[134:145]:      EntityTypeContactInfo,
+	_EntityTypeLowerName[134:145]: EntityTypeContactInfo,
+	_EntityTypeName[145:153]:      EntityTypeContract,
+	_EntityTypeLowerName[145:153]: EntityTypeContract,
+	_EntityTypeName[153:166]:      EntityTypeContractGroup,
+	_EntityTypeLowerName[153:166]: EntityTypeContractGroup,
+	_EntityTypeName[166:180]:      EntityTypeContractMargin,
+	_EntityTypeLowerName[166:180]: EntityTypeContractMargin,
+	_EntityTypeName[180:196]:      EntityTypeContractMaturity,
+	_EntityTypeLowerName[180:196]: EntityTypeContractMaturity,
+	_EntityTypeName[196:204]:      EntityTypeCurrency,
+	_EntityTypeLowerName[196:204]: EntityTypeCurrency,
+	_EntityTypeName[204:216]:      EntityTypeCurrencyRate,
+	_EntityTypeLowerName[204:216]: EntityTypeCurrencyRate,
+	_EntityTypeName[216:227]:      EntityTypeEntitlement,
+	_EntityTypeLowerName[216:227]: EntityTypeEntitlement,
+	_EntityTypeName[227:235]:      EntityTypeExchange,
+	_EntityTypeLowerName[227:235]: EntityTypeExchange,
+	_EntityTypeName[235:250]:      EntityTypeExecutionReport,
+	_EntityTypeLowerName[235:250]: EntityTypeExecutionReport,
+	_EntityTypeName[250:254]:      EntityTypeFill,
+	_EntityTypeLowerName[250:254]: EntityTypeFill,
+	_EntityTypeName[254:261]:      EntityTypeFillFee,
+	_EntityTypeLowerName[254:261]: EntityTypeFillFee,
+	_EntityTypeName[261:269]:      EntityTypeFillPair,
+	_EntityTypeLowerName[261:269]: EntityTypeFillPair,
+	_EntityTypeName[269:283]:      EntityTypeMarginSnapshot,
+	_EntityTypeLowerName[269:283]: EntityTypeMarginSnapshot,
+	_EntityTypeName[283:305]:      EntityTypeMarketDataSubscription,
+	_EntityTypeLowerName[283:305]: EntityTypeMarketDataSubscription,
+	_EntityTypeName[305:340]:      EntityTypeMarketDataSubscriptionExchangeScope,
+	_EntityTypeLowerName[305:340]: EntityTypeMarketDataSubscriptionExchangeScope,
+	_EntityTypeName[340:366]:      EntityTypeMarketDataSubscriptionPlan,
+	_EntityTypeLowerName[340:366]: EntityTypeMarketDataSubscriptionPlan,
+	_EntityTypeName[366:371]:      EntityTypeOrder,
+	_EntityTypeLowerName[366:371]: EntityTypeOrder,
+	_EntityTypeName[371:384]:      EntityTypeOrderStrategy,
+	_EntityTypeLowerName[371:384]: EntityTypeOrderStrategy,
+	_EntityTypeName[384:401]:      EntityTypeOrderStrategyLink,
+	_EntityTypeLowerName[384:401]: EntityTypeOrderStrategyLink,
+	_EntityTypeName[401:418]:      EntityTypeOrderStrategyType,
+	_EntityTypeLowerName[401:418]: EntityTypeOrderStrategyType,
+	_EntityTypeName[418:430]:      EntityTypeOrderVersion,
+	_EntityTypeLowerName[418:430]: EntityTypeOrderVersion,
+	_EntityTypeName[430:442]:      EntityTypeOrganization,
+	_EntityTypeLowerName[430:442]: EntityTypeOrganization,
+	_EntityTypeName[442:468]:      EntityTypePermissionedAccountAutoLiq,
+	_EntityTypeLowerName[442:468]: EntityTypePermissionedAccountAutoLiq,
+	_EntityTypeName[468:476]:      EntityTypePosition,
+	_EntityTypeLowerName[468:476]: EntityTypePosition,
+	_EntityTypeName[476:483]:      EntityTypeProduct,
+	_EntityTypeLowerName[476:483]: EntityTypeProduct,
+	_EntityTypeName[483:496]:      EntityTypeProductMargin,
+	_EntityTypeLowerName[483:496]: EntityTypeProductMargin,
+	_EntityTypeName[496:510]:      EntityTypeProductSession,
+	_EntityTypeLowerName[496:510]: EntityTypeProductSession,
+	_EntityTypeName[510:518]:      EntityTypeProperty,
+	_EntityTypeLowerName[510:518]: EntityTypeProperty,
+	_EntityTypeName[518:546]:      EntityTypeSecondMarketDataSubscription,
+	_EntityTypeLowerName[518:546]: EntityTypeSecondMarketDataSubscription,
+	_EntityTypeName[546:562]:      EntityTypeSpreadDefinition,
+	_EntityTypeLowerName[546:562]: EntityTypeSpreadDefinition,
+	_EntityTypeName[562:579]:      EntityTypeTradingPermission,
+	_EntityTypeLowerName[562:579]: EntityTypeTradingPermission,
+	_EntityTypeName[579:600]:      EntityTypeTradovateSubscription,
+	_EntityTypeLowerName[579:600]: EntityTypeTradovateSubscription,
+	_EntityTypeName[600:625]:      EntityTypeTradovateSubscriptionPlan,
+	_EntityTypeLowerName[600:625]: EntityTypeTradovateSubscriptionPlan,
+	_EntityTypeName[625:629]:      EntityTypeUser,
+	_EntityTypeLowerName[625:629]: EntityTypeUser,
+	_EntityTypeName[629:647]:      EntityTypeUserAccountAutoLiq,
+	_EntityTypeLowerName[629:647]: EntityTypeUserAccountAutoLiq,
+	_EntityTypeName[647:671]:      EntityTypeUserAccountPositionLimit,
+	_EntityTypeLowerName[647:671]: EntityTypeUserAccountPositionLimit,
+	_EntityTypeName[671:695]:      EntityTypeUserAccountRiskParameter,
+	_EntityTypeLowerName[671:695]: EntityTypeUserAccountRiskParameter,
+	_EntityTypeName[695:705]:      EntityTypeUserPlugin,
+	_EntityTypeLowerName[695:705]: EntityTypeUserPlugin,
+	_EntityTypeName[705:717]:      EntityTypeUserProperty,
+	_EntityTypeLowerName[705:717]: EntityTypeUserProperty,
+	_EntityTypeName[717:728]:      EntityTypeUserSession,
+	_EntityTypeLowerName[717:728]: EntityTypeUserSession,
+	_EntityTypeName[728:744]:      EntityTypeUserSessionStats,
+	_EntityTypeLowerName[728:744]: EntityTypeUserSessionStats,
 }
 
 var _EntityTypeNames = []string{
-	_EntityTypeName[0:21],
-	_EntityTypeName[21:38],
-	_EntityTypeName[38:65],
-	_EntityTypeName[65:85],
-	_EntityTypeName[85:111],
-	_EntityTypeName[111:132],
-	_EntityTypeName[132:156],
-	_EntityTypeName[156:170],
-	_EntityTypeName[170:191],
-	_EntityTypeName[191:214],
-	_EntityTypeName[214:231],
-	_EntityTypeName[231:254],
-	_EntityTypeName[254:275],
-	_EntityTypeName[275:293],
-	_EntityTypeName[293:316],
-	_EntityTypeName[316:340],
+	_EntityTypeName[0:11],
+	_EntityTypeName[11:18],
+	_EntityTypeName[18:35],
+	_EntityTypeName[35:45],
+	_EntityTypeName[45:61],
+	_EntityTypeName[61:72],
+	_EntityTypeName[72:86],
+	_EntityTypeName[86:90],
+	_EntityTypeName[90:101],
+	_EntityTypeName[101:114],
+	_EntityTypeName[114:121],
+	_EntityTypeName[121:134],
+	_EntityTypeName[134:145],
+	_EntityTypeName[145:153],
+	_EntityTypeName[153:166],
+	_EntityTypeName[166:180],
+	_EntityTypeName[180:196],
+	_EntityTypeName[196:204],
+	_EntityTypeName[204:216],
+	_EntityTypeName[216:227],
+	_EntityTypeName[227:235],
+	_EntityTypeName[235:250],
+	_EntityTypeName[250:254],
+	_EntityTypeName[254:261],
+	_EntityTypeName[261:269],
+	_EntityTypeName[269:283],
+	_EntityTypeName[283:305],
+	_EntityTypeName[305:340],
 	_EntityTypeName[340:366],
-	_EntityTypeName[366:384],
-	_EntityTypeName[384:406],
-	_EntityTypeName[406:427],
-	_EntityTypeName[427:445],
-	_EntityTypeName[445:470],
-	_EntityTypeName[470:484],
-	_EntityTypeName[484:501],
-	_EntityTypeName[501:519],
-	_EntityTypeName[519:543],
-	_EntityTypeName[543:575],
-	_EntityTypeName[575:620],
-	_EntityTypeName[620:656],
-	_EntityTypeName[656:671],
-	_EntityTypeName[671:694],
-	_EntityTypeName[694:721],
-	_EntityTypeName[721:748],
-	_EntityTypeName[748:770],
-	_EntityTypeName[770:792],
-	_EntityTypeName[792:828],
-	_EntityTypeName[828:846],
-	_EntityTypeName[846:863],
-	_EntityTypeName[863:886],
-	_EntityTypeName[886:910],
-	_EntityTypeName[910:928],
-	_EntityTypeName[928:966],
-	_EntityTypeName[966:992],
-	_EntityTypeName[992:1019],
-	_EntityTypeName[1019:1050],
-	_EntityTypeName[1050:1085],
-	_EntityTypeName[1085:1099],
-	_EntityTypeName[1099:1127],
-	_EntityTypeName[1127:1161],
-	_EntityTypeName[1161:1195],
-	_EntityTypeName[1195:1215],
-	_EntityTypeName[1215:1237],
-	_EntityTypeName[1237:1258],
-	_EntityTypeName[1258:1284],
+	_EntityTypeName[366:371],
+	_EntityTypeName[371:384],
+	_EntityTypeName[384:401],
+	_EntityTypeName[401:418],
+	_EntityTypeName[418:430],
+	_EntityTypeName[430:442],
+	_EntityTypeName[442:468],
+	_EntityTypeName[468:476],
+	_EntityTypeName[476:483],
+	_EntityTypeName[483:496],
+	_EntityTypeName[496:510],
+	_EntityTypeName[510:518],
+	_EntityTypeName[518:546],
+	_EntityTypeName[546:562],
+	_EntityTypeName[562:579],
+	_EntityTypeName[579:600],
+	_EntityTypeName[600:625],
+	_EntityTypeName[625:629],
+	_EntityTypeName[629:647],
+	_EntityTypeName[647:671],
+	_EntityTypeName[671:695],
+	_EntityTypeName[695:705],
+	_EntityTypeName[705:717],
+	_EntityTypeName[717:728],
+	_EntityTypeName[728:744],
 }
 
 // EntityTypeString retrieves an enum value from the enum constants string name.
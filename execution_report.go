@@ -0,0 +1,39 @@
+package tradovate
+
+import "time"
+
+// Report of something that happened to an order: acknowledged,
+// replaced, traded, canceled, rejected...
+type ExecutionReport struct {
+	ID              uint        `json:"id"`
+	CommandID       uint        `json:"commandId"`
+	Name            string      `json:"name"`
+	AccountID       uint        `json:"accountId"`
+	ContractID      uint        `json:"contractId"`
+	Timestamp       time.Time   `json:"timestamp"`
+	OrderID         uint        `json:"orderId"`
+	ExecType        string      `json:"execType"` // New, Replaced, Trade, Canceled, Rejected...
+	ExecRefID       string      `json:"execRefId"`
+	Status          OrderStatus `json:"ordStatus"`
+	Action          Action      `json:"action"`
+	CumQty          uint32      `json:"cumQty"`
+	AvgPx           float64     `json:"avgPx"`
+	LastQty         uint32      `json:"lastQty"`
+	LastPx          float64     `json:"lastPx"`
+	RejectReason    string      `json:"rejectReason"`
+	Text            string      `json:"text"`
+	ExchangeOrderID string      `json:"exchangeOrderId"`
+}
+
+func (e *EntityMsg) ExecutionReport() (*ExecutionReport, error) {
+	return decode[ExecutionReport](e)
+}
+
+func (e *EntityMsg) MustExecutionReport() *ExecutionReport {
+	r, err := e.ExecutionReport()
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
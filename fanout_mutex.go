@@ -7,75 +7,163 @@ import (
 	"time"
 )
 
+// ErrDraining is returned for requests made while the socket is
+// draining, either because you called Drain or because the server
+// announced a shutdown and the socket is waiting to reconnect
+var ErrDraining = fmt.Errorf("%w: socket is draining", ErrNotReady)
+
+// fanoutMutex routes responses to the requests waiting on them
 type fanoutMutex struct {
 	mu       sync.Mutex
-	timeout  time.Duration
+	timeout  time.Duration // used when the caller's ctx has no deadline
 	acc      int
-	channels []*socketReq
+	waiting  map[int]*socketReq
+	draining bool
+	idle     chan struct{} // closed when waiting empties, if anyone's watching
 }
 
 type socketReq struct {
-	c        chan *rawMsg
+	f        *fanoutMutex
+	c        chan *rawMsg // closed without a value on expiry
 	deadline time.Time
+	timer    *time.Timer
 	id       int
 }
 
-func (f *socketReq) wait(userCtx, connCtx context.Context) (*rawMsg, error) {
-	ctx, cancel := context.WithDeadline(userCtx, f.deadline)
-	defer cancel()
-
-	for {
-		select {
-		case <-connCtx.Done():
-			return nil, fmt.Errorf("websocket connection killed: %w", ctx.Err())
-		case <-userCtx.Done():
-			return nil, ctx.Err()
-		case v := <-f.c:
-			if v == nil {
-				return nil, fmt.Errorf("channel closed early; timeout")
-			}
-
-			return v, nil
-		}
+// register a new request. It expires at the ctx deadline if there is
+// one, shorter or longer than the default, otherwise after the default
+// timeout
+func (f *fanoutMutex) request(ctx context.Context, skipDrain bool) (*socketReq, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(f.timeout)
 	}
-}
 
-func (f *fanoutMutex) request() *socketReq {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	c := &socketReq{
+	if f.draining && !skipDrain {
+		return nil, ErrDraining
+	}
+
+	r := &socketReq{
+		f:        f,
 		c:        make(chan *rawMsg, 1),
-		deadline: time.Now().Add(f.timeout),
+		deadline: deadline,
 		id:       f.acc,
 	}
 	f.acc++
 
-	f.channels = append(f.channels, c)
-	return c
+	f.waiting[r.id] = r
+	r.timer = time.AfterFunc(time.Until(deadline), r.expire)
+	return r, nil
+}
+
+// remove the request, reporting whether it was still waiting. Whoever
+// removes it owns the channel
+func (r *socketReq) remove() bool {
+	r.f.mu.Lock()
+	defer r.f.mu.Unlock()
+
+	if r.f.waiting[r.id] != r {
+		return false
+	}
+
+	r.f.delete(r)
+	return true
+}
+
+// remove a request with the lock held
+func (f *fanoutMutex) delete(r *socketReq) {
+	delete(f.waiting, r.id)
+	r.timer.Stop()
+
+	if len(f.waiting) == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+func (r *socketReq) expire() {
+	if r.remove() {
+		close(r.c)
+	}
 }
 
-func (f *fanoutMutex) pub(r *rawMsg) {
+// stop waiting, e.g. because the request never made it out
+func (r *socketReq) cancel() { r.remove() }
+
+func (r *socketReq) wait(userCtx, connCtx context.Context) (*rawMsg, error) {
+	select {
+	case <-connCtx.Done():
+		r.cancel()
+		return nil, fmt.Errorf("websocket connection killed: %w", connCtx.Err())
+	case <-userCtx.Done():
+		r.cancel()
+		return nil, userCtx.Err()
+	case v := <-r.c:
+		if v == nil {
+			return nil, fmt.Errorf("no response to request %d by %s: %w", r.id, r.deadline.Format(time.RFC3339Nano), ErrTimeout)
+		}
+
+		return v, nil
+	}
+}
+
+// send the response to whoever's waiting on it, reporting if anyone was
+func (f *fanoutMutex) pub(msg *rawMsg) bool {
+	f.mu.Lock()
+	r, ok := f.waiting[msg.ID]
+	if ok {
+		f.delete(r)
+	}
+	f.mu.Unlock()
+
+	if ok {
+		r.c <- msg
+		close(r.c)
+	}
+
+	return ok
+}
+
+// stop accepting new requests
+func (f *fanoutMutex) drain() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	f.draining = true
+}
 
-	goodPtr, n := 0, len(f.channels)
-	for i := 0; i < n; i++ {
-		v := f.channels[i]
-		if v.deadline.Before(time.Now()) {
-			close(v.c)
-			continue
-		}
+func (f *fanoutMutex) resume() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.draining = false
+}
 
-		if v.id == r.ID {
-			v.c <- r
-			close(v.c)
-			continue
-		}
+func (f *fanoutMutex) pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiting)
+}
 
-		f.channels[goodPtr] = v
-		goodPtr++
+// wait for every request in flight to get a response or expire
+func (f *fanoutMutex) waitIdle(ctx context.Context) error {
+	f.mu.Lock()
+	if len(f.waiting) == 0 {
+		f.mu.Unlock()
+		return nil
 	}
 
-	f.channels = f.channels[:goodPtr]
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-idle:
+		return nil
+	}
 }
@@ -1,179 +1,200 @@
 package tradovate
 
 import (
-	"fmt"
-	"strings"
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
 
-func (f *fanoutMutex) equal(x *fanoutMutex) bool {
-	if len(f.channels) != len(x.channels) {
-		return false
-	}
-
-	for i := 0; i < len(f.channels); i++ {
-		if eq := f.channels[i].equal(x.channels[i]); !eq {
-			return false
-		}
-	}
-
-	return f.acc == x.acc && f.timeout == x.timeout
+func newFanout(timeout time.Duration) *fanoutMutex {
+	return &fanoutMutex{acc: 1, timeout: timeout, waiting: map[int]*socketReq{}}
 }
 
-func (f *fanoutMutex) String() string {
-	var sb strings.Builder
-
-	for i, v := range f.channels {
-		sb.WriteString(v.String())
-		if i != len(f.channels)-1 {
-			sb.WriteRune(',')
-		}
+func TestPub(mainTest *testing.T) {
+	testCases := []struct {
+		name          string
+		requests      int
+		msg           *rawMsg
+		expectedFound bool
+		expectedLeft  int
+	}{
+		{
+			name:          "nobody waiting",
+			msg:           &rawMsg{ID: 1},
+			expectedFound: false,
+		},
+		{
+			name:          "delivers to the right request and removes it",
+			requests:      3,
+			msg:           &rawMsg{ID: 2},
+			expectedFound: true,
+			expectedLeft:  2,
+		},
+		{
+			name:          "unknown ID leaves everything waiting",
+			requests:      3,
+			msg:           &rawMsg{ID: 27},
+			expectedFound: false,
+			expectedLeft:  3,
+		},
 	}
 
-	return fmt.Sprintf(
-		"{Acc:%d,\n\tChannels:[%s],\n\tDeadline: %s}",
-		f.acc, sb.String(), f.timeout,
-	)
-}
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			f := newFanout(time.Minute)
 
-func (s *socketReq) equal(x *socketReq) bool {
-	return (x.deadline.Truncate(time.Millisecond) == s.deadline.Truncate(time.Millisecond) &&
-		x.id == s.id)
-}
+			reqs := make([]*socketReq, tc.requests)
+			for i := range reqs {
+				reqs[i], _ = f.request(context.Background(), false)
+			}
 
-func (s *socketReq) String() string {
-	return fmt.Sprintf(
-		"{Deadline: %s, ID: %d}",
-		s.deadline, s.id,
-	)
-}
+			if found := f.pub(tc.msg); found != tc.expectedFound {
+				tt.Errorf("found: want %v got %v", tc.expectedFound, found)
+			}
 
-var futureDeadline = time.Now().Add(time.Second).Truncate(time.Second)
+			if len(f.waiting) != tc.expectedLeft {
+				tt.Errorf("want %d left waiting, got %d", tc.expectedLeft, len(f.waiting))
+			}
 
-func newValidReq(id int) *socketReq {
-	return &socketReq{id: id, deadline: futureDeadline, c: make(chan *rawMsg, 1)}
-}
+			for _, r := range reqs {
+				if r.id != tc.msg.ID {
+					continue
+				}
 
-func newInvalidReq(id int) *socketReq {
-	return &socketReq{id: id, c: make(chan *rawMsg, 1)}
+				if v, err := r.wait(context.Background(), context.Background()); err != nil || v != tc.msg {
+					tt.Errorf("wanted the msg, got %v %v", v, err)
+				}
+			}
+		})
+	}
 }
 
-func TestPub(mainTest *testing.T) {
+func TestWait(mainTest *testing.T) {
+	killed, kill := context.WithCancel(context.Background())
+	kill()
 
 	testCases := []struct {
-		name  string
-		msg   *rawMsg
-		start *fanoutMutex
-		end   *fanoutMutex
+		name        string
+		timeout     time.Duration // default
+		ctx         func() (context.Context, context.CancelFunc)
+		connCtx     context.Context
+		respondIn   time.Duration // 0 never responds
+		expectedErr error
 	}{
 		{
-			name:  "base case",
-			msg:   &rawMsg{},
-			start: &fanoutMutex{},
-			end:   &fanoutMutex{},
+			name:      "responds in time",
+			timeout:   time.Second,
+			ctx:       func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			respondIn: time.Millisecond,
 		},
 		{
-			name: "removes an event that's past its deadline in case of a write error",
-			msg:  &rawMsg{},
-			start: &fanoutMutex{
-				channels: []*socketReq{{c: make(chan *rawMsg)}},
-			},
-			end: &fanoutMutex{},
+			name:        "default timeout expires",
+			timeout:     10 * time.Millisecond,
+			ctx:         func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			expectedErr: ErrTimeout,
 		},
 		{
-			name: "removes a channel after delivering its msg",
-			msg:  &rawMsg{},
-			start: &fanoutMutex{
-				channels: []*socketReq{
-					{deadline: time.Now().Add(time.Second), c: make(chan *rawMsg, 1)},
-				},
+			name:    "longer ctx deadline wins over the default",
+			timeout: 10 * time.Millisecond,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Second)
 			},
-			end: &fanoutMutex{},
+			respondIn: 50 * time.Millisecond,
 		},
 		{
-			name: "stable in removal of elements",
-			msg:  &rawMsg{ID: 4},
-			start: &fanoutMutex{
-				channels: []*socketReq{
-					newValidReq(1),
-					newValidReq(2),
-					newValidReq(3),
-					newValidReq(4),
-					newValidReq(5),
-					newValidReq(6),
-				},
-			},
-			end: &fanoutMutex{
-				channels: []*socketReq{
-					newValidReq(1),
-					newValidReq(2),
-					newValidReq(3),
-					newValidReq(5),
-					newValidReq(6),
-				},
+			name:    "shorter ctx deadline wins over the default",
+			timeout: time.Hour,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
 			},
+			expectedErr: ErrTimeout,
 		},
 		{
-			name: "example with various removals: removing several invalids, and ID#27 (unrealistic state, but also still recovers)",
-			msg:  &rawMsg{ID: 27},
-			start: &fanoutMutex{
-				channels: []*socketReq{
-					newValidReq(3),
-					newValidReq(1),
-					newInvalidReq(93452),
-					newValidReq(2),
-					newInvalidReq(56592),
-					newValidReq(5),
-					newInvalidReq(92234),
-					newInvalidReq(9432),
-					newInvalidReq(9122),
-					newInvalidReq(92),
-					newValidReq(26),
-					newInvalidReq(94332),
-					newInvalidReq(11111192),
-					newValidReq(21),
-					newInvalidReq(912232),
-					newInvalidReq(9122),
-					newValidReq(27),
-					newInvalidReq(9562),
-					newInvalidReq(911112),
-					newValidReq(24),
-					newValidReq(6),
-					newValidReq(21),
-				},
-			},
-			end: &fanoutMutex{
-				channels: []*socketReq{
-					newValidReq(3),
-					newValidReq(1),
-					newValidReq(2),
-					newValidReq(5),
-					newValidReq(26),
-					newValidReq(21),
-					newValidReq(24),
-					newValidReq(6),
-					newValidReq(21),
-				},
+			name:    "canceled caller",
+			timeout: time.Hour,
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
 			},
+			expectedErr: context.Canceled,
+		},
+		{
+			name:        "dead connection",
+			timeout:     time.Hour,
+			ctx:         func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			connCtx:     killed,
+			expectedErr: context.Canceled,
 		},
 	}
 
 	for _, tc := range testCases {
 		mainTest.Run(tc.name, func(tt *testing.T) {
-			tc.start.pub(tc.msg)
-			if !tc.start.equal(tc.end) {
-				tt.Errorf(
-					"final state not correct\nexpected: %+v\nactual: %+v",
-					tc.end,
-					tc.start,
-				)
-				return
+			f := newFanout(tc.timeout)
+			ctx, cancel := tc.ctx()
+			defer cancel()
+
+			r, _ := f.request(ctx, false)
+			if tc.respondIn > 0 {
+				time.AfterFunc(tc.respondIn, func() { f.pub(&rawMsg{ID: r.id}) })
+			}
+
+			connCtx := tc.connCtx
+			if connCtx == nil {
+				connCtx = context.Background()
+			}
+
+			_, err := r.wait(ctx, connCtx)
+			if tc.expectedErr == nil && err != nil {
+				tt.Errorf("wanted no error, got %v", err)
+			}
+
+			// timeouts surface as ErrTimeout or the ctx deadline, whichever fires first
+			matches := errors.Is(err, tc.expectedErr) || tc.expectedErr == ErrTimeout && isTimeout(err)
+			if tc.expectedErr != nil && !matches {
+				tt.Errorf("wanted %v, got %v", tc.expectedErr, err)
 			}
 
-			for _, v := range tc.start.channels {
-				close(v.c) // if it panics, this is a problem
+			// abandoned and expired waiters are gone right away, not on the next pub
+			if n := len(f.waiting); n != 0 {
+				tt.Errorf("wanted nothing left waiting, got %d", n)
 			}
 		})
 	}
 }
+
+func TestDrain(t *testing.T) {
+	f := newFanout(time.Minute)
+	r, _ := f.request(context.Background(), false)
+
+	f.drain()
+	if _, err := f.request(context.Background(), false); !errors.Is(err, ErrDraining) {
+		t.Errorf("wanted ErrDraining, got %v", err)
+	}
+
+	auth, err := f.request(context.Background(), true)
+	if err != nil {
+		t.Fatalf("skipping the drain should work, got %v", err)
+	}
+	auth.cancel()
+
+	idle := make(chan error)
+	go func() { idle <- f.waitIdle(context.Background()) }()
+
+	select {
+	case <-idle:
+		t.Fatal("shouldn't be idle with a request in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	f.pub(&rawMsg{ID: r.id})
+	if err = <-idle; err != nil {
+		t.Errorf("wanted idle, got %v", err)
+	}
+
+	f.resume()
+	if _, err = f.request(context.Background(), false); err != nil {
+		t.Errorf("should accept requests after resuming, got %v", err)
+	}
+}
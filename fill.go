@@ -0,0 +1,75 @@
+package tradovate
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const fillListURL = "fill/list"
+
+type Fill struct {
+	ID            uint      `json:"id"`
+	OrderID       uint      `json:"orderId"`
+	ContractID    int       `json:"contractId"`
+	Timestamp     time.Time `json:"timestamp"`
+	TradeDate     time.Time `json:"tradeDate"` // set to 00:00:00-0500 (nyse timezone)
+	Action        Action    `json:"action"`
+	Qty           uint32    `json:"qty"`
+	Price         float64   `json:"price"`
+	Active        bool      `json:"active"`
+	FinallyPaired uint32    `json:"finallyPaired"`
+}
+
+func (f *Fill) UnmarshalJSON(b []byte) error {
+	type fill struct {
+		ID            uint      `json:"id"`
+		OrderID       uint      `json:"orderId"`
+		ContractID    int       `json:"contractId"`
+		Timestamp     time.Time `json:"timestamp"`
+		TradeDate     tradeDate `json:"tradeDate"`
+		Action        Action    `json:"action"`
+		Qty           uint32    `json:"qty"`
+		Price         float64   `json:"price"`
+		Active        bool      `json:"active"`
+		FinallyPaired uint32    `json:"finallyPaired"`
+	}
+
+	var x fill
+	if err := json.Unmarshal(b, &x); err != nil {
+		return err
+	}
+
+	*f = Fill{
+		ID:            x.ID,
+		OrderID:       x.OrderID,
+		ContractID:    x.ContractID,
+		Timestamp:     x.Timestamp,
+		TradeDate:     x.TradeDate.time(),
+		Action:        x.Action,
+		Qty:           x.Qty,
+		Price:         x.Price,
+		Active:        x.Active,
+		FinallyPaired: x.FinallyPaired,
+	}
+	return nil
+}
+
+func (e *EntityMsg) Fill() (*Fill, error) { return decode[Fill](e) }
+func (e *EntityMsg) MustFill() *Fill {
+	f, err := e.Fill()
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+func (s *WS) ListFills(ctx context.Context) ([]*Fill, error) {
+	var x []*Fill
+	if err := s.do(ctx, fillListURL, nil, nil, &x); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
@@ -0,0 +1,122 @@
+package tradovate
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrServerSilent is sent to the error handler when no frames of any
+// kind arrive within the silence timeout; the connection is assumed dead
+var ErrServerSilent = errors.New("server went silent")
+
+// how many recent round trips Health computes percentiles over
+const rttSamples = 256
+
+// The server heartbeats every few seconds, so going this long without
+// any frame means the connection is dead even if TCP hasn't noticed.
+// The socket closes and the error handler gets ErrServerSilent. Defaults
+// to 15s; 0 disables it
+func WithSilenceTimeout(d time.Duration) WSOpt {
+	return func(s *WS) { s.silence = d }
+}
+
+// Health is a snapshot of the connection
+type Health struct {
+	State     State
+	LastFrame time.Time // last frame of any kind, heartbeats included
+
+	// round trip of recent request/response pairs, zero until the
+	// first response
+	RTTP50 time.Duration
+	RTTP99 time.Duration
+
+	Pending int // requests waiting on a response
+}
+
+// Report the connection's health
+func (s *WS) Health() Health {
+	h := Health{
+		State:   s.State(),
+		Pending: s.fm.pending(),
+	}
+
+	if t := s.lastFrame.Load(); t > 0 {
+		h.LastFrame = time.Unix(0, t)
+	}
+
+	h.RTTP50, h.RTTP99 = s.rtt.percentiles()
+	return h
+}
+
+// close the session if it goes silent. Ends with the session
+func (s *WS) watchdog(ss *session) {
+	defer s.wg.Done()
+
+	every := s.silence / 4
+	if every <= 0 {
+		every = s.silence
+	}
+
+	t := time.NewTicker(every)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.connCtx.Done():
+			return
+		case <-ss.done:
+			return
+		case <-t.C:
+		}
+
+		last := time.Unix(0, s.lastFrame.Load())
+		if quiet := time.Since(last); quiet >= s.silence {
+			s.log.LogAttrs(s.connCtx, slog.LevelWarn, "no frames from server, assuming the connection is dead",
+				slog.Duration("silence", quiet),
+			)
+
+			// a half open connection won't answer a close handshake
+			ss.end(fmt.Errorf("%w: nothing received in %s", ErrServerSilent, quiet.Round(time.Millisecond)))
+			ss.conn.CloseNow()
+			return
+		}
+	}
+}
+
+// ring of recent round trips
+type rttWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (w *rttWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < rttSamples {
+		w.samples = append(w.samples, d)
+		return
+	}
+
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % rttSamples
+}
+
+func (w *rttWindow) percentiles() (p50, p99 time.Duration) {
+	w.mu.Lock()
+	x := slices.Clone(w.samples)
+	w.mu.Unlock()
+
+	if len(x) == 0 {
+		return 0, 0
+	}
+
+	slices.Sort(x)
+	rank := func(p float64) time.Duration { return x[int(p*float64(len(x)-1))] }
+	return rank(.5), rank(.99)
+}
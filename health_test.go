@@ -0,0 +1,93 @@
+package tradovate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestHealth(mainTest *testing.T) {
+	const silence = 100 * time.Millisecond
+
+	testCases := []struct {
+		name          string
+		heartbeat     bool
+		expectedErr   error
+		expectedState tradovate.State
+	}{
+		{
+			name:          "heartbeats keep it alive",
+			heartbeat:     true,
+			expectedState: tradovate.StateReady,
+		},
+		{
+			name:          "silence kills it",
+			expectedErr:   tradovate.ErrServerSilent,
+			expectedState: tradovate.StateClosed,
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			srv := tradovatetest.NewServer()
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			errs := make(chan error, 1)
+			ws, err := srv.NewSocket(ctx,
+				tradovate.WithSilenceTimeout(silence),
+				tradovate.WithErrHandler(func(err error) { errs <- err }),
+			)
+			if err != nil {
+				tt.Fatalf("failed connecting: %v", err)
+			}
+			defer ws.Close()
+
+			if _, err = ws.ListAccounts(ctx); err != nil {
+				tt.Fatalf("failed listing: %v", err)
+			}
+
+			h := ws.Health()
+			if h.State != tradovate.StateReady || h.Pending != 0 {
+				tt.Errorf("wanted a ready socket with nothing pending, got %+v", h)
+			}
+
+			if h.RTTP50 <= 0 || h.RTTP99 < h.RTTP50 {
+				tt.Errorf("wanted a round trip measured, got p50 %s p99 %s", h.RTTP50, h.RTTP99)
+			}
+
+			if time.Since(h.LastFrame) > time.Second {
+				tt.Errorf("last frame should be recent, got %s", h.LastFrame)
+			}
+
+			stop := time.After(4 * silence)
+		loop:
+			for {
+				select {
+				case err = <-errs:
+					break loop
+				case <-stop:
+					break loop
+				case <-time.After(silence / 4):
+					if tc.heartbeat {
+						srv.Heartbeat()
+					}
+				}
+			}
+
+			if !errors.Is(err, tc.expectedErr) {
+				tt.Errorf("wanted %v, got %v", tc.expectedErr, err)
+			}
+
+			if s := ws.Health().State; s != tc.expectedState {
+				tt.Errorf("wrong state: want %s got %s", tc.expectedState, s)
+			}
+		})
+	}
+}
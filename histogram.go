@@ -55,7 +55,12 @@ func (s *WS) SubscribeHistogramSymbol(ctx context.Context, symbol string) error
 }
 
 func (s *WS) subscribeHistogram(ctx context.Context, x any) error {
-	return s.do(ctx, subscribeHistogram, nil, map[string]any{"symbol": x}, nil)
+	if err := s.do(ctx, subscribeHistogram, nil, map[string]any{"symbol": x}, nil); err != nil {
+		return err
+	}
+
+	s.md.add(subscribeHistogram, x)
+	return nil
 }
 
 func (s *WS) UnsubscribeHistogramID(ctx context.Context, id int) error {
@@ -67,5 +72,10 @@ func (s *WS) UnsubscribeHistogramSymbol(ctx context.Context, symbol string) erro
 }
 
 func (s *WS) unsubscribeHistogram(ctx context.Context, x any) error {
-	return s.do(ctx, unsubscribeHistogram, nil, map[string]any{"symbol": x}, nil)
+	if err := s.do(ctx, unsubscribeHistogram, nil, map[string]any{"symbol": x}, nil); err != nil {
+		return err
+	}
+
+	s.md.remove(subscribeHistogram, x)
+	return nil
 }
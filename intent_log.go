@@ -0,0 +1,163 @@
+package tradovate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Intent is an order a strategy meant to place, recorded before it's
+// sent so a restarted process can tell its orders from anyone else's
+type Intent struct {
+	ClOrdID     string    `json:"clOrdId"`
+	Text        string    `json:"text,omitzero"`
+	CustomTag50 string    `json:"customTag50,omitzero"`
+	AccountID   uint      `json:"accountId,omitzero"`
+	Symbol      string    `json:"symbol"`
+	Action      Action    `json:"action"`
+	Qty         uint32    `json:"qty"`
+	Created     time.Time `json:"created"`
+
+	OrderID   uint   `json:"orderId,omitzero"`   // once it's known
+	FilledQty uint32 `json:"filledQty,omitzero"` // fills the strategy has accounted for
+	Done      bool   `json:"done,omitzero"`      // the order won't change again
+}
+
+// The intent behind an order request. The clOrdId should be set first
+func NewIntent(r *OrderReq) Intent {
+	return Intent{
+		ClOrdID:     r.ClientOrderID,
+		Text:        r.Text,
+		CustomTag50: r.CustomTag50,
+		AccountID:   r.AccountID,
+		Symbol:      r.Symbol,
+		Action:      r.Action,
+		Qty:         r.OrderQty,
+		Created:     time.Now(),
+	}
+}
+
+// IntentLog persists intents. Recording an intent with a clOrdId already
+// in the log replaces it. Implementations must be safe for concurrent use
+type IntentLog interface {
+	Record(Intent) error
+	Intents() ([]Intent, error)
+}
+
+// FileIntentLog is an IntentLog appending JSON lines to a file, synced on
+// every record. Updates are appended too; the last line for a clOrdId wins
+type FileIntentLog struct {
+	mu   sync.Mutex
+	f    *os.File
+	enc  *json.Encoder
+	path string
+}
+
+func OpenIntentLog(path string) (*FileIntentLog, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening intent log: %w", err)
+	}
+
+	if err = cutTornLine(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed repairing intent log: %w", err)
+	}
+
+	return &FileIntentLog{f: f, enc: json.NewEncoder(f), path: path}, nil
+}
+
+// a crash mid-write leaves a partial last line. Appending after it would
+// bury it, and the log would be unreadable from then on, so it's cut back
+// to the last complete line
+func cutTornLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4096)
+	for end := info.Size(); end > 0; {
+		start := max(end-int64(len(buf)), 0)
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			if size := start + int64(i) + 1; size < info.Size() {
+				return f.Truncate(size)
+			}
+			return nil
+		}
+		end = start
+	}
+
+	// no complete line at all
+	if info.Size() > 0 {
+		return f.Truncate(0)
+	}
+	return nil
+}
+
+func (l *FileIntentLog) Record(x Intent) error {
+	if x.ClOrdID == "" {
+		return fmt.Errorf("intent has no clOrdId")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.enc.Encode(x); err != nil {
+		return fmt.Errorf("failed recording intent %s: %w", x.ClOrdID, err)
+	}
+	return l.f.Sync()
+}
+
+// Every intent in the log, in the order they were first recorded
+func (l *FileIntentLog) Intents() ([]Intent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading intent log: %w", err)
+	}
+	defer f.Close()
+
+	var x []Intent
+	idx := map[string]int{}
+
+	// a bad last line is a write torn by a crash and is skipped; a bad
+	// line anywhere else is corruption
+	var torn error
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		if torn != nil {
+			return nil, torn
+		}
+
+		var v Intent
+		if err = json.Unmarshal(sc.Bytes(), &v); err != nil {
+			torn = fmt.Errorf("line %d of intent log is invalid: %w", line, err)
+			continue
+		}
+
+		if i, ok := idx[v.ClOrdID]; ok {
+			x[i] = v
+			continue
+		}
+
+		idx[v.ClOrdID] = len(x)
+		x = append(x, v)
+	}
+
+	return x, sc.Err()
+}
+
+func (l *FileIntentLog) Close() error { return l.f.Close() }
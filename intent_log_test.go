@@ -0,0 +1,73 @@
+package tradovate_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+func TestIntentLogTornWrite(mainTest *testing.T) {
+	testCases := []struct {
+		name     string
+		lines    int    // complete lines before the torn one
+		torn     string // what the crash left behind
+		expected []string
+	}{
+		{name: "torn last line", lines: 2, torn: `{"clOrdId":"x","sym`, expected: []string{"a", "b", "c"}},
+		{name: "only a torn line", torn: `{"clOrd`, expected: []string{"c"}},
+		{name: "clean", lines: 2, expected: []string{"a", "b", "c"}},
+		{name: "torn line longer than a read", lines: 1, torn: `{"clOrdId":"` + strings.Repeat("x", 10000), expected: []string{"a", "c"}},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "intents.jsonl")
+
+			log, err := tradovate.OpenIntentLog(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, v := range []string{"a", "b"}[:tc.lines] {
+				if err = log.Record(tradovate.Intent{ClOrdID: v}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			log.Close()
+
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.WriteString(tc.torn)
+			f.Close()
+
+			if log, err = tradovate.OpenIntentLog(path); err != nil {
+				t.Fatal(err)
+			}
+			defer log.Close()
+
+			if err = log.Record(tradovate.Intent{ClOrdID: "c"}); err != nil {
+				t.Fatal(err)
+			}
+
+			intents, err := log.Intents()
+			if err != nil {
+				t.Fatalf("the log should be readable after a torn write: %v", err)
+			}
+
+			var got []string
+			for _, v := range intents {
+				got = append(got, v.ClOrdID)
+			}
+
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("want %v got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net"
+	"time"
 
 	"github.com/coder/websocket"
 )
@@ -13,35 +15,67 @@ var (
 	ErrForceShutdown = errors.New("shutdown frame received")
 )
 
-func (s *WS) closeErr(err error) {
-	s.connCancel()
-	go s.errHandler(err)
+// a single connection. The WS outlives it when it reconnects
+type session struct {
+	conn  *websocket.Conn
+	ended chan error    // why the session ended, first reason wins
+	done  chan struct{} // closed once the read loop returns
+
+	shutdown *ShutdownMsg // set once the server announces one; guarded by WS.mu
+}
+
+func newSession(conn *websocket.Conn) *session {
+	return &session{conn: conn, ended: make(chan error, 1), done: make(chan struct{})}
+}
+
+func (ss *session) end(err error) {
+	select {
+	case ss.ended <- err:
+	default:
+	}
+}
+
+// end the session and close its connection, with a close status taken
+// from err if it has one
+func (s *WS) abort(ss *session, err error) {
+	ss.end(err)
 
 	status := websocket.CloseStatus(err)
 	if status == -1 || status == 0 {
 		status = websocket.StatusInternalError
 	}
 
-	if err = s.ws.Close(status, err.Error()); err != nil {
-		go s.errHandler(err)
+	ss.conn.Close(status, err.Error())
+}
+
+// the WS can't go on: tell the user and stop everything
+func (s *WS) fail(err error) {
+	level := slog.LevelError
+	if websocket.CloseStatus(err) == websocket.StatusNormalClosure {
+		level = slog.LevelInfo
 	}
+
+	s.log.LogAttrs(context.Background(), level, "closing websocket", slog.Any("err", err))
+	s.finish(err)
+	s.connCancel()
+	go s.errHandler(err)
 }
 
-func (s *WS) keepalive(ctx context.Context) {
+// read loop for a single session. It ends when the connection does,
+// leaving the supervisor to decide what happens next
+func (s *WS) keepalive(ss *session) {
+	defer s.wg.Done()
+	defer close(ss.done)
+
+	ctx := s.connCtx
 	for {
-		f, err := s.readFrame(ctx)
+		f, err := s.readFrame(ctx, ss.conn)
 		if err != nil {
-			switch {
-			case errors.Is(err, context.Canceled):
-				s.Close()
-				return
-			case err == net.ErrClosed:
-				s.connCancel()
-				s.errHandler(err)
-				return
+			if err == net.ErrClosed {
+				s.log.LogAttrs(ctx, slog.LevelInfo, "websocket closed")
 			}
 
-			s.closeErr(err)
+			ss.end(err)
 			return
 		}
 
@@ -52,18 +86,27 @@ func (s *WS) keepalive(ctx context.Context) {
 				Reason: "unexpected close",
 			}
 		case frameTypeData:
-			err = s.handleDataframe(f.(dataframe).msgs)
+			err = s.handleDataframe(ss, f.(dataframe).msgs)
 		case frameTypeHeartbeat:
-			go func() { // dont slow down the read routine for ping writes
-				if pingErr := s.ping(ctx); pingErr != nil {
-					s.closeErr(pingErr)
+			s.log.LogAttrs(ctx, slog.LevelDebug, "heartbeat")
+			s.metrics.Heartbeat()
+			s.background(func() { // dont slow down the read routine for ping writes
+				if pingErr := s.ping(ctx, ss.conn); pingErr != nil {
+					s.abort(ss, pingErr)
 				}
-			}()
+			})
 		case frameTypeOpen:
-			var t *Token
-			if t, err = s.rest.Token(ctx); err == nil {
-				err = s.do(ctx, accessTokenURL, nil, t.AccessToken, nil)
-			}
+			s.log.LogAttrs(ctx, slog.LevelInfo, "server reopened the session, reauthorizing")
+			s.metrics.Reconnect()
+			s.setState(StateAuthorizing, StateReady)
+			s.background(func() { // the response comes through this loop
+				if authErr := s.authorize(ctx); authErr != nil {
+					s.abort(ss, authErr)
+					return
+				}
+
+				s.setState(StateReady, StateAuthorizing)
+			})
 		default:
 			err = websocket.CloseError{
 				Code:   websocket.StatusInternalError,
@@ -72,29 +115,65 @@ func (s *WS) keepalive(ctx context.Context) {
 		}
 
 		if err != nil {
-			s.closeErr(err)
+			s.abort(ss, err)
+			return
 		}
 	}
 }
 
-func (s *WS) handleDataframe(msgs []rawMsg) error {
+func (s *WS) authorize(ctx context.Context) error {
+	t, err := s.rest.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, true, "authorize", nil, t.AccessToken, nil)
+}
+
+// ss is nil when replaying
+func (s *WS) handleDataframe(ss *session, msgs []rawMsg) error {
 	for i := range msgs {
+		v := &msgs[i]
+		if v.Event == frameEventUnspecified {
+			s.metrics.Frame("response")
+		} else {
+			s.metrics.Frame(v.Event.String())
+		}
+
 		var err error
-		switch v := &msgs[i]; v.Event {
+		switch v.Event {
 		case frameEventUnspecified: // server response to request
-			s.fm.pub(v)
+			if !s.fm.pub(v) {
+				s.log.LogAttrs(s.connCtx, slog.LevelWarn, "dropped response nobody was waiting for",
+					slog.Int("id", v.ID),
+					slog.Int("status", v.Status),
+				)
+			}
 		case frameEventClock:
 			// unimplemented rn
+			s.log.LogAttrs(s.connCtx, slog.LevelDebug, "dropped clock frame")
 		case frameEventProps: // server event update
-			err = eventHandler(v.entityMsg, s.entityHandler)
+			var e *EntityMsg
+			if e, err = v.entityMsg(); err == nil {
+				s.trace.entity(e)
+				s.clOrds.entity(e)
+				s.dispatch(func() { s.entityHandler(e) })
+			}
 		case frameEventChart:
-			err = eventHandler(v.chart, s.chartHandler)
+			var c *Chart
+			if c, err = v.chart(); err == nil && s.md.chart(c) {
+				s.dispatch(func() { s.chartHandler(c) })
+			}
 		case frameEventMd:
-			err = eventHandler(v.marketData, s.marketDataHandler)
+			err = eventHandler(s, v.marketData, s.marketDataHandler)
 		case frameEventShutdown:
 			var x *ShutdownMsg
 			if x, err = v.shutdownMsg(); err == nil {
-				err = websocket.CloseError{Code: websocket.StatusNormalClosure, Reason: x.Reason}
+				if ss == nil {
+					return x
+				}
+
+				s.shutdown(ss, x)
 			}
 		default:
 			return websocket.CloseError{
@@ -111,27 +190,59 @@ func (s *WS) handleDataframe(msgs []rawMsg) error {
 	return nil
 }
 
-func eventHandler[X any](fn func() (X, error), handler func(X)) error {
+func eventHandler[X any](s *WS, fn func() (X, error), handler func(X)) error {
 	e, err := fn()
 	if err == nil {
-		go handler(e)
+		s.dispatch(func() { handler(e) })
 	}
 	return err
 }
 
-func (s *WS) readFrame(ctx context.Context) (frame, error) {
-	_, binary, err := s.ws.Read(ctx)
+// run a handler as a goroutine, or inline when replaying
+func (s *WS) dispatch(fn func()) {
+	s.metrics.HandlerQueue(1)
+	if s.inline {
+		fn()
+		s.metrics.HandlerQueue(-1)
+		return
+	}
+
+	s.background(func() {
+		defer s.metrics.HandlerQueue(-1)
+		fn()
+	})
+}
+
+// run fn as a goroutine Close waits on
+func (s *WS) background(fn func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn()
+	}()
+}
+
+func (s *WS) readFrame(ctx context.Context, conn *websocket.Conn) (frame, error) {
+	_, binary, err := conn.Read(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	s.lastFrame.Store(time.Now().UnixNano())
+	s.rec.record(DirectionIn, binary)
+	logPayload(ctx, s.log, "ws frame received", binary)
+
 	return newFrame(binary)
 }
 
-func (s *WS) ping(ctx context.Context) error {
+func (s *WS) ping(ctx context.Context, conn *websocket.Conn) error {
 	var i uint8 = 0
 	for ; i < s.pingRetries; i++ {
-		switch err := s.ws.Write(ctx, websocket.MessageText, []byte("[]")); {
+		if i > 0 {
+			s.metrics.PingRetry()
+		}
+
+		switch err := conn.Write(ctx, websocket.MessageText, []byte("[]")); {
 		case err == nil || err == net.ErrClosed:
 			return err
 		case errors.Is(err, context.Canceled):
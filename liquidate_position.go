@@ -0,0 +1,32 @@
+package tradovate
+
+import "context"
+
+const liquidatePositionURL = "order/liquidateposition"
+
+// Close out the account's position in a contract at market, canceling
+// any working orders on it
+func (s *WS) LiquidatePosition(ctx context.Context, accountID uint, contractID int) (orderID uint, err error) {
+	type liquidateReq struct {
+		AccountID  uint `json:"accountId"`
+		ContractID int  `json:"contractId"`
+		Admin      bool `json:"admin"`
+	}
+
+	type liquidateResp struct {
+		Fail OrderErrReason `json:"failureReason"`
+		Text string         `json:"failureText"`
+		ID   uint           `json:"orderId"`
+	}
+
+	var x liquidateResp
+	if err = s.do(ctx, liquidatePositionURL, nil, &liquidateReq{AccountID: accountID, ContractID: contractID}, &x); err != nil {
+		return 0, err
+	}
+
+	if x.Fail != OrderErrReasonSuccess {
+		return 0, &OrderErr{Reason: x.Fail, Text: x.Text}
+	}
+
+	return x.ID, nil
+}
@@ -89,7 +89,7 @@ func (p *Position) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-func (e *EntityMsg) Position() (*Position, error) { return decode[*Position](e) }
+func (e *EntityMsg) Position() (*Position, error) { return decode[Position](e) }
 func (e *EntityMsg) MustPosition() *Position {
 	d, err := e.Position()
 	if err != nil {
@@ -0,0 +1,48 @@
+package tradovate
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Level request and response payloads are logged at, below debug so
+// turning on debug logs doesn't also dump every quote. Credentials and
+// tokens are redacted regardless of level
+const LevelPayload = slog.LevelDebug - 4
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// Log connection lifecycle, requests, dropped frames, token refreshes
+// and shutdowns. Defaults to discarding everything
+func WithLogger(l *slog.Logger) WSOpt {
+	return func(s *WS) {
+		if l != nil {
+			s.log = l
+		}
+	}
+}
+
+// log a finished request at debug, or warn if it failed
+func logRequest(ctx context.Context, l *slog.Logger, err error, msg string, attrs ...slog.Attr) {
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.Any("err", err))
+	}
+
+	l.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// log a payload, if anyone's listening at LevelPayload
+func logPayload(ctx context.Context, l *slog.Logger, msg string, payload []byte, attrs ...slog.Attr) {
+	if !l.Enabled(ctx, LevelPayload) {
+		return
+	}
+
+	l.LogAttrs(ctx, LevelPayload, msg, append(attrs, slog.String("payload", string(payload)))...)
+}
+
+func since(start time.Time) slog.Attr {
+	return slog.Duration("latency", time.Since(start))
+}
@@ -0,0 +1,61 @@
+package tradovate_test
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestLoggingRedacts(t *testing.T) {
+	srv := tradovatetest.NewServer(tradovatetest.WithCreds(&tradovate.Creds{Name: "me", Password: "hunter2"}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var logs syncBuffer
+	l := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: tradovate.LevelPayload}))
+
+	r := srv.NewREST(tradovate.WithRESTLogger(l))
+	ws, err := tradovate.NewSocket(ctx, srv.WSURL(), nil, r, tradovate.WithLogger(l))
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+
+	// logging the creds or token directly is safe too
+	l.Info("creds", "creds", srv.Creds())
+	tok, err := r.Token(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("token", "token", tok)
+
+	if _, err = ws.ListAccounts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ws.Close()
+
+	out := logs.String()
+	for _, secret := range []string{"hunter2", tok.AccessToken} {
+		if strings.Contains(out, secret) {
+			t.Errorf("logs leaked %q:\n%s", secret, out)
+		}
+	}
+
+	for _, want := range []string{
+		"fetched access token",
+		"websocket connected",
+		"msg=\"ws request\" uri=" + srv.WSURL() + " path=authorize",
+		"path=account/list id=2 status=200",
+		"ws frame received",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("logs missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package tradovate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Market data subscriptions belong to a session, so a reconnect loses
+// them. mdRegistry remembers what's active so it can be sent again.
+// Subscribe and unsubscribe the same way (by symbol or by ID), or the
+// unsubscribe won't find what it's undoing
+type mdRegistry struct {
+	mu     sync.Mutex
+	subs   map[mdSub]struct{}
+	charts []*mdChart
+
+	restoring bool
+	pending   []*Chart // chart frames waiting to learn which chart they're for
+}
+
+type mdSub struct {
+	path   string // the subscribe path
+	symbol any    // symbol or contract ID, as it was sent
+}
+
+type mdChart struct {
+	symbol string
+	req    ChartReq
+	orig   ChartResp // the IDs callers know it by
+	cur    ChartResp // the IDs the server uses now
+	lost   bool      // resubscribing failed; retried on the next reconnect
+}
+
+func (m *mdRegistry) add(path string, symbol any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.subs == nil {
+		m.subs = map[mdSub]struct{}{}
+	}
+	m.subs[mdSub{path: path, symbol: symbol}] = struct{}{}
+}
+
+func (m *mdRegistry) remove(path string, symbol any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subs, mdSub{path: path, symbol: symbol})
+}
+
+func (m *mdRegistry) addChart(symbol string, r *ChartReq, resp ChartResp) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.charts = append(m.charts, &mdChart{symbol: symbol, req: *r, orig: resp, cur: resp})
+}
+
+// forget a chart by its original historicalId, returning the one the
+// server knows it by now. False if the server doesn't have it anymore
+func (m *mdRegistry) removeChart(id int) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, v := range m.charts {
+		if v.orig.HistoricalID == id {
+			m.charts = append(m.charts[:i], m.charts[i+1:]...)
+			return v.cur.HistoricalID, !v.lost
+		}
+	}
+	return id, true
+}
+
+// give a chart frame the ID callers know it by. False if it's held until
+// the restore in progress says which chart it belongs to
+func (m *mdRegistry) chart(c *Chart) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.translate(c) || !m.restoring {
+		return true
+	}
+
+	m.pending = append(m.pending, c)
+	return false
+}
+
+func (m *mdRegistry) translate(c *Chart) bool {
+	for _, v := range m.charts {
+		switch {
+		case v.lost:
+		case c.ID == v.cur.HistoricalID:
+			c.ID = v.orig.HistoricalID
+			return true
+		case c.ID == v.cur.RealtimeID:
+			c.ID = v.orig.RealtimeID
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mdRegistry) begin() ([]mdSub, []*mdChart) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.restoring = true
+	subs := make([]mdSub, 0, len(m.subs))
+	for k := range m.subs {
+		subs = append(subs, k)
+	}
+	return subs, append([]*mdChart(nil), m.charts...)
+}
+
+// a chart's new IDs, releasing the frames that were waiting on them
+func (m *mdRegistry) remap(c *mdChart, resp ChartResp, lost bool) []*Chart {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c.cur, c.lost = resp, lost
+	var ready []*Chart
+	kept := m.pending[:0]
+	for _, v := range m.pending {
+		if m.translate(v) {
+			ready = append(ready, v)
+		} else {
+			kept = append(kept, v)
+		}
+	}
+	m.pending = kept
+	return ready
+}
+
+// everything still held belongs to no chart we know of
+func (m *mdRegistry) end() []*Chart {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	x := m.pending
+	m.restoring, m.pending = false, nil
+	return x
+}
+
+// subscribe again to everything that was active before a reconnect.
+// Runs before the socket is ready, so it skips the drain
+func (s *WS) restoreMarketData(ctx context.Context) error {
+	subs, charts := s.md.begin()
+	defer func() { s.deliverCharts(s.md.end()) }()
+
+	var errs []error
+	for _, v := range subs {
+		if err := s.send(ctx, true, v.path, nil, map[string]any{"symbol": v.symbol}, nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s %v: %w", v.path, v.symbol, err))
+		}
+	}
+
+	for _, v := range charts {
+		resp, err := s.requestChart(ctx, true, v.symbol, &v.req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chart %s: %w", v.symbol, err))
+		}
+		s.deliverCharts(s.md.remap(v, resp, err != nil))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *WS) deliverCharts(x []*Chart) {
+	for _, v := range x {
+		s.dispatch(func() { s.chartHandler(v) })
+	}
+}
@@ -0,0 +1,256 @@
+package tradovate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned when the server doesn't answer a websocket
+// request in time
+var ErrTimeout = errors.New("request timed out")
+
+// Metrics receives measurements from REST and WS clients. Implement it
+// to feed whatever metrics library you use, or use PromMetrics, which
+// serves the Prometheus text format itself. Implementations must be
+// safe for concurrent use; one is usually shared by every client
+type Metrics interface {
+	// A websocket request finished, timed from the write to the response
+	Request(path string, latency time.Duration, err error)
+	// Websocket requests waiting on a response went up or down by delta
+	InFlight(delta int)
+	// A message was received on a data frame, by its event, e.g. "md"
+	// or "props"; responses to requests are "response"
+	Frame(event string)
+	Heartbeat()
+	// A ping failed and is being retried
+	PingRetry()
+	// The server reopened the session and the socket reauthorized
+	Reconnect()
+	// A token was fetched or refreshed; kind is "fetch" or "refresh"
+	TokenRefresh(kind string, err error)
+	// Handlers dispatched but not yet returned went up or down by delta
+	HandlerQueue(delta int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Request(string, time.Duration, error) {}
+func (nopMetrics) InFlight(int)                         {}
+func (nopMetrics) Frame(string)                         {}
+func (nopMetrics) Heartbeat()                           {}
+func (nopMetrics) PingRetry()                           {}
+func (nopMetrics) Reconnect()                           {}
+func (nopMetrics) TokenRefresh(string, error)           {}
+func (nopMetrics) HandlerQueue(int)                     {}
+
+// Report socket health to m. Share one Metrics across sockets to
+// aggregate them
+func WithMetrics(m Metrics) WSOpt {
+	return func(s *WS) {
+		if m != nil {
+			s.metrics = m
+		}
+	}
+}
+
+// Report token fetches and refreshes to m
+func WithRESTMetrics(m Metrics) RESTOpt {
+	return func(r *REST) {
+		if m != nil {
+			r.metrics = m
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Request latency buckets, in seconds
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PromMetrics is a Metrics that serves the Prometheus text exposition
+// format, so it can be mounted at /metrics without pulling in a client
+// library:
+//
+//	m := tradovate.NewPromMetrics()
+//	http.Handle("/metrics", m)
+type PromMetrics struct {
+	mu sync.Mutex
+
+	latency        map[string]*histogram // by path
+	timeouts       map[string]uint64     // by path
+	errors         map[string]uint64     // by path
+	frames         map[string]uint64     // by event
+	tokenRefreshes map[[2]string]uint64  // by kind, result
+
+	heartbeats, pingRetries, reconnects uint64
+	inFlight, handlerQueue              int64
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func NewPromMetrics() *PromMetrics {
+	return &PromMetrics{
+		latency:        map[string]*histogram{},
+		timeouts:       map[string]uint64{},
+		errors:         map[string]uint64{},
+		frames:         map[string]uint64{},
+		tokenRefreshes: map[[2]string]uint64{},
+	}
+}
+
+func (m *PromMetrics) Request(path string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.latency[path]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		m.latency[path] = h
+	}
+
+	secs := latency.Seconds()
+	if i, _ := slices.BinarySearch(latencyBuckets, secs); i < len(latencyBuckets) {
+		h.counts[i]++
+	}
+	h.sum += secs
+	h.count++
+
+	switch {
+	case err == nil:
+	case isTimeout(err):
+		m.timeouts[path]++
+	default:
+		m.errors[path]++
+	}
+}
+
+func (m *PromMetrics) InFlight(delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight += int64(delta)
+}
+
+func (m *PromMetrics) Frame(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.frames[event]++
+}
+
+func (m *PromMetrics) Heartbeat() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.heartbeats++
+}
+
+func (m *PromMetrics) PingRetry() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pingRetries++
+}
+
+func (m *PromMetrics) Reconnect() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconnects++
+}
+
+func (m *PromMetrics) TokenRefresh(kind string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokenRefreshes[[2]string{kind, result}]++
+}
+
+func (m *PromMetrics) HandlerQueue(delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlerQueue += int64(delta)
+}
+
+// Serve the metrics in the Prometheus text format
+func (m *PromMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// Write the metrics in the Prometheus text format
+func (m *PromMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	var sb strings.Builder
+
+	header(&sb, "tradovate_request_duration_seconds", "histogram", "Websocket request latency, from write to response")
+	for _, path := range slices.Sorted(maps.Keys(m.latency)) {
+		h, cumulative := m.latency[path], uint64(0)
+		for i, le := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&sb, "tradovate_request_duration_seconds_bucket{path=%q,le=%q} %d\n", path, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&sb, "tradovate_request_duration_seconds_bucket{path=%q,le=\"+Inf\"} %d\n", path, h.count)
+		fmt.Fprintf(&sb, "tradovate_request_duration_seconds_sum{path=%q} %s\n", path, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&sb, "tradovate_request_duration_seconds_count{path=%q} %d\n", path, h.count)
+	}
+
+	header(&sb, "tradovate_request_timeouts_total", "counter", "Websocket requests that timed out")
+	labelled(&sb, "tradovate_request_timeouts_total", "path", m.timeouts)
+
+	header(&sb, "tradovate_request_errors_total", "counter", "Websocket requests that failed other than by timing out")
+	labelled(&sb, "tradovate_request_errors_total", "path", m.errors)
+
+	header(&sb, "tradovate_requests_in_flight", "gauge", "Websocket requests waiting on a response")
+	fmt.Fprintf(&sb, "tradovate_requests_in_flight %d\n", m.inFlight)
+
+	header(&sb, "tradovate_frames_received_total", "counter", "Data frame messages received, by event")
+	labelled(&sb, "tradovate_frames_received_total", "event", m.frames)
+
+	header(&sb, "tradovate_heartbeats_total", "counter", "Heartbeat frames received")
+	fmt.Fprintf(&sb, "tradovate_heartbeats_total %d\n", m.heartbeats)
+
+	header(&sb, "tradovate_ping_retries_total", "counter", "Pings retried after failing")
+	fmt.Fprintf(&sb, "tradovate_ping_retries_total %d\n", m.pingRetries)
+
+	header(&sb, "tradovate_reconnects_total", "counter", "Sessions reopened by the server and reauthorized")
+	fmt.Fprintf(&sb, "tradovate_reconnects_total %d\n", m.reconnects)
+
+	header(&sb, "tradovate_token_refreshes_total", "counter", "Access tokens fetched or refreshed")
+	for _, k := range slices.SortedFunc(maps.Keys(m.tokenRefreshes), func(a, b [2]string) int {
+		return strings.Compare(a[0]+a[1], b[0]+b[1])
+	}) {
+		fmt.Fprintf(&sb, "tradovate_token_refreshes_total{kind=%q,result=%q} %d\n", k[0], k[1], m.tokenRefreshes[k])
+	}
+
+	header(&sb, "tradovate_handler_queue_depth", "gauge", "Handlers dispatched but not yet returned")
+	fmt.Fprintf(&sb, "tradovate_handler_queue_depth %d\n", m.handlerQueue)
+	m.mu.Unlock()
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func header(sb *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func labelled(sb *strings.Builder, name, label string, x map[string]uint64) {
+	for _, k := range slices.Sorted(maps.Keys(x)) {
+		fmt.Fprintf(sb, "%s{%s=%q} %d\n", name, label, k, x[k])
+	}
+}
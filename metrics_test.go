@@ -0,0 +1,87 @@
+package tradovate_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestPromMetricsHistogram(t *testing.T) {
+	m := tradovate.NewPromMetrics()
+	m.Request("order/placeorder", 250*time.Millisecond, nil)
+	m.Request("order/placeorder", 20*time.Second, tradovate.ErrTimeout)
+	m.Request("order/placeorder", 500*time.Millisecond, errors.New("HTTP 400"))
+
+	var sb strings.Builder
+	if _, err := m.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`tradovate_request_duration_seconds_bucket{path="order/placeorder",le="0.1"} 0`,
+		`tradovate_request_duration_seconds_bucket{path="order/placeorder",le="0.25"} 1`,
+		`tradovate_request_duration_seconds_bucket{path="order/placeorder",le="0.5"} 2`,
+		`tradovate_request_duration_seconds_bucket{path="order/placeorder",le="10"} 2`,
+		`tradovate_request_duration_seconds_bucket{path="order/placeorder",le="+Inf"} 3`,
+		`tradovate_request_duration_seconds_sum{path="order/placeorder"} 20.75`,
+		`tradovate_request_duration_seconds_count{path="order/placeorder"} 3`,
+		`tradovate_request_timeouts_total{path="order/placeorder"} 1`,
+		`tradovate_request_errors_total{path="order/placeorder"} 1`,
+	} {
+		if !strings.Contains(sb.String(), want+"\n") {
+			t.Errorf("missing %s in:\n%s", want, sb.String())
+		}
+	}
+}
+
+func TestPromMetricsSocket(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	m := tradovate.NewPromMetrics()
+	quotes := make(chan struct{}, 1)
+	ws, err := tradovate.NewSocket(ctx, srv.WSURL(), nil, srv.NewREST(tradovate.WithRESTMetrics(m)),
+		tradovate.WithMetrics(m),
+		tradovate.WithMarketDataHandler(func(*tradovate.MarketData) { quotes <- struct{}{} }),
+	)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	if _, err = ws.ListAccounts(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.PushQuotes(&tradovate.Quote{ContractID: 1, Entries: tradovate.QuoteEntryBid})
+	select {
+	case <-quotes:
+	case <-ctx.Done():
+		t.Fatal("never got the quote")
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	for _, want := range []string{
+		`tradovate_request_duration_seconds_count{path="authorize"} 1`,
+		`tradovate_request_duration_seconds_count{path="account/list"} 1`,
+		`tradovate_requests_in_flight 0`,
+		`tradovate_frames_received_total{event="md"} 1`,
+		`tradovate_frames_received_total{event="response"} 2`,
+		`tradovate_token_refreshes_total{kind="fetch",result="ok"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want+"\n") {
+			t.Errorf("missing %s in:\n%s", want, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tradovate
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const modifyOrderURL = "order/modifyorder"
+
+// Replaces the parameters of a working order. Qty, type and
+// time in force are required by tradovate even if unchanged
+type ModifyOrderReq struct {
+	OrderID        uint      `json:"orderId"`
+	ClientOrderID  string    `json:"clOrdId,omitzero"` // string <= 64 characters
+	OrderQty       uint32    `json:"orderQty"`
+	OrderType      OrderType `json:"orderType"`
+	Price          float64   `json:"price,omitzero"`
+	StopPrice      float64   `json:"stopPrice,omitzero"`
+	MaxShow        uint32    `json:"maxShow,omitzero"`
+	PegDifference  float64   `json:"pegDifference,omitzero"`
+	TimeInForce    Tif       `json:"timeInForce"`
+	ExpireTime     time.Time `json:"expireTime,omitzero"`
+	ActivationTime time.Time `json:"activationTime,omitzero"`
+	CustomTag50    string    `json:"customTag50,omitzero"`
+	IsAutomated    bool      `json:"isAutomated,omitzero"`
+}
+
+func (s *WS) ModifyOrder(ctx context.Context, r *ModifyOrderReq) (commandID uint, err error) {
+	ctx, sp := s.traceOrder(ctx, "ModifyOrder", r.ClientOrderID,
+		slog.Uint64("order.id", uint64(r.OrderID)),
+		slog.Uint64("qty", uint64(r.OrderQty)),
+		slog.String("order.type", r.OrderType.String()),
+	)
+	sp.ackOn("Replaced", "Rejected")
+	sp.watch(r.OrderID)
+	defer func() { sp.done(err, slog.Uint64("command.id", uint64(commandID))) }()
+
+	type modifyResp struct {
+		Fail OrderErrReason `json:"failureReason"`
+		Text string         `json:"failureText"`
+		Cmd  uint           `json:"commandId"`
+	}
+
+	var x modifyResp
+	if err = s.do(ctx, modifyOrderURL, nil, r, &x); err != nil {
+		return 0, err
+	}
+
+	if x.Fail != OrderErrReasonSuccess {
+		return 0, &OrderErr{Reason: x.Fail, Text: x.Text}
+	}
+
+	return x.Cmd, nil
+}
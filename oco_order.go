@@ -2,6 +2,8 @@ package tradovate
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"time"
 )
 
@@ -13,44 +15,79 @@ const (
 // docs
 type OtherOrder struct {
 	Action        Action    `json:"action"`
-	ClOrdID       string    `json:"clOrdId"`
+	ClOrdID       string    `json:"clOrdId,omitzero"`
 	OrderType     OrderType `json:"orderType"`
-	Price         float64   `json:"price"`
-	StopPrice     float64   `json:"stopPrice"`
-	MaxShow       uint32    `json:"maxShow"`
-	PegDifference float64   `json:"pegDifference"`
-	TimeInForce   Tif       `json:"timeInForce"`
-	ExpireTime    time.Time `json:"expireTime"`
-	Text          string    `json:"text"`
+	Price         float64   `json:"price,omitzero"`
+	StopPrice     float64   `json:"stopPrice,omitzero"`
+	MaxShow       uint32    `json:"maxShow,omitzero"`
+	PegDifference float64   `json:"pegDifference,omitzero"`
+	TimeInForce   Tif       `json:"timeInForce,omitzero"`
+	ExpireTime    time.Time `json:"expireTime,omitzero"`
+	Text          string    `json:"text,omitzero"`
 }
 
 // One-cancels-other order
 type OcoReq struct {
-	AccountSpec    string      `json:"accountSpec"`
-	AccountID      uint        `json:"accountId"`
-	ClientID       string      `json:"clOrdId"`
+	AccountSpec    string      `json:"accountSpec,omitzero"`
+	AccountID      uint        `json:"accountId,omitzero"`
+	ClientID       string      `json:"clOrdId,omitzero"`
 	Action         Action      `json:"action"`
 	Symbol         string      `json:"symbol"`
 	OrderQty       uint        `json:"orderQty"`
 	OrderType      OrderType   `json:"orderType"`
-	Price          float64     `json:"price"`
-	StopPrice      float64     `json:"stopPrice"`
-	MaxShow        uint32      `json:"maxShow"`
-	PegDifference  float64     `json:"pegDifference"`
-	TimeInForce    Tif         `json:"timeInForce"`
-	ExpireTime     time.Time   `json:"expireTime"`
-	Text           string      `json:"text"`
-	ActivationTime time.Time   `json:"activationTime"`
-	CustomTag50    string      `json:"customTag50"`
-	IsAutomated    bool        `json:"isAutomated"`
+	Price          float64     `json:"price,omitzero"`
+	StopPrice      float64     `json:"stopPrice,omitzero"`
+	MaxShow        uint32      `json:"maxShow,omitzero"`
+	PegDifference  float64     `json:"pegDifference,omitzero"`
+	TimeInForce    Tif         `json:"timeInForce,omitzero"`
+	ExpireTime     time.Time   `json:"expireTime,omitzero"`
+	Text           string      `json:"text,omitzero"`
+	ActivationTime time.Time   `json:"activationTime,omitzero"`
+	CustomTag50    string      `json:"customTag50,omitzero"`
+	IsAutomated    bool        `json:"isAutomated,omitzero"`
 	Other          *OtherOrder `json:"other"`
 }
 
+// Check the order makes sense before it's sent. OCO calls this
+func (o *OcoReq) Validate() error {
+	if o.OrderQty == 0 {
+		return fmt.Errorf("%w: no quantity", ErrInvalidOrder)
+	}
+
+	if err := validateLeg("order", o.Action, o.OrderType, o.Price, o.StopPrice, o.TimeInForce, o.ExpireTime); err != nil {
+		return err
+	}
+
+	if o.Other == nil {
+		return fmt.Errorf("%w: no other order", ErrInvalidOrder)
+	}
+
+	return o.Other.validate("other order")
+}
+
 type OcoResp struct {
 	OrderID, OcoID uint
 }
 
-func (s *WS) OCO(ctx context.Context, o *OcoReq) (*OcoResp, error) {
+func (s *WS) OCO(ctx context.Context, o *OcoReq) (resp *OcoResp, err error) {
+	if err = o.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.assignClOrdID(&o.ClientID)
+	s.assignClOrdID(&o.Other.ClOrdID)
+
+	ctx, sp := s.traceOrder(ctx, "OCO", o.ClientID, orderAttrs(o.AccountID, o.Symbol, o.Action, uint32(o.OrderQty), o.OrderType)...)
+	defer func() {
+		var x OcoResp
+		if resp != nil {
+			x = *resp
+		}
+
+		sp.watch(x.OrderID, x.OcoID)
+		sp.done(err, slog.Uint64("order.id", uint64(x.OrderID)), slog.Uint64("oco.id", uint64(x.OcoID)))
+	}()
+
 	type ocoResp struct {
 		FailReason OrderErrReason `json:"failureReason"`
 		FailText   string         `json:"failureText"`
@@ -64,6 +101,8 @@ func (s *WS) OCO(ctx context.Context, o *OcoReq) (*OcoResp, error) {
 	}
 
 	if x.FailReason == OrderErrReasonSuccess {
+		s.clOrds.add(o.ClientID, x.OrderID, true)
+		s.clOrds.add(o.Other.ClOrdID, x.OcoID, true)
 		return &OcoResp{OrderID: x.OrderID, OcoID: x.OcoID}, nil
 	}
 
@@ -79,7 +79,7 @@ type Order struct {
 	Admin               bool        `json:"admin"`
 }
 
-func (e *EntityMsg) Order() (*Order, error) { return decode[*Order](e) }
+func (e *EntityMsg) Order() (*Order, error) { return decode[Order](e) }
 func (e *EntityMsg) MustOrder() *Order {
 	o, err := e.Order()
 	if err != nil {
@@ -0,0 +1,429 @@
+package tradovate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// How the order builder handles prices off the contract's tick grid
+//
+//go:generate enumer -type TickRounding -trimprefix TickRounding -json
+type TickRounding byte
+
+const (
+	TickRoundingReject  TickRounding = iota // fail the build
+	TickRoundingNearest                     // round to the nearest tick
+	TickRoundingPassive                     // round away from the market: limits less aggressive, stops later
+)
+
+// TickLookup finds a contract's tick size by symbol. WS.TickSize is one
+type TickLookup func(ctx context.Context, symbol string) (float64, error)
+
+// OrderBuilder builds OrderReq, OcoReq and OsoReq with prices on the
+// contract's tick grid:
+//
+//	Buy("ESZ5").Qty(2).Limit(5012.25).GTD(t).Bracket(
+//		Leg().LimitTicks(16),  // take profit 16 ticks above the entry
+//		Leg().StopTicks(-8),   // stop 8 ticks below
+//	).Place(ctx, ws)
+//
+// Prices given in ticks are offsets from the Reference price; brackets
+// and OCO legs default to the entry's price. Everything's validated
+// before anything's sent
+type OrderBuilder struct {
+	action   Action
+	symbol   string
+	qty      uint32
+	typ      OrderType
+	price    priceSpec
+	stop     priceSpec
+	ref      float64
+	rounding TickRounding
+
+	tif         Tif
+	expire      time.Time
+	accountID   uint
+	accountSpec string
+	clOrdID     string
+	text        string
+	automated   bool
+
+	other    *OrderBuilder
+	brackets []*OrderBuilder
+}
+
+// an absolute price or an offset in ticks from the reference
+type priceSpec struct {
+	price float64
+	ticks int
+	rel   bool
+}
+
+func Buy(symbol string) *OrderBuilder  { return &OrderBuilder{action: ActionBuy, symbol: symbol} }
+func Sell(symbol string) *OrderBuilder { return &OrderBuilder{action: ActionSell, symbol: symbol} }
+
+// A bracket or OCO leg. The action defaults to exiting the entry for
+// brackets and matching the order for OCO legs
+func Leg() *OrderBuilder { return &OrderBuilder{} }
+
+func (b *OrderBuilder) Qty(n uint32) *OrderBuilder { b.qty = n; return b }
+
+func (b *OrderBuilder) Market() *OrderBuilder { b.typ = OrderTypeMarket; return b }
+
+func (b *OrderBuilder) Limit(price float64) *OrderBuilder {
+	b.typ, b.price = OrderTypeLimit, priceSpec{price: price}
+	return b
+}
+
+func (b *OrderBuilder) Stop(stopPrice float64) *OrderBuilder {
+	b.typ, b.stop = OrderTypeStop, priceSpec{price: stopPrice}
+	return b
+}
+
+func (b *OrderBuilder) StopLimit(stopPrice, price float64) *OrderBuilder {
+	b.typ, b.stop, b.price = OrderTypeStopLimit, priceSpec{price: stopPrice}, priceSpec{price: price}
+	return b
+}
+
+// Limit order n ticks from the reference price; negative is below
+func (b *OrderBuilder) LimitTicks(n int) *OrderBuilder {
+	b.typ, b.price = OrderTypeLimit, priceSpec{ticks: n, rel: true}
+	return b
+}
+
+// Stop order n ticks from the reference price; negative is below
+func (b *OrderBuilder) StopTicks(n int) *OrderBuilder {
+	b.typ, b.stop = OrderTypeStop, priceSpec{ticks: n, rel: true}
+	return b
+}
+
+// Stop limit order with both prices in ticks from the reference price
+func (b *OrderBuilder) StopLimitTicks(stop, limit int) *OrderBuilder {
+	b.typ, b.stop, b.price = OrderTypeStopLimit, priceSpec{ticks: stop, rel: true}, priceSpec{ticks: limit, rel: true}
+	return b
+}
+
+// Price that offsets in ticks are from. Needed for market entries with
+// brackets in ticks, since there's no entry price to go off
+func (b *OrderBuilder) Reference(price float64) *OrderBuilder { b.ref = price; return b }
+
+// How to handle prices off the tick grid. Defaults to rejecting them.
+// Legs always follow the order's
+func (b *OrderBuilder) Round(r TickRounding) *OrderBuilder { b.rounding = r; return b }
+
+func (b *OrderBuilder) Day() *OrderBuilder { b.tif = TifDay; return b }
+func (b *OrderBuilder) GTC() *OrderBuilder { b.tif = TifGTC; return b }
+func (b *OrderBuilder) IOC() *OrderBuilder { b.tif = TifIOC; return b }
+func (b *OrderBuilder) FOK() *OrderBuilder { b.tif = TifFOK; return b }
+
+// Good till date
+func (b *OrderBuilder) GTD(expire time.Time) *OrderBuilder {
+	b.tif, b.expire = TifGTD, expire
+	return b
+}
+
+func (b *OrderBuilder) Account(id uint) *OrderBuilder         { b.accountID = id; return b }
+func (b *OrderBuilder) AccountSpec(name string) *OrderBuilder { b.accountSpec = name; return b }
+func (b *OrderBuilder) ClientOrderID(id string) *OrderBuilder { b.clOrdID = id; return b }
+func (b *OrderBuilder) Text(text string) *OrderBuilder        { b.text = text; return b }
+func (b *OrderBuilder) Automated() *OrderBuilder              { b.automated = true; return b }
+
+// Pair the order with another, one cancelling the other
+func (b *OrderBuilder) OCO(other *OrderBuilder) *OrderBuilder { b.other = other; return b }
+
+// Orders to place once this one fills, at most two
+func (b *OrderBuilder) Bracket(legs ...*OrderBuilder) *OrderBuilder {
+	b.brackets = append(b.brackets, legs...)
+	return b
+}
+
+// Build a single order; OCO legs and brackets aren't allowed
+func (b *OrderBuilder) OrderReq(ctx context.Context, lookup TickLookup) (*OrderReq, error) {
+	if b.other != nil || len(b.brackets) > 0 {
+		return nil, fmt.Errorf("%w: order has legs, build it as an OCO or OSO", ErrInvalidOrder)
+	}
+
+	tick, err := b.tickSize(ctx, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	price, stop, err := b.prices(tick, b.action, b.ref, b.rounding)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &OrderReq{
+		AccountSpec:   b.accountSpec,
+		AccountID:     b.accountID,
+		ClientOrderID: b.clOrdID,
+		Action:        b.action,
+		Symbol:        b.symbol,
+		OrderQty:      b.qty,
+		OrderType:     b.typ,
+		Price:         price,
+		StopPrice:     stop,
+		TimeInForce:   b.tif,
+		ExpireTime:    b.expire,
+		Text:          b.text,
+		IsAutomated:   b.automated,
+	}
+
+	if err = r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Build the order and its OCO leg
+func (b *OrderBuilder) OcoReq(ctx context.Context, lookup TickLookup) (*OcoReq, error) {
+	if b.other == nil || len(b.brackets) > 0 {
+		return nil, fmt.Errorf("%w: an OCO needs exactly one other order and no brackets", ErrInvalidOrder)
+	}
+
+	tick, err := b.tickSize(ctx, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	price, stop, err := b.prices(tick, b.action, b.ref, b.rounding)
+	if err != nil {
+		return nil, err
+	}
+
+	other, err := b.other.leg(tick, b.action, b.legRef(price, stop), b.rounding)
+	if err != nil {
+		return nil, fmt.Errorf("other order: %w", err)
+	}
+
+	r := &OcoReq{
+		AccountSpec: b.accountSpec,
+		AccountID:   b.accountID,
+		ClientID:    b.clOrdID,
+		Action:      b.action,
+		Symbol:      b.symbol,
+		OrderQty:    uint(b.qty),
+		OrderType:   b.typ,
+		Price:       price,
+		StopPrice:   stop,
+		TimeInForce: b.tif,
+		ExpireTime:  b.expire,
+		Text:        b.text,
+		IsAutomated: b.automated,
+		Other:       other,
+	}
+
+	if err = r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Build the entry order and its brackets
+func (b *OrderBuilder) OsoReq(ctx context.Context, lookup TickLookup) (*OsoReq, error) {
+	if b.other != nil || len(b.brackets) == 0 || len(b.brackets) > 2 {
+		return nil, fmt.Errorf("%w: an OSO needs one or two brackets and no OCO leg", ErrInvalidOrder)
+	}
+
+	tick, err := b.tickSize(ctx, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	price, stop, err := b.prices(tick, b.action, b.ref, b.rounding)
+	if err != nil {
+		return nil, err
+	}
+
+	exit := ActionSell
+	if b.action == ActionSell {
+		exit = ActionBuy
+	}
+
+	brackets := make([]*OtherOrder, 2)
+	for i, v := range b.brackets {
+		if brackets[i], err = v.leg(tick, exit, b.legRef(price, stop), b.rounding); err != nil {
+			return nil, fmt.Errorf("bracket%d: %w", i+1, err)
+		}
+	}
+
+	r := &OsoReq{
+		AccountSpec: b.accountSpec,
+		AccountID:   b.accountID,
+		ClientID:    b.clOrdID,
+		Action:      b.action,
+		Symbol:      b.symbol,
+		OrderQty:    uint(b.qty),
+		OrderType:   b.typ,
+		Price:       price,
+		StopPrice:   stop,
+		TimeInForce: b.tif,
+		ExpireTime:  b.expire,
+		Text:        b.text,
+		IsAutomated: b.automated,
+		Bracket1:    brackets[0],
+		Bracket2:    brackets[1],
+	}
+
+	if err = r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Build and place the order, as an OCO or OSO if it has legs. Tick sizes
+// come from the contract's product
+func (b *OrderBuilder) Place(ctx context.Context, s *WS) (orderID uint, err error) {
+	switch {
+	case b.other != nil:
+		r, err := b.OcoReq(ctx, s.TickSize)
+		if err != nil {
+			return 0, err
+		}
+
+		resp, err := s.OCO(ctx, r)
+		if err != nil {
+			return 0, err
+		}
+		return resp.OrderID, nil
+	case len(b.brackets) > 0:
+		r, err := b.OsoReq(ctx, s.TickSize)
+		if err != nil {
+			return 0, err
+		}
+
+		resp, err := s.OSO(ctx, r)
+		if err != nil {
+			return 0, err
+		}
+		return resp.OrderID, nil
+	default:
+		r, err := b.OrderReq(ctx, s.TickSize)
+		if err != nil {
+			return 0, err
+		}
+		return s.PlaceOrder(ctx, r)
+	}
+}
+
+func (b *OrderBuilder) tickSize(ctx context.Context, lookup TickLookup) (float64, error) {
+	if b.symbol == "" {
+		return 0, fmt.Errorf("%w: no symbol", ErrInvalidOrder)
+	}
+
+	tick, err := lookup(ctx, b.symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed getting the tick size of %s: %w", b.symbol, err)
+	}
+
+	if tick <= 0 {
+		return 0, fmt.Errorf("%s has no tick size", b.symbol)
+	}
+
+	return tick, nil
+}
+
+// what legs offset from, unless they set their own reference: the
+// entry's price, or its stop for stops, or its reference for market
+// orders
+func (b *OrderBuilder) legRef(price, stop float64) float64 {
+	switch {
+	case price != 0:
+		return price
+	case stop != 0:
+		return stop
+	default:
+		return b.ref
+	}
+}
+
+// build a leg of an OCO or OSO. action is the default when the leg
+// doesn't set one
+func (b *OrderBuilder) leg(tick float64, action Action, ref float64, r TickRounding) (*OtherOrder, error) {
+	if b.action != ActionUnspecified {
+		action = b.action
+	}
+
+	if b.ref != 0 {
+		ref = b.ref
+	}
+
+	price, stop, err := b.prices(tick, action, ref, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OtherOrder{
+		Action:      action,
+		ClOrdID:     b.clOrdID,
+		OrderType:   b.typ,
+		Price:       price,
+		StopPrice:   stop,
+		TimeInForce: b.tif,
+		ExpireTime:  b.expire,
+		Text:        b.text,
+	}, nil
+}
+
+// the limit and stop prices on the tick grid
+func (b *OrderBuilder) prices(tick float64, action Action, ref float64, r TickRounding) (price, stop float64, err error) {
+	// passive limits round away from the market, passive stops toward
+	// triggering later; for a buy that's down and up respectively
+	limitDown := action == ActionBuy
+
+	if price, err = b.price.resolve("price", tick, ref, r, limitDown); err != nil {
+		return 0, 0, err
+	}
+
+	if stop, err = b.stop.resolve("stop price", tick, ref, r, !limitDown); err != nil {
+		return 0, 0, err
+	}
+
+	return price, stop, nil
+}
+
+func (p priceSpec) resolve(name string, tick, ref float64, r TickRounding, down bool) (float64, error) {
+	if p.rel {
+		if ref == 0 {
+			return 0, fmt.Errorf("%w: %s is %d ticks from a reference price, but there isn't one", ErrInvalidOrder, name, p.ticks)
+		}
+
+		ref, err := priceSpec{price: ref}.resolve("reference price", tick, 0, r, down)
+		if err != nil {
+			return 0, err
+		}
+		return onTick(math.Round(ref/tick)+float64(p.ticks), tick), nil
+	}
+
+	if p.price == 0 {
+		return 0, nil
+	}
+
+	ticks := p.price / tick
+	nearest := math.Round(ticks)
+	if math.Abs(ticks-nearest) < 1e-6 {
+		return onTick(nearest, tick), nil
+	}
+
+	switch r {
+	case TickRoundingNearest:
+		return onTick(nearest, tick), nil
+	case TickRoundingPassive:
+		if down {
+			return onTick(math.Floor(ticks), tick), nil
+		}
+		return onTick(math.Ceil(ticks), tick), nil
+	default:
+		return 0, fmt.Errorf("%w: %s %v isn't a multiple of the %v tick", ErrInvalidOrder, name, p.price, tick)
+	}
+}
+
+func onTick(ticks, tick float64) float64 {
+	// round off the float noise from multiplying back out
+	return math.Round(ticks*tick*1e9) / 1e9
+}
@@ -0,0 +1,189 @@
+package tradovate_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestOrderBuilder(mainTest *testing.T) {
+	expire := time.Date(2025, 3, 10, 21, 0, 0, 0, time.UTC)
+	lookup := func(context.Context, string) (float64, error) { return 0.25, nil }
+
+	type build func(context.Context, tradovate.TickLookup) (any, error)
+	order := func(b *tradovate.OrderBuilder) build {
+		return func(ctx context.Context, l tradovate.TickLookup) (any, error) { return b.OrderReq(ctx, l) }
+	}
+	oco := func(b *tradovate.OrderBuilder) build {
+		return func(ctx context.Context, l tradovate.TickLookup) (any, error) { return b.OcoReq(ctx, l) }
+	}
+	oso := func(b *tradovate.OrderBuilder) build {
+		return func(ctx context.Context, l tradovate.TickLookup) (any, error) { return b.OsoReq(ctx, l) }
+	}
+
+	testCases := []struct {
+		name     string
+		arg      build
+		expected any
+		invalid  bool
+	}{
+		{
+			name: "limit on the grid",
+			arg:  order(tradovate.Buy("ESZ5").Qty(2).Limit(5012.25).GTD(expire).Account(1)),
+			expected: &tradovate.OrderReq{
+				AccountID: 1, Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 2, OrderType: tradovate.OrderTypeLimit,
+				Price: 5012.25, TimeInForce: tradovate.TifGTD, ExpireTime: expire,
+			},
+		},
+		{
+			name:    "off the grid is rejected",
+			arg:     order(tradovate.Buy("ESZ5").Qty(1).Limit(5012.3)),
+			invalid: true,
+		},
+		{
+			name: "rounded to the nearest tick",
+			arg:  order(tradovate.Buy("ESZ5").Qty(1).Limit(5012.3).Round(tradovate.TickRoundingNearest)),
+			expected: &tradovate.OrderReq{
+				Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: 5012.25,
+			},
+		},
+		{
+			name: "passive sell limits round up",
+			arg:  order(tradovate.Sell("ESZ5").Qty(1).Limit(5012.05).Round(tradovate.TickRoundingPassive)),
+			expected: &tradovate.OrderReq{
+				Action: tradovate.ActionSell, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: 5012.25,
+			},
+		},
+		{
+			name: "passive buy stops round up",
+			arg:  order(tradovate.Buy("ESZ5").Qty(1).Stop(5012.05).Round(tradovate.TickRoundingPassive)),
+			expected: &tradovate.OrderReq{
+				Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeStop, StopPrice: 5012.25,
+			},
+		},
+		{
+			name: "brackets in ticks from the entry",
+			arg: oso(tradovate.Buy("ESZ5").Qty(2).Limit(5012.25).Bracket(
+				tradovate.Leg().LimitTicks(16),
+				tradovate.Leg().StopTicks(-8),
+			)),
+			expected: &tradovate.OsoReq{
+				Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 2, OrderType: tradovate.OrderTypeLimit, Price: 5012.25,
+				Bracket1: &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeLimit, Price: 5016.25},
+				Bracket2: &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeStop, StopPrice: 5010.25},
+			},
+		},
+		{
+			name:    "market entry without a reference",
+			arg:     oso(tradovate.Sell("ESZ5").Qty(1).Market().Bracket(tradovate.Leg().StopTicks(8))),
+			invalid: true,
+		},
+		{
+			name: "market entry with a reference",
+			arg:  oso(tradovate.Sell("ESZ5").Qty(1).Market().Reference(5000).Bracket(tradovate.Leg().StopTicks(8))),
+			expected: &tradovate.OsoReq{
+				Action: tradovate.ActionSell, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeMarket,
+				Bracket1: &tradovate.OtherOrder{Action: tradovate.ActionBuy, OrderType: tradovate.OrderTypeStop, StopPrice: 5002},
+			},
+		},
+		{
+			name:    "brackets on the same side",
+			arg:     oso(tradovate.Buy("ESZ5").Qty(1).Limit(5000).Bracket(tradovate.Buy("").LimitTicks(4))),
+			invalid: true,
+		},
+		{
+			name: "oco legs match the order",
+			arg:  oco(tradovate.Sell("ESZ5").Qty(1).Limit(5020).OCO(tradovate.Leg().Stop(4990))),
+			expected: &tradovate.OcoReq{
+				Action: tradovate.ActionSell, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: 5020,
+				Other: &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeStop, StopPrice: 4990},
+			},
+		},
+		{
+			name:    "legs need an OCO or OSO",
+			arg:     order(tradovate.Buy("ESZ5").Qty(1).Market().Bracket(tradovate.Leg().StopTicks(-8))),
+			invalid: true,
+		},
+		{
+			name:    "no quantity",
+			arg:     order(tradovate.Buy("ESZ5").Market()),
+			invalid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			got, err := tc.arg(context.Background(), lookup)
+			if tc.invalid {
+				if !errors.Is(err, tradovate.ErrInvalidOrder) {
+					tt.Errorf("should be invalid, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				tt.Fatalf("failed building: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expected, got) {
+				tt.Errorf("wrong request\nwant %+v\ngot  %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestOrderBuilderPlace(t *testing.T) {
+	srv := tradovatetest.NewServer(
+		tradovatetest.WithHandler("contractMaturity/item", func(*tradovatetest.Request) tradovatetest.Response {
+			return tradovatetest.Response{Status: http.StatusOK, Body: &tradovate.ContractMaturity{ID: 100, ProductID: 10}}
+		}),
+		tradovatetest.WithHandler("product/item", func(*tradovatetest.Request) tradovatetest.Response {
+			return tradovatetest.Response{Status: http.StatusOK, Body: &tradovate.Product{ID: 10, Name: "ES", TickSize: 0.25}}
+		}),
+	)
+	defer srv.Close()
+	srv.SetContracts(&tradovate.Contract{ID: 1, Name: "ESZ5", ContractMaturityID: 100})
+	srv.Respond("order/placeoso", http.StatusOK, map[string]any{"orderId": 7, "osoId1": 8})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	id, err := tradovate.Buy("ESZ5").Qty(1).Limit(5000).Bracket(tradovate.Leg().StopTicks(-8)).Place(ctx, ws)
+	if err != nil || id != 7 {
+		t.Fatalf("should place, got %d %v", id, err)
+	}
+
+	reqs := srv.RequestsFor("order/placeoso")
+	if len(reqs) != 1 {
+		t.Fatalf("wanted one OSO sent, got %d", len(reqs))
+	}
+
+	var sent tradovate.OsoReq
+	if err = reqs[0].Decode(&sent); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent.Bracket1 == nil || sent.Bracket1.StopPrice != 4998 {
+		t.Errorf("stop should be 8 ticks under the entry, got %+v", sent.Bracket1)
+	}
+
+	if _, err = tradovate.Buy("ESZ5").Qty(1).Limit(5000.1).Place(ctx, ws); !errors.Is(err, tradovate.ErrInvalidOrder) {
+		t.Errorf("off grid prices shouldn't be sent, got %v", err)
+	}
+
+	if n := len(srv.RequestsFor("order/placeorder")); n != 0 {
+		t.Errorf("nothing should be sent for an invalid order, got %d", n)
+	}
+}
@@ -0,0 +1,50 @@
+package tradovate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidOrder is returned for orders caught before they're sent
+var ErrInvalidOrder = errors.New("invalid order")
+
+func (o *OtherOrder) validate(name string) error {
+	return validateLeg(name, o.Action, o.OrderType, o.Price, o.StopPrice, o.TimeInForce, o.ExpireTime)
+}
+
+// the checks every order shares: an action and type, the prices its type
+// needs, and an expire time exactly when it's good till date
+func validateLeg(name string, action Action, t OrderType, price, stopPrice float64, tif Tif, expire time.Time) error {
+	switch action {
+	case ActionBuy, ActionSell:
+	default:
+		return fmt.Errorf("%w: %s has no action", ErrInvalidOrder, name)
+	}
+
+	switch t {
+	case OrderTypeUnspecified:
+		return fmt.Errorf("%w: %s has no order type", ErrInvalidOrder, name)
+	case OrderTypeLimit:
+		if price == 0 {
+			return fmt.Errorf("%w: %s is a %s order without a price", ErrInvalidOrder, name, t)
+		}
+	case OrderTypeStop, OrderTypeMIT: // an MIT triggers at its stop price
+		if stopPrice == 0 {
+			return fmt.Errorf("%w: %s is a %s order without a stop price", ErrInvalidOrder, name, t)
+		}
+	case OrderTypeStopLimit:
+		if price == 0 || stopPrice == 0 {
+			return fmt.Errorf("%w: %s is a %s order, it needs a price and a stop price", ErrInvalidOrder, name, t)
+		}
+	}
+
+	switch {
+	case tif == TifGTD && expire.IsZero():
+		return fmt.Errorf("%w: %s is good till date without an expire time", ErrInvalidOrder, name)
+	case tif != TifGTD && !expire.IsZero():
+		return fmt.Errorf("%w: %s has an expire time but is %s, not GTD", ErrInvalidOrder, name, tif)
+	}
+
+	return nil
+}
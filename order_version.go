@@ -0,0 +1,34 @@
+package tradovate
+
+import (
+	"context"
+	"time"
+)
+
+const listOrderVersionsPath = "orderVersion/list"
+
+// The parameters an order was placed or last modified with. Unlike
+// Order, it carries the order's text
+type OrderVersion struct {
+	ID          uint      `json:"id"`
+	OrderID     uint      `json:"orderId"`
+	OrderQty    uint32    `json:"orderQty"`
+	OrderType   OrderType `json:"orderType"`
+	Price       float64   `json:"price"`
+	StopPrice   float64   `json:"stopPrice"`
+	TimeInForce Tif       `json:"timeInForce"`
+	ExpireTime  time.Time `json:"expireTime"`
+	Text        string    `json:"text"`
+	CustomTag50 string    `json:"customTag50"` // when the server sends it
+}
+
+func (e *EntityMsg) OrderVersion() (*OrderVersion, error) { return decode[OrderVersion](e) }
+
+func (s *WS) ListOrderVersions(ctx context.Context) ([]*OrderVersion, error) {
+	var x []*OrderVersion
+	if err := s.do(ctx, listOrderVersionsPath, nil, nil, &x); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
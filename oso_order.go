@@ -2,6 +2,8 @@ package tradovate
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"time"
 )
 
@@ -9,33 +11,95 @@ const (
 	placeOsoOrderPath = "order/placeoso"
 )
 
+// Order-sends-order: an entry order whose brackets are placed once it
+// fills. Bracket2 is optional
 type OsoReq struct {
-	AccountSpec    string      `json:"accountSpec"`
-	AccountID      uint        `json:"accountId"`
-	ClientID       string      `json:"clOrdId"`
+	AccountSpec    string      `json:"accountSpec,omitzero"`
+	AccountID      uint        `json:"accountId,omitzero"`
+	ClientID       string      `json:"clOrdId,omitzero"`
 	Action         Action      `json:"action"`
 	Symbol         string      `json:"symbol"`
 	OrderQty       uint        `json:"orderQty"`
 	OrderType      OrderType   `json:"orderType"`
-	Price          float64     `json:"price"`
-	StopPrice      float64     `json:"stopPrice"`
-	MaxShow        uint32      `json:"maxShow"`
-	PegDifference  float64     `json:"pegDifference"`
-	TimeInForce    Tif         `json:"timeInForce"`
-	ExpireTime     time.Time   `json:"expireTime"`
-	Text           string      `json:"text"`
-	ActivationTime time.Time   `json:"activationTime"`
-	CustomTag50    string      `json:"customTag50"`
-	IsAutomated    bool        `json:"isAutomated"`
+	Price          float64     `json:"price,omitzero"`
+	StopPrice      float64     `json:"stopPrice,omitzero"`
+	MaxShow        uint32      `json:"maxShow,omitzero"`
+	PegDifference  float64     `json:"pegDifference,omitzero"`
+	TimeInForce    Tif         `json:"timeInForce,omitzero"`
+	ExpireTime     time.Time   `json:"expireTime,omitzero"`
+	Text           string      `json:"text,omitzero"`
+	ActivationTime time.Time   `json:"activationTime,omitzero"`
+	CustomTag50    string      `json:"customTag50,omitzero"`
+	IsAutomated    bool        `json:"isAutomated,omitzero"`
 	Bracket1       *OtherOrder `json:"bracket1"`
-	Bracket2       *OtherOrder `json:"bracket2"`
+	Bracket2       *OtherOrder `json:"bracket2,omitzero"`
+}
+
+// Check the order makes sense before it's sent: on top of each order's
+// own fields, brackets have to exit the entry, so their action opposes
+// it. OSO calls this
+func (o *OsoReq) Validate() error {
+	if o.OrderQty == 0 {
+		return fmt.Errorf("%w: no quantity", ErrInvalidOrder)
+	}
+
+	if err := validateLeg("order", o.Action, o.OrderType, o.Price, o.StopPrice, o.TimeInForce, o.ExpireTime); err != nil {
+		return err
+	}
+
+	if o.Bracket1 == nil {
+		return fmt.Errorf("%w: no bracket1", ErrInvalidOrder)
+	}
+
+	for i, v := range []*OtherOrder{o.Bracket1, o.Bracket2} {
+		if v == nil {
+			continue
+		}
+
+		name := fmt.Sprintf("bracket%d", i+1)
+		if err := v.validate(name); err != nil {
+			return err
+		}
+
+		if v.Action == o.Action {
+			return fmt.Errorf("%w: %s is a %s, the same as the order it brackets", ErrInvalidOrder, name, v.Action)
+		}
+	}
+
+	return nil
 }
 
 type OsoResp struct {
 	OrderID, Oso1ID, Oso2ID uint
 }
 
-func (s *WS) OSO(ctx context.Context, o *OsoReq) (*OsoResp, error) {
+func (s *WS) OSO(ctx context.Context, o *OsoReq) (resp *OsoResp, err error) {
+	if err = o.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.assignClOrdID(&o.ClientID)
+	for _, v := range []*OtherOrder{o.Bracket1, o.Bracket2} {
+		if v != nil {
+			s.assignClOrdID(&v.ClOrdID)
+		}
+	}
+
+	ctx, sp := s.traceOrder(ctx, "OSO", o.ClientID, orderAttrs(o.AccountID, o.Symbol, o.Action, uint32(o.OrderQty), o.OrderType)...)
+	defer func() {
+		var x OsoResp
+		if resp != nil {
+			x = *resp
+		}
+
+		sp.watch(x.OrderID, x.Oso1ID, x.Oso2ID)
+		sp.done(err,
+			slog.Uint64("order.id", uint64(x.OrderID)),
+			slog.Uint64("oso1.id", uint64(x.Oso1ID)),
+			slog.Uint64("oso2.id", uint64(x.Oso2ID)),
+		)
+	}()
+
 	type osoResp struct {
 		FailReason OrderErrReason `json:"failureReason"`
 		FailText   string         `json:"failureText"`
@@ -50,8 +114,13 @@ func (s *WS) OSO(ctx context.Context, o *OsoReq) (*OsoResp, error) {
 	}
 
 	if x.FailReason == OrderErrReasonSuccess {
-		return nil, &OrderErr{Reason: x.FailReason, Text: x.FailText}
+		s.clOrds.add(o.ClientID, x.OrderID, true)
+		s.clOrds.add(o.Bracket1.ClOrdID, x.OsoID1, true)
+		if o.Bracket2 != nil {
+			s.clOrds.add(o.Bracket2.ClOrdID, x.OsoID2, true)
+		}
+		return &OsoResp{OrderID: x.OrderID, Oso1ID: x.OsoID1, Oso2ID: x.OsoID2}, nil
 	}
 
-	return &OsoResp{OrderID: x.OrderID, Oso1ID: x.OsoID1, Oso2ID: x.OsoID2}, nil
+	return nil, &OrderErr{Reason: x.FailReason, Text: x.FailText}
 }
@@ -2,6 +2,8 @@ package tradovate
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"time"
 )
 
@@ -27,7 +29,24 @@ type OrderReq struct {
 	IsAutomated    bool      `json:"isAutomated,omitzero"`
 }
 
+// Check the order makes sense before it's sent. PlaceOrder sends what
+// it's given, so call this first if the order wasn't built by OrderBuilder
+func (r *OrderReq) Validate() error {
+	if r.OrderQty == 0 {
+		return fmt.Errorf("%w: no quantity", ErrInvalidOrder)
+	}
+
+	return validateLeg("order", r.Action, r.OrderType, r.Price, r.StopPrice, r.TimeInForce, r.ExpireTime)
+}
+
 func (s *WS) PlaceOrder(ctx context.Context, r *OrderReq) (orderID uint, err error) {
+	s.assignClOrdID(&r.ClientOrderID)
+	ctx, sp := s.traceOrder(ctx, "PlaceOrder", r.ClientOrderID, orderAttrs(r.AccountID, r.Symbol, r.Action, r.OrderQty, r.OrderType)...)
+	defer func() {
+		sp.watch(orderID)
+		sp.done(err, slog.Uint64("order.id", uint64(orderID)))
+	}()
+
 	type orderResp struct {
 		Err  OrderErrReason `json:"failureReason"`
 		Text string         `json:"failureText"`
@@ -40,6 +59,7 @@ func (s *WS) PlaceOrder(ctx context.Context, r *OrderReq) (orderID uint, err err
 	}
 
 	if o.Err == OrderErrReasonSuccess {
+		s.clOrds.add(r.ClientOrderID, o.ID, true)
 		return o.ID, nil
 	}
 
@@ -0,0 +1,469 @@
+package tradovate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SpecLookup finds a contract's spec. WS.ContractSpec is one
+type SpecLookup func(ctx context.Context, contractID int) (ContractSpec, error)
+
+// PositionPnL is the P&L of one account's position in one contract, in
+// the contract's currency
+type PositionPnL struct {
+	AccountID  int
+	ContractID int
+	Currency   string
+	TradeDate  time.Time
+
+	NetPos   int
+	AvgPrice float64 // of the open lots
+	Mark     float64 // last trade; zero until there's been one
+
+	Realized   float64 // today's closed trades, matched FIFO
+	Unrealized float64 // open lots against the mark
+	Open       float64 // net position against the server's net price and the mark
+}
+
+func (p PositionPnL) Total() float64 { return p.Realized + p.Unrealized }
+
+// PnLTotals sums positions in a single currency
+type PnLTotals struct {
+	Realized   float64
+	Unrealized float64
+	Open       float64
+}
+
+func (t PnLTotals) Total() float64 { return t.Realized + t.Unrealized }
+
+// PnL turns positions, fills and quotes into realized, unrealized and
+// open P&L per position, per account and overall. Feed it entity events
+// with Handle and quotes with HandleMarketData; fills are matched FIFO
+// against what's carried in from the prior day, and realized P&L resets
+// when the trade date changes
+type PnL struct {
+	lookup SpecLookup
+
+	mu        sync.Mutex
+	tradeDate time.Time
+	specs     map[int]ContractSpec
+	orders    map[uint]int    // order ID -> account ID
+	pending   map[uint][]Fill // fills for orders not seen yet
+	positions map[posKey]*posState
+	marks     map[int]float64
+
+	subSeq int
+	subs   map[int]func(PositionPnL)
+}
+
+type posKey struct{ accountID, contractID int }
+
+type posState struct {
+	spec      ContractSpec
+	tradeDate time.Time
+	seeded    bool  // carry came from the server's prev position
+	carry     []lot // open at the start of the day, oldest first
+	fills     []Fill
+	seen      map[uint]bool
+
+	netPos   int
+	netPrice float64
+	hasNet   bool // netPos/netPrice are from a Position entity
+
+	open     []lot
+	realized float64 // points times qty
+}
+
+// signed qty at a price
+type lot struct {
+	qty   int
+	price float64
+}
+
+func NewPnL(lookup SpecLookup) *PnL {
+	return &PnL{
+		lookup:    lookup,
+		specs:     map[int]ContractSpec{},
+		orders:    map[uint]int{},
+		pending:   map[uint][]Fill{},
+		positions: map[posKey]*posState{},
+		marks:     map[int]float64{},
+		subs:      map[int]func(PositionPnL){},
+	}
+}
+
+// Apply a Position, Fill or Order entity; anything else is ignored.
+// Fills are tied to accounts through their orders, so fills for orders
+// it hasn't seen wait until the order shows up, or the trade date turns
+func (p *PnL) Handle(ctx context.Context, e *EntityMsg) error {
+	switch e.Type {
+	case EntityTypePosition:
+		x, err := e.Position()
+		if err != nil {
+			return err
+		}
+		return p.UpdatePosition(ctx, x)
+	case EntityTypeOrder:
+		x, err := e.Order()
+		if err != nil {
+			return err
+		}
+		return p.addOrder(ctx, x)
+	case EntityTypeFill:
+		x, err := e.Fill()
+		if err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		acct, ok := p.orders[x.OrderID]
+		if !ok {
+			p.pending[x.OrderID] = append(p.pending[x.OrderID], *x)
+		}
+		p.mu.Unlock()
+
+		if !ok {
+			return nil
+		}
+		return p.AddFill(ctx, acct, x)
+	}
+
+	return nil
+}
+
+// Merge the quotes. Matches the signature of WithMarketDataHandler
+func (p *PnL) HandleMarketData(md *MarketData) {
+	for _, v := range md.Quotes {
+		p.UpdateQuote(v)
+	}
+}
+
+// Mark every position in the quote's contract to its last trade
+func (p *PnL) UpdateQuote(q *Quote) {
+	if !q.Entries.Has(QuoteEntryTrade) {
+		return
+	}
+
+	p.mu.Lock()
+	p.marks[q.ContractID] = q.Trade.Price
+
+	var changed []PositionPnL
+	for k, v := range p.positions {
+		if k.contractID == q.ContractID {
+			changed = append(changed, p.snapshot(k, v))
+		}
+	}
+	p.mu.Unlock()
+
+	p.notify(changed...)
+}
+
+// Apply the server's view of a position. The first one each trade date
+// sets what was carried in from the day before
+func (p *PnL) UpdatePosition(ctx context.Context, x *Position) error {
+	k := posKey{accountID: x.AccountID, contractID: x.ContractID}
+	st, err := p.state(ctx, k, x.TradeDate)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if !st.seeded && st.tradeDate.Equal(x.TradeDate) {
+		st.carry, st.seeded = nil, true
+		if x.PrevPos != 0 {
+			st.carry = []lot{{qty: x.PrevPos, price: x.PrevPrice}}
+		}
+		st.match()
+	}
+
+	st.netPos, st.netPrice, st.hasNet = x.NetPos, x.NetPrice, true
+	snap := p.snapshot(k, st)
+	p.mu.Unlock()
+
+	p.notify(snap)
+	return nil
+}
+
+// Apply a fill for an account. Fills already applied are ignored
+func (p *PnL) AddFill(ctx context.Context, accountID int, f *Fill) error {
+	k := posKey{accountID: accountID, contractID: f.ContractID}
+	st, err := p.state(ctx, k, f.TradeDate)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if st.seen[f.ID] || !f.TradeDate.IsZero() && f.TradeDate.Before(st.tradeDate) {
+		p.mu.Unlock()
+		return nil
+	}
+
+	st.seen[f.ID] = true
+	st.fills = append(st.fills, *f)
+	slices.SortStableFunc(st.fills, func(a, b Fill) int { return a.Timestamp.Compare(b.Timestamp) })
+	st.match()
+	snap := p.snapshot(k, st)
+	p.mu.Unlock()
+
+	p.notify(snap)
+	return nil
+}
+
+// P&L of one account's position in a contract
+func (p *PnL) Position(accountID, contractID int) (PositionPnL, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k := posKey{accountID: accountID, contractID: contractID}
+	st, ok := p.positions[k]
+	if !ok {
+		return PositionPnL{}, false
+	}
+
+	return p.snapshot(k, st), true
+}
+
+// P&L of every position, by account then contract
+func (p *PnL) Positions() []PositionPnL {
+	p.mu.Lock()
+	x := make([]PositionPnL, 0, len(p.positions))
+	for k, v := range p.positions {
+		x = append(x, p.snapshot(k, v))
+	}
+	p.mu.Unlock()
+
+	slices.SortFunc(x, func(a, b PositionPnL) int {
+		if a.AccountID != b.AccountID {
+			return a.AccountID - b.AccountID
+		}
+		return a.ContractID - b.ContractID
+	})
+	return x
+}
+
+// An account's totals, by currency
+func (p *PnL) Account(accountID int) map[string]PnLTotals {
+	return p.totals(func(x PositionPnL) bool { return x.AccountID == accountID })
+}
+
+// Totals across every account, by currency
+func (p *PnL) Totals() map[string]PnLTotals {
+	return p.totals(func(PositionPnL) bool { return true })
+}
+
+// Call fn with a position's P&L whenever a fill, position or quote
+// changes it. fn runs on the goroutine that made the change, so keep it
+// quick. Call the returned func to unsubscribe
+func (p *PnL) OnChange(fn func(PositionPnL)) (unsubscribe func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.subSeq++
+	id := p.subSeq
+	p.subs[id] = fn
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.subs, id)
+	}
+}
+
+func (p *PnL) totals(include func(PositionPnL) bool) map[string]PnLTotals {
+	x := map[string]PnLTotals{}
+	for _, v := range p.Positions() {
+		if !include(v) {
+			continue
+		}
+
+		t := x[v.Currency]
+		t.Realized += v.Realized
+		t.Unrealized += v.Unrealized
+		t.Open += v.Open
+		x[v.Currency] = t
+	}
+
+	return x
+}
+
+func (p *PnL) addOrder(ctx context.Context, o *Order) error {
+	p.mu.Lock()
+	acct := int(o.AccountID)
+	p.orders[o.ID] = acct
+	fills := p.pending[o.ID]
+	delete(p.pending, o.ID)
+	p.mu.Unlock()
+
+	for _, v := range fills {
+		if err := p.AddFill(ctx, acct, &v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// get or create a position's state, moving everything to a new trade
+// date if this is the first sign of one
+func (p *PnL) state(ctx context.Context, k posKey, tradeDate time.Time) (*posState, error) {
+	spec, err := p.spec(ctx, k.contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if tradeDate.After(p.tradeDate) {
+		p.tradeDate = tradeDate
+		for _, v := range p.positions {
+			v.reset(tradeDate)
+		}
+
+		// fills from before the new day whose order never showed
+		for k, v := range p.pending {
+			if v = slices.DeleteFunc(v, func(f Fill) bool { return f.TradeDate.Before(tradeDate) }); len(v) == 0 {
+				delete(p.pending, k)
+			} else {
+				p.pending[k] = v
+			}
+		}
+	}
+
+	st, ok := p.positions[k]
+	if !ok {
+		st = &posState{spec: spec, tradeDate: p.tradeDate, seen: map[uint]bool{}}
+		p.positions[k] = st
+	}
+
+	return st, nil
+}
+
+func (p *PnL) spec(ctx context.Context, contractID int) (ContractSpec, error) {
+	p.mu.Lock()
+	spec, ok := p.specs[contractID]
+	p.mu.Unlock()
+	if ok {
+		return spec, nil
+	}
+
+	spec, err := p.lookup(ctx, contractID)
+	if err != nil {
+		return ContractSpec{}, fmt.Errorf("failed looking up contract %d: %w", contractID, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.specs[contractID] = spec
+	return spec, nil
+}
+
+// p.mu held
+func (p *PnL) snapshot(k posKey, st *posState) PositionPnL {
+	x := PositionPnL{
+		AccountID:  k.accountID,
+		ContractID: k.contractID,
+		Currency:   st.spec.Currency,
+		TradeDate:  st.tradeDate,
+		Realized:   st.realized * st.spec.ValuePerPoint,
+	}
+
+	var cost float64
+	for _, v := range st.open {
+		x.NetPos += v.qty
+		cost += float64(v.qty) * v.price
+	}
+
+	if x.NetPos != 0 {
+		x.AvgPrice = cost / float64(x.NetPos)
+	}
+
+	mark, ok := p.marks[k.contractID]
+	if !ok {
+		return x
+	}
+	x.Mark = mark
+
+	for _, v := range st.open {
+		x.Unrealized += float64(v.qty) * (mark - v.price) * st.spec.ValuePerPoint
+	}
+
+	x.Open = x.Unrealized
+	if st.hasNet {
+		x.Open = float64(st.netPos) * (mark - st.netPrice) * st.spec.ValuePerPoint
+	}
+
+	return x
+}
+
+func (p *PnL) notify(x ...PositionPnL) {
+	if len(x) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	subs := make([]func(PositionPnL), 0, len(p.subs))
+	for _, v := range p.subs {
+		subs = append(subs, v)
+	}
+	p.mu.Unlock()
+
+	for _, v := range x {
+		for _, fn := range subs {
+			fn(v)
+		}
+	}
+}
+
+// start a new trade date, carrying whatever's open until the server
+// says what the carry really is
+func (st *posState) reset(tradeDate time.Time) {
+	st.tradeDate, st.seeded = tradeDate, false
+	st.carry, st.fills, st.realized = st.open, nil, 0
+	st.seen = map[uint]bool{}
+	st.hasNet = false
+}
+
+// rebuild the open lots and realized P&L from the carry and today's fills
+func (st *posState) match() {
+	st.open, st.realized = slices.Clone(st.carry), 0
+
+	for _, f := range st.fills {
+		qty := int(f.Qty)
+		if f.Action == ActionSell {
+			qty = -qty
+		}
+
+		for qty != 0 && len(st.open) > 0 && (st.open[0].qty > 0) != (qty > 0) {
+			oldest := &st.open[0]
+			n := min(abs(qty), abs(oldest.qty))
+			if oldest.qty > 0 {
+				st.realized += float64(n) * (f.Price - oldest.price)
+				oldest.qty -= n
+				qty += n
+			} else {
+				st.realized += float64(n) * (oldest.price - f.Price)
+				oldest.qty += n
+				qty -= n
+			}
+
+			if oldest.qty == 0 {
+				st.open = st.open[1:]
+			}
+		}
+
+		if qty != 0 {
+			st.open = append(st.open, lot{qty: qty, price: f.Price})
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,207 @@
+package tradovate
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPnL(mainTest *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, nyseTimezone)
+	next := day.AddDate(0, 0, 1)
+
+	fill := func(id uint, action Action, qty uint32, price float64, date time.Time) *Fill {
+		return &Fill{ID: id, ContractID: 1, Action: action, Qty: qty, Price: price, TradeDate: date, Timestamp: date.Add(time.Duration(id) * time.Second)}
+	}
+
+	testCases := []struct {
+		name               string
+		position           *Position
+		fills              []*Fill
+		mark               float64
+		expectedNet        int
+		expectedAvg        float64
+		expectedRealized   float64
+		expectedUnrealized float64
+		expectedOpen       float64
+	}{
+		{
+			name: "fifo closes the oldest lot first",
+			fills: []*Fill{
+				fill(1, ActionBuy, 2, 100, day),
+				fill(2, ActionBuy, 1, 102, day),
+				fill(3, ActionSell, 2, 105, day),
+			},
+			mark:               106,
+			expectedNet:        1,
+			expectedAvg:        102,
+			expectedRealized:   200, // 2 x 5pts x $20
+			expectedUnrealized: 80,
+			expectedOpen:       80,
+		},
+		{
+			name: "duplicate fills are ignored",
+			fills: []*Fill{
+				fill(1, ActionBuy, 1, 100, day),
+				fill(1, ActionBuy, 1, 100, day),
+			},
+			mark:               101,
+			expectedNet:        1,
+			expectedAvg:        100,
+			expectedUnrealized: 20,
+			expectedOpen:       20,
+		},
+		{
+			name: "shorts flip long",
+			fills: []*Fill{
+				fill(1, ActionSell, 1, 100, day),
+				fill(2, ActionBuy, 2, 98, day),
+			},
+			mark:               99,
+			expectedNet:        1,
+			expectedAvg:        98,
+			expectedRealized:   40,
+			expectedUnrealized: 20,
+			expectedOpen:       20,
+		},
+		{
+			name:     "carry from the server's previous position",
+			position: &Position{AccountID: 7, ContractID: 1, TradeDate: day, PrevPos: 2, PrevPrice: 90, NetPos: 1, NetPrice: 91},
+			fills: []*Fill{
+				fill(1, ActionSell, 1, 95, day),
+			},
+			mark:               96,
+			expectedNet:        1,
+			expectedAvg:        90,
+			expectedRealized:   100,
+			expectedUnrealized: 120,
+			expectedOpen:       100, // against the server's net price
+		},
+		{
+			name: "realized resets on a new trade date",
+			fills: []*Fill{
+				fill(1, ActionBuy, 1, 100, day),
+				fill(2, ActionSell, 1, 110, day),
+				fill(3, ActionBuy, 1, 100, next),
+			},
+			mark:               100,
+			expectedNet:        1,
+			expectedAvg:        100,
+			expectedRealized:   0,
+			expectedUnrealized: 0,
+		},
+	}
+
+	lookup := func(context.Context, int) (ContractSpec, error) {
+		return ContractSpec{ValuePerPoint: 20, Currency: "USD"}, nil
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			ctx := context.Background()
+			p := NewPnL(lookup)
+
+			var changes int
+			p.OnChange(func(PositionPnL) { changes++ })
+
+			if tc.position != nil {
+				if err := p.UpdatePosition(ctx, tc.position); err != nil {
+					tt.Fatalf("failed updating position: %v", err)
+				}
+			}
+
+			for _, v := range tc.fills {
+				if err := p.AddFill(ctx, 7, v); err != nil {
+					tt.Fatalf("failed adding fill: %v", err)
+				}
+			}
+
+			p.UpdateQuote(&Quote{ContractID: 1, Trade: PriceQty{Price: tc.mark}, Entries: QuoteEntryTrade})
+
+			x, ok := p.Position(7, 1)
+			if !ok {
+				tt.Fatal("position missing")
+			}
+
+			if x.NetPos != tc.expectedNet || x.AvgPrice != tc.expectedAvg {
+				tt.Errorf("wrong position: want %d @ %v, got %d @ %v", tc.expectedNet, tc.expectedAvg, x.NetPos, x.AvgPrice)
+			}
+
+			if x.Realized != tc.expectedRealized || x.Unrealized != tc.expectedUnrealized || x.Open != tc.expectedOpen {
+				tt.Errorf("wrong P&L: want realized %v unrealized %v open %v, got %+v",
+					tc.expectedRealized, tc.expectedUnrealized, tc.expectedOpen, x)
+			}
+
+			totals := p.Totals()["USD"]
+			if totals.Total() != x.Total() || p.Account(7)["USD"] != totals {
+				tt.Errorf("totals should match the only position, got %+v", totals)
+			}
+
+			if changes == 0 {
+				tt.Error("subscribers should hear about changes")
+			}
+		})
+	}
+}
+
+func TestPnLHandleWaitsForOrders(t *testing.T) {
+	ctx := context.Background()
+	p := NewPnL(func(context.Context, int) (ContractSpec, error) {
+		return ContractSpec{ValuePerPoint: 50, Currency: "USD"}, nil
+	})
+
+	entity := func(kind EntityType, v any) *EntityMsg {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &EntityMsg{Type: kind, Event: EventTypeCreated, Data: buf}
+	}
+
+	fill := entity(EntityTypeFill, map[string]any{
+		"id": 1, "orderId": 9, "contractId": 3, "action": "Buy", "qty": 2, "price": 10,
+		"tradeDate": map[string]int{"year": 2025, "month": 3, "day": 10},
+	})
+
+	if err := p.Handle(ctx, fill); err != nil {
+		t.Fatalf("failed handling fill: %v", err)
+	}
+
+	if _, ok := p.Position(4, 3); ok {
+		t.Fatal("fill shouldn't apply before its order's known")
+	}
+
+	if err := p.Handle(ctx, entity(EntityTypeOrder, map[string]any{"id": 9, "accountId": 4})); err != nil {
+		t.Fatalf("failed handling order: %v", err)
+	}
+
+	if x, ok := p.Position(4, 3); !ok || x.NetPos != 2 {
+		t.Errorf("fill should apply once the order shows up, got %+v", x)
+	}
+
+	// a fill whose order never shows is dropped when the day turns
+	fill = entity(EntityTypeFill, map[string]any{
+		"id": 2, "orderId": 50, "contractId": 3, "action": "Buy", "qty": 1, "price": 10,
+		"tradeDate": map[string]int{"year": 2025, "month": 3, "day": 10},
+	})
+	if err := p.Handle(ctx, fill); err != nil {
+		t.Fatalf("failed handling fill: %v", err)
+	}
+
+	position := entity(EntityTypePosition, map[string]any{
+		"id": 1, "accountId": 4, "contractId": 3, "netPos": 2,
+		"tradeDate": map[string]int{"year": 2025, "month": 3, "day": 11},
+	})
+	if err := p.Handle(ctx, position); err != nil {
+		t.Fatalf("failed handling position: %v", err)
+	}
+
+	if err := p.Handle(ctx, entity(EntityTypeOrder, map[string]any{"id": 50, "accountId": 5})); err != nil {
+		t.Fatalf("failed handling order: %v", err)
+	}
+
+	if x, ok := p.Position(5, 3); ok {
+		t.Errorf("the prior day's pending fill should be dropped, got %+v", x)
+	}
+}
@@ -0,0 +1,48 @@
+package tradovate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+const (
+	productFindURL = "product/find"
+	productItemURL = "product/item"
+)
+
+// Product is what contracts are listed under, e.g. NQ for NQH5
+type Product struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"` // root symbol, e.g. NQ
+	Description   string  `json:"description"`
+	ExchangeID    int     `json:"exchangeId"`
+	CurrencyID    int     `json:"currencyId"`
+	Months        string  `json:"months"`        // listed month codes, e.g. HMUZ
+	ValuePerPoint float64 `json:"valuePerPoint"` // dollars per full point of price
+	TickSize      float64 `json:"tickSize"`
+}
+
+// Find a product by its root symbol, e.g. NQ
+func (s *WS) FindProduct(ctx context.Context, name string) (*Product, error) {
+	if name == "" {
+		return nil, fmt.Errorf("no name passed to find product")
+	}
+
+	var p Product
+	if err := s.do(ctx, productFindURL, url.Values{"name": {name}}, nil, &p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// Get a product by its ID
+func (s *WS) GetProduct(ctx context.Context, id int) (*Product, error) {
+	var p Product
+	if err := s.do(ctx, productItemURL, url.Values{"id": {fmt.Sprint(id)}}, nil, &p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
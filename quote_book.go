@@ -0,0 +1,214 @@
+package tradovate
+
+import (
+	"sync"
+	"time"
+)
+
+// QuoteBook merges the partial quotes from md/subscribeQuote into the last
+// known state of every contract. Feed it with WithMarketDataHandler(book.Handle),
+// or call Update yourself.
+//
+// Market data handlers run as goroutines, so updates can arrive out of
+// order; each entry keeps the timestamp it was last set at, and older
+// values never overwrite newer ones
+type QuoteBook struct {
+	mu     sync.RWMutex
+	quotes map[int]*bookEntry
+	subSeq int
+	subs   map[int]quoteSub
+}
+
+type bookEntry struct {
+	quote    Quote
+	at       [9]time.Time // timestamp each entry was last set at, indexed by bit
+	received time.Time
+}
+
+type quoteSub struct {
+	filter QuoteEntry
+	fn     func(Quote, QuoteEntry)
+}
+
+func NewQuoteBook() *QuoteBook {
+	return &QuoteBook{
+		quotes: map[int]*bookEntry{},
+		subs:   map[int]quoteSub{},
+	}
+}
+
+// Merge every quote in the market data. Matches the signature of
+// WithMarketDataHandler
+func (b *QuoteBook) Handle(md *MarketData) {
+	for _, v := range md.Quotes {
+		b.Update(v)
+	}
+}
+
+// Merge a quote into the book, returning the entries that changed.
+// A trade counts as a change even at the same price and size, since
+// it's a new print. Subscribers interested in any of the changed
+// entries are called before Update returns
+func (b *QuoteBook) Update(q *Quote) QuoteEntry {
+	b.mu.Lock()
+
+	e, ok := b.quotes[q.ContractID]
+	if !ok {
+		e = &bookEntry{quote: Quote{ContractID: q.ContractID}}
+		b.quotes[q.ContractID] = e
+	}
+
+	var changed QuoteEntry
+	for i := range len(e.at) {
+		bit := QuoteEntry(1 << i)
+		if !q.Entries.Has(bit) || q.Timestamp.Before(e.at[i]) {
+			continue
+		}
+
+		e.at[i] = q.Timestamp
+		if merge(&e.quote, q, bit) || bit == QuoteEntryTrade {
+			changed |= bit
+		}
+	}
+
+	e.received = time.Now()
+	if q.Timestamp.After(e.quote.Timestamp) {
+		e.quote.Timestamp = q.Timestamp
+	}
+	e.quote.Entries |= q.Entries
+
+	snapshot := e.quote
+	var notify []func(Quote, QuoteEntry)
+	for _, v := range b.subs {
+		if v.filter&changed != 0 {
+			notify = append(notify, v.fn)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, fn := range notify {
+		fn(snapshot, changed)
+	}
+
+	return changed
+}
+
+// copy a single entry from src into dst, reporting if it changed
+func merge(dst, src *Quote, bit QuoteEntry) bool {
+	var changed bool
+	set := func(d *float64, s float64) {
+		changed = *d != s
+		*d = s
+	}
+
+	pq := func(d *PriceQty, s PriceQty) {
+		changed = *d != s
+		*d = s
+	}
+
+	switch bit {
+	case QuoteEntryBid:
+		pq(&dst.Bid, src.Bid)
+	case QuoteEntryOffer:
+		pq(&dst.Offer, src.Offer)
+	case QuoteEntryTrade:
+		pq(&dst.Trade, src.Trade)
+	case QuoteEntryTotalTradeVolume:
+		set(&dst.TotalTradeVolume, src.TotalTradeVolume)
+	case QuoteEntryOpenInterest:
+		set(&dst.OpenInterest, src.OpenInterest)
+	case QuoteEntryOpeningPrice:
+		set(&dst.OpeningPrice, src.OpeningPrice)
+	case QuoteEntryLowPrice:
+		set(&dst.LowPrice, src.LowPrice)
+	case QuoteEntryHighPrice:
+		set(&dst.HighPrice, src.HighPrice)
+	case QuoteEntrySettlementPrice:
+		set(&dst.SettlementPrice, src.SettlementPrice)
+	}
+
+	return changed
+}
+
+// Last known state of a contract. Entries says which fields have been
+// seen at least once
+func (b *QuoteBook) Get(contractID int) (Quote, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	e, ok := b.quotes[contractID]
+	if !ok {
+		return Quote{}, false
+	}
+
+	return e.quote, true
+}
+
+// Drop a contract, e.g. once it's unsubscribed, so its last state isn't
+// taken for a live one
+func (b *QuoteBook) Forget(contractID int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.quotes, contractID)
+}
+
+// Last known state of every contract, keyed by contract ID
+func (b *QuoteBook) Snapshot() map[int]Quote {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	x := make(map[int]Quote, len(b.quotes))
+	for k, v := range b.quotes {
+		x[k] = v.quote
+	}
+
+	return x
+}
+
+// Time since the book last received an update for the contract, by the
+// local clock. False if it's never received one
+func (b *QuoteBook) Age(contractID int) (time.Duration, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	e, ok := b.quotes[contractID]
+	if !ok {
+		return 0, false
+	}
+
+	return time.Since(e.received), true
+}
+
+// Contracts that haven't had an update in longer than maxAge
+func (b *QuoteBook) Stale(maxAge time.Duration) []int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var x []int
+	for k, v := range b.quotes {
+		if time.Since(v.received) > maxAge {
+			x = append(x, k)
+		}
+	}
+
+	return x
+}
+
+// Call fn with the merged quote whenever an update changes any of the
+// entries in filter, e.g. QuoteEntryTop for bid/ask changes or
+// QuoteEntryTrade for trades. fn runs on the goroutine calling Update,
+// so keep it quick. Call the returned func to unsubscribe
+func (b *QuoteBook) OnChange(filter QuoteEntry, fn func(q Quote, changed QuoteEntry)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subSeq++
+	id := b.subSeq
+	b.subs[id] = quoteSub{filter: filter, fn: fn}
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
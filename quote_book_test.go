@@ -0,0 +1,159 @@
+package tradovate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuoteUnmarshalEntries(t *testing.T) {
+	var q Quote
+	err := json.Unmarshal([]byte(`{
+		"timestamp":"2025-03-04T15:00:00Z",
+		"contractId":7,
+		"entries":{
+			"Trade":{"price":5000.25,"size":2},
+			"TotalTradeVolume":{"size":0}
+		}
+	}`), &q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Quote{
+		ContractID: 7,
+		Timestamp:  time.Date(2025, 3, 4, 15, 0, 0, 0, time.UTC),
+		Trade:      PriceQty{Price: 5000.25, Size: 2},
+		Entries:    QuoteEntryTrade | QuoteEntryTotalTradeVolume,
+	}
+
+	if !reflect.DeepEqual(want, q) {
+		t.Errorf("want: %+v\n got: %+v", want, q)
+	}
+}
+
+func TestQuoteBook(mainTest *testing.T) {
+	t0 := time.Date(2025, 3, 4, 15, 0, 0, 0, time.UTC)
+	bid := PriceQty{Price: 5000, Size: 3}
+	offer := PriceQty{Price: 5000.25, Size: 4}
+	trade := PriceQty{Price: 5000.25, Size: 1}
+
+	testCases := []struct {
+		name            string
+		updates         []*Quote
+		expected        Quote
+		expectedChanged QuoteEntry // of the last update
+	}{
+		{
+			name:            "first update sets what it has",
+			updates:         []*Quote{{ContractID: 1, Timestamp: t0, Bid: bid, Entries: QuoteEntryBid}},
+			expected:        Quote{ContractID: 1, Timestamp: t0, Bid: bid, Entries: QuoteEntryBid},
+			expectedChanged: QuoteEntryBid,
+		},
+		{
+			name: "trade-only update keeps the bid and offer",
+			updates: []*Quote{
+				{ContractID: 1, Timestamp: t0, Bid: bid, Offer: offer, Entries: QuoteEntryTop},
+				{ContractID: 1, Timestamp: t0.Add(time.Second), Trade: trade, Entries: QuoteEntryTrade},
+			},
+			expected:        Quote{ContractID: 1, Timestamp: t0.Add(time.Second), Bid: bid, Offer: offer, Trade: trade, Entries: QuoteEntryTop | QuoteEntryTrade},
+			expectedChanged: QuoteEntryTrade,
+		},
+		{
+			name: "present zero entries are applied",
+			updates: []*Quote{
+				{ContractID: 1, Timestamp: t0, Bid: bid, Entries: QuoteEntryBid},
+				{ContractID: 1, Timestamp: t0.Add(time.Second), Entries: QuoteEntryBid},
+			},
+			expected:        Quote{ContractID: 1, Timestamp: t0.Add(time.Second), Entries: QuoteEntryBid},
+			expectedChanged: QuoteEntryBid,
+		},
+		{
+			name: "same bid isn't a change, same trade is",
+			updates: []*Quote{
+				{ContractID: 1, Timestamp: t0, Bid: bid, Trade: trade, Entries: QuoteEntryBid | QuoteEntryTrade},
+				{ContractID: 1, Timestamp: t0.Add(time.Second), Bid: bid, Trade: trade, Entries: QuoteEntryBid | QuoteEntryTrade},
+			},
+			expected:        Quote{ContractID: 1, Timestamp: t0.Add(time.Second), Bid: bid, Trade: trade, Entries: QuoteEntryBid | QuoteEntryTrade},
+			expectedChanged: QuoteEntryTrade,
+		},
+		{
+			name: "late update doesn't overwrite newer entries",
+			updates: []*Quote{
+				{ContractID: 1, Timestamp: t0.Add(time.Second), Bid: bid, Entries: QuoteEntryBid},
+				{ContractID: 1, Timestamp: t0, Bid: PriceQty{Price: 1}, Offer: offer, Entries: QuoteEntryTop},
+			},
+			expected:        Quote{ContractID: 1, Timestamp: t0.Add(time.Second), Bid: bid, Offer: offer, Entries: QuoteEntryTop},
+			expectedChanged: QuoteEntryOffer,
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			b := NewQuoteBook()
+
+			var changed QuoteEntry
+			for _, v := range tc.updates {
+				changed = b.Update(v)
+			}
+
+			if changed != tc.expectedChanged {
+				tt.Errorf("wrong change mask: want %b got %b", tc.expectedChanged, changed)
+			}
+
+			actual, ok := b.Get(1)
+			if !ok || !reflect.DeepEqual(tc.expected, actual) {
+				tt.Errorf("want: %+v\n got: %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestQuoteBookOnChange(t *testing.T) {
+	b := NewQuoteBook()
+
+	var trades, tops int
+	b.OnChange(QuoteEntryTrade, func(Quote, QuoteEntry) { trades++ })
+	unsub := b.OnChange(QuoteEntryTop, func(Quote, QuoteEntry) { tops++ })
+
+	b.Handle(&MarketData{Quotes: []*Quote{
+		{ContractID: 1, Bid: PriceQty{Price: 1}, Entries: QuoteEntryBid},
+		{ContractID: 1, Trade: PriceQty{Price: 1}, Entries: QuoteEntryTrade},
+		{ContractID: 1, Bid: PriceQty{Price: 1}, Entries: QuoteEntryBid}, // no change
+	}})
+
+	unsub()
+	b.Update(&Quote{ContractID: 1, Offer: PriceQty{Price: 2}, Entries: QuoteEntryOffer})
+
+	if trades != 1 || tops != 1 {
+		t.Errorf("wanted 1 trade and 1 top notification, got %d and %d", trades, tops)
+	}
+
+	if age, ok := b.Age(1); !ok || age > time.Second {
+		t.Errorf("age wrong: %s", age)
+	}
+
+	if _, ok := b.Age(2); ok {
+		t.Error("unknown contract shouldn't have an age")
+	}
+
+	if s := b.Snapshot(); len(s) != 1 || s[1].Offer.Price != 2 {
+		t.Errorf("snapshot wrong: %+v", s)
+	}
+}
+
+func TestQuoteBookForget(t *testing.T) {
+	b := NewQuoteBook()
+	b.Update(&Quote{ContractID: 1, Bid: PriceQty{Price: 5000}, Entries: QuoteEntryBid})
+	b.Update(&Quote{ContractID: 2, Bid: PriceQty{Price: 20000}, Entries: QuoteEntryBid})
+
+	b.Forget(1)
+	if q, ok := b.Get(1); ok {
+		t.Errorf("forgotten contracts shouldn't be kept, got %+v", q)
+	}
+
+	if _, ok := b.Get(2); !ok {
+		t.Error("only the forgotten contract should go")
+	}
+}
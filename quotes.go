@@ -12,6 +12,30 @@ const (
 	unsubscribeQuotePath = "md/unsubscribeQuote"
 )
 
+// Bitmask of the entries in a quote. The server only sends the entries
+// that changed, so Quote.Entries says which fields of a quote were actually
+// in the update; the rest are zero because they were absent, not because
+// they're zero
+type QuoteEntry uint16
+
+const (
+	QuoteEntryBid QuoteEntry = 1 << iota
+	QuoteEntryOffer
+	QuoteEntryTrade
+	QuoteEntryTotalTradeVolume
+	QuoteEntryOpenInterest
+	QuoteEntryOpeningPrice
+	QuoteEntryLowPrice
+	QuoteEntryHighPrice
+	QuoteEntrySettlementPrice
+
+	QuoteEntryTop = QuoteEntryBid | QuoteEntryOffer // top of book
+	QuoteEntryAll = QuoteEntrySettlementPrice<<1 - 1
+)
+
+// Check if every entry in x is set
+func (q QuoteEntry) Has(x QuoteEntry) bool { return q&x == x }
+
 type PriceQty struct {
 	Price float64 `json:"price"`
 	Size  float64 `json:"size"`
@@ -32,6 +56,8 @@ type Quote struct {
 	OpeningPrice    float64
 	HighPrice       float64
 	SettlementPrice float64
+
+	Entries QuoteEntry // which of the fields above were in the update
 }
 
 // Implemented unmarshal for this data structure because it's
@@ -46,21 +72,22 @@ func (q *Quote) UnmarshalJSON(b []byte) error {
 		Price float64 `json:"price"`
 	}
 
+	// pointers so absent entries can be told apart from zeroes
 	type bloat struct {
 		Timestamp  time.Time `json:"timestamp"`
 		ContractID int       `json:"contractId"`
 		Entries    struct {
-			Bid   PriceQty `json:"Bid"`
-			Offer PriceQty `json:"Offer"`
-			Trade PriceQty `json:"Trade"`
+			Bid   *PriceQty `json:"Bid"`
+			Offer *PriceQty `json:"Offer"`
+			Trade *PriceQty `json:"Trade"`
 
-			TotalTradeVolume size `json:"TotalTradeVolume"`
-			OpenInterest     size `json:"OpenInterest"`
+			TotalTradeVolume *size `json:"TotalTradeVolume"`
+			OpenInterest     *size `json:"OpenInterest"`
 
-			OpeningPrice    price `json:"OpeningPrice"`
-			LowPrice        price `json:"LowPrice"`
-			HighPrice       price `json:"HighPrice"`
-			SettlementPrice price `json:"SettlementPrice"`
+			OpeningPrice    *price `json:"OpeningPrice"`
+			LowPrice        *price `json:"LowPrice"`
+			HighPrice       *price `json:"HighPrice"`
+			SettlementPrice *price `json:"SettlementPrice"`
 		} `json:"entries"`
 	}
 
@@ -69,19 +96,43 @@ func (q *Quote) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
+	*q = Quote{Timestamp: x.Timestamp, ContractID: x.ContractID}
+
 	e := x.Entries
-	*q = Quote{
-		Timestamp:        x.Timestamp,
-		ContractID:       x.ContractID,
-		Bid:              e.Bid,
-		TotalTradeVolume: e.TotalTradeVolume.Size,
-		Offer:            e.Offer,
-		LowPrice:         e.LowPrice.Price,
-		Trade:            e.Trade,
-		OpenInterest:     e.OpenInterest.Size,
-		OpeningPrice:     e.OpeningPrice.Price,
-		HighPrice:        e.HighPrice.Price,
-		SettlementPrice:  e.SettlementPrice.Price,
+	if e.Bid != nil {
+		q.Bid, q.Entries = *e.Bid, q.Entries|QuoteEntryBid
+	}
+
+	if e.Offer != nil {
+		q.Offer, q.Entries = *e.Offer, q.Entries|QuoteEntryOffer
+	}
+
+	if e.Trade != nil {
+		q.Trade, q.Entries = *e.Trade, q.Entries|QuoteEntryTrade
+	}
+
+	if e.TotalTradeVolume != nil {
+		q.TotalTradeVolume, q.Entries = e.TotalTradeVolume.Size, q.Entries|QuoteEntryTotalTradeVolume
+	}
+
+	if e.OpenInterest != nil {
+		q.OpenInterest, q.Entries = e.OpenInterest.Size, q.Entries|QuoteEntryOpenInterest
+	}
+
+	if e.OpeningPrice != nil {
+		q.OpeningPrice, q.Entries = e.OpeningPrice.Price, q.Entries|QuoteEntryOpeningPrice
+	}
+
+	if e.LowPrice != nil {
+		q.LowPrice, q.Entries = e.LowPrice.Price, q.Entries|QuoteEntryLowPrice
+	}
+
+	if e.HighPrice != nil {
+		q.HighPrice, q.Entries = e.HighPrice.Price, q.Entries|QuoteEntryHighPrice
+	}
+
+	if e.SettlementPrice != nil {
+		q.SettlementPrice, q.Entries = e.SettlementPrice.Price, q.Entries|QuoteEntrySettlementPrice
 	}
 
 	return nil
@@ -120,13 +171,20 @@ func (s *WS) UnsubscribeQuoteID(ctx context.Context, id int) error {
 }
 
 func (s *WS) unsubscribeQuote(ctx context.Context, x any) error {
-	return s.do(ctx, unsubscribeQuotePath, nil, map[string]any{"symbol": x}, nil)
+	if err := s.do(ctx, unsubscribeQuotePath, nil, map[string]any{"symbol": x}, nil); err != nil {
+		return err
+	}
+
+	s.md.remove(subscribeQuotePath, x)
+	return nil
 }
 
 func (s *WS) marketDataSubscribeQuote(ctx context.Context, x any) ([]*Quote, error) {
 	var q []*Quote
-	if err := s.do(ctx, subscribeQuotePath, nil, map[string]any{"symbol": x}, q); err != nil {
+	if err := s.do(ctx, subscribeQuotePath, nil, map[string]any{"symbol": x}, &q); err != nil {
 		return nil, err
 	}
+
+	s.md.add(subscribeQuotePath, x)
 	return q, nil
 }
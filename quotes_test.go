@@ -0,0 +1,48 @@
+package tradovate_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestSubscribeQuote(mainTest *testing.T) {
+	testCases := []struct {
+		name     string
+		resp     any
+		expected int
+	}{
+		{name: "no data"},
+		{name: "initial quotes", resp: json.RawMessage(`[{"contractId":1},{"contractId":2}]`), expected: 2},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			srv := tradovatetest.NewServer()
+			defer srv.Close()
+			srv.Respond("md/subscribeQuote", http.StatusOK, tc.resp)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			ws, err := srv.NewSocket(ctx)
+			if err != nil {
+				tt.Fatalf("failed connecting: %v", err)
+			}
+			defer ws.Close()
+
+			q, err := ws.SubscribeQuoteSymbol(ctx, "ESZ5")
+			if err != nil {
+				tt.Fatalf("failed subscribing: %v", err)
+			}
+
+			if len(q) != tc.expected {
+				tt.Errorf("wanted %d quotes, got %+v", tc.expected, q)
+			}
+		})
+	}
+}
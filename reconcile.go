@@ -0,0 +1,545 @@
+package tradovate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Reconciliation is what a Reconciler found comparing the server's
+// orders, positions and fills to the intent log
+type Reconciliation struct {
+	At time.Time
+
+	Matched []MatchedOrder
+	Orphans []*Order // working orders no intent accounts for
+	Missing []Intent // intents that aren't done but have no order on the server
+	Expired []Intent // missing past WithIntentExpiry, now logged done
+
+	UnexpectedPositions []PositionGap
+	MissingFills        []FillGap
+
+	Canceled []uint // orphans canceled, with WithCancelOrphans
+}
+
+type MatchedOrder struct {
+	Intent Intent
+	Order  *Order
+	By     string // what matched them: orderId, clOrdId, text or customTag50
+}
+
+// A position that isn't what the prior day's position and the fills on
+// the log's orders add up to, so something else traded it
+type PositionGap struct {
+	AccountID  int
+	ContractID int
+	Expected   int
+	Actual     int
+}
+
+// Fills on an intent's order the strategy hasn't accounted for with
+// Reconciler.Filled
+type FillGap struct {
+	Intent Intent
+	Fills  []*Fill
+	Filled uint32 // total on the server
+}
+
+type ReconcileOpt func(r *Reconciler)
+
+// Cancel orphaned orders instead of only reporting them
+func WithCancelOrphans() ReconcileOpt {
+	return func(r *Reconciler) { r.cancelOrphans = true }
+}
+
+// Stop reporting an intent missing once it's older than d, logging it
+// done instead. Defaults to reporting it until it's forgotten
+func WithIntentExpiry(d time.Duration) ReconcileOpt {
+	return func(r *Reconciler) { r.expiry = d }
+}
+
+// Called with every reconciliation Watch runs. Defaults to discarding them
+func WithReconcileHandler(fn func(*Reconciliation, error)) ReconcileOpt {
+	return func(r *Reconciler) { r.handler = fn }
+}
+
+// Reconciler squares a strategy's intent log with what the server says
+// happened, after a restart or a reconnect. Place orders through it so
+// every order is logged before it's sent
+type Reconciler struct {
+	s   *WS
+	log IntentLog
+
+	cancelOrphans bool
+	expiry        time.Duration
+	handler       func(*Reconciliation, error)
+
+	mu sync.Mutex // one reconciliation or fill update at a time
+}
+
+func NewReconciler(s *WS, log IntentLog, opts ...ReconcileOpt) *Reconciler {
+	r := &Reconciler{s: s, log: log, handler: func(*Reconciliation, error) {}}
+	for _, v := range opts {
+		v(r)
+	}
+	return r
+}
+
+// Log the order's intent, then place it. The order gets a clOrdId if it
+// doesn't have one, and its order ID is logged once it's known
+func (r *Reconciler) PlaceOrder(ctx context.Context, o *OrderReq) (uint, error) {
+	if err := r.clOrdID(&o.ClientOrderID); err != nil {
+		return 0, err
+	}
+
+	ids, err := r.place([]Intent{NewIntent(o)}, func() ([]uint, error) {
+		id, err := r.s.PlaceOrder(ctx, o)
+		return []uint{id}, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// Log an intent for both legs of the OCO, then place it. Either way, the
+// legs are the strategy's own orders and never orphans
+func (r *Reconciler) OCO(ctx context.Context, o *OcoReq) (*OcoResp, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, v := range []*string{&o.ClientID, &o.Other.ClOrdID} {
+		if err := r.clOrdID(v); err != nil {
+			return nil, err
+		}
+	}
+
+	x := Intent{
+		ClOrdID:     o.ClientID,
+		Text:        o.Text,
+		CustomTag50: o.CustomTag50,
+		AccountID:   o.AccountID,
+		Symbol:      o.Symbol,
+		Action:      o.Action,
+		Qty:         uint32(o.OrderQty),
+		Created:     time.Now(),
+	}
+
+	var resp *OcoResp
+	_, err := r.place([]Intent{x, legIntent(x, o.Other)}, func() ([]uint, error) {
+		var err error
+		if resp, err = r.s.OCO(ctx, o); err != nil {
+			return nil, err
+		}
+		return []uint{resp.OrderID, resp.OcoID}, nil
+	})
+	return resp, err
+}
+
+// Log an intent for the entry and each bracket, then place the OSO, so
+// the brackets aren't taken for orphans
+func (r *Reconciler) OSO(ctx context.Context, o *OsoReq) (*OsoResp, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := r.clOrdID(&o.ClientID); err != nil {
+		return nil, err
+	}
+
+	x := Intent{
+		ClOrdID:     o.ClientID,
+		Text:        o.Text,
+		CustomTag50: o.CustomTag50,
+		AccountID:   o.AccountID,
+		Symbol:      o.Symbol,
+		Action:      o.Action,
+		Qty:         uint32(o.OrderQty),
+		Created:     time.Now(),
+	}
+
+	intents := []Intent{x}
+	for _, v := range []*OtherOrder{o.Bracket1, o.Bracket2} {
+		if v == nil {
+			continue
+		}
+
+		if err := r.clOrdID(&v.ClOrdID); err != nil {
+			return nil, err
+		}
+		intents = append(intents, legIntent(x, v))
+	}
+
+	var resp *OsoResp
+	_, err := r.place(intents, func() ([]uint, error) {
+		var err error
+		if resp, err = r.s.OSO(ctx, o); err != nil {
+			return nil, err
+		}
+		return []uint{resp.OrderID, resp.Oso1ID, resp.Oso2ID}[:len(intents)], nil
+	})
+	return resp, err
+}
+
+// Mark qty of an order's fills as accounted for, so they aren't reported
+// in MissingFills. qty is the total so far, not what's new since the last
+// call
+func (r *Reconciler) Filled(clOrdID string, qty uint32) error {
+	return r.update(clOrdID, func(x *Intent) { x.FilledQty = qty })
+}
+
+// Log an intent done, e.g. one the strategy knows was never sent, so it
+// isn't reported missing anymore
+func (r *Reconciler) Forget(clOrdID string) error {
+	return r.update(clOrdID, func(x *Intent) { x.Done = true })
+}
+
+func (r *Reconciler) update(clOrdID string, fn func(*Intent)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	intents, err := r.log.Intents()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range intents {
+		if v.ClOrdID == clOrdID {
+			fn(&v)
+			return r.log.Record(v)
+		}
+	}
+	return fmt.Errorf("no intent for clOrdId %s", clOrdID)
+}
+
+func (r *Reconciler) clOrdID(id *string) error {
+	if *id != "" {
+		return nil
+	}
+
+	if r.s.clOrdGen == nil {
+		return fmt.Errorf("order has no clOrdId and the socket isn't generating them")
+	}
+
+	*id = r.s.clOrdGen()
+	return nil
+}
+
+// log the intents, send their orders, then log the order IDs they got,
+// one per intent
+func (r *Reconciler) place(intents []Intent, send func() ([]uint, error)) ([]uint, error) {
+	for _, v := range intents {
+		if err := r.log.Record(v); err != nil {
+			return nil, err
+		}
+	}
+
+	ids, err := send()
+	if err != nil {
+		var orderErr *OrderErr
+		if !errors.As(err, &orderErr) {
+			return nil, err
+		}
+
+		// rejected outright, there'll never be an order
+		errs := []error{err}
+		for _, v := range intents {
+			v.Done = true
+			if err := r.log.Record(v); err != nil {
+				errs = append(errs, fmt.Errorf("failed logging rejected intent %s: %w", v.ClOrdID, err))
+			}
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	var errs []error
+	for i, v := range intents {
+		v.OrderID = ids[i]
+		if err := r.log.Record(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return ids, errors.Join(errs...)
+}
+
+// a bracket or OCO leg trades the parent's contract and quantity
+func legIntent(parent Intent, leg *OtherOrder) Intent {
+	x := parent
+	x.ClOrdID, x.Text, x.CustomTag50, x.Action = leg.ClOrdID, leg.Text, "", leg.Action
+	return x
+}
+
+// Reconcile after every reconnect, passing the results to the handler
+// set with WithReconcileHandler. Call the returned func to stop
+func (r *Reconciler) Watch() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		stopped bool
+	)
+
+	unsubscribe := r.s.OnStateChange(func(_, to State) {
+		if to != StateReady {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.handler(r.Run(ctx))
+		}()
+	})
+
+	return func() {
+		unsubscribe()
+
+		mu.Lock()
+		stopped = true
+		mu.Unlock()
+
+		cancel()
+		wg.Wait()
+	}
+}
+
+// Reconcile now
+func (r *Reconciler) Run(ctx context.Context) (*Reconciliation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	intents, err := r.log.Intents()
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := r.s.ListOrders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing orders: %w", err)
+	}
+
+	positions, err := r.s.ListPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing positions: %w", err)
+	}
+
+	fills, err := r.s.ListFills(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing fills: %w", err)
+	}
+
+	// commands tie clOrdIds to orders
+	if _, err = r.s.ListCommands(ctx); err != nil {
+		return nil, fmt.Errorf("failed listing commands: %w", err)
+	}
+
+	m := newMatcher(orders)
+	if err = m.versions(ctx, r.s, intents); err != nil {
+		return nil, err
+	}
+
+	x := &Reconciliation{At: time.Now()}
+	for _, v := range intents {
+		o, by := m.match(r.s, &v)
+		switch {
+		case o != nil:
+		case v.Done:
+			continue
+		case r.expiry > 0 && x.At.Sub(v.Created) > r.expiry:
+			v.Done = true
+			if err = r.log.Record(v); err != nil {
+				return nil, err
+			}
+			x.Expired = append(x.Expired, v)
+			continue
+		default:
+			x.Missing = append(x.Missing, v)
+			continue
+		}
+
+		x.Matched = append(x.Matched, MatchedOrder{Intent: v, Order: o, By: by})
+
+		if done := finalStatus(o.Status); v.OrderID != o.ID || done != v.Done {
+			v.OrderID, v.Done = o.ID, done
+			if err = r.log.Record(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, v := range orders {
+		if !m.used[v.ID] && !finalStatus(v.Status) {
+			x.Orphans = append(x.Orphans, v)
+		}
+	}
+
+	x.MissingFills, x.UnexpectedPositions = gaps(x.Matched, positions, fills)
+
+	if r.cancelOrphans {
+		var errs []error
+		for _, v := range x.Orphans {
+			if _, err = r.s.CancelOrder(ctx, v.ID); err != nil {
+				errs = append(errs, fmt.Errorf("failed canceling orphan %d: %w", v.ID, err))
+				continue
+			}
+			x.Canceled = append(x.Canceled, v.ID)
+		}
+
+		if err = errors.Join(errs...); err != nil {
+			return x, err
+		}
+	}
+
+	return x, nil
+}
+
+type matcher struct {
+	byID   map[uint]*Order
+	byText map[string]uint
+	byTag  map[string]uint
+	used   map[uint]bool
+}
+
+func newMatcher(orders []*Order) *matcher {
+	m := &matcher{byID: map[uint]*Order{}, used: map[uint]bool{}}
+	for _, v := range orders {
+		m.byID[v.ID] = v
+	}
+	return m
+}
+
+// order versions carry the text and tag, only fetched if there's an
+// intent that can't be matched without them
+func (m *matcher) versions(ctx context.Context, s *WS, intents []Intent) error {
+	need := false
+	for _, v := range intents {
+		if v.OrderID == 0 && (v.Text != "" || v.CustomTag50 != "") {
+			if _, ok := s.OrderIDFor(v.ClOrdID); !ok {
+				need = true
+				break
+			}
+		}
+	}
+
+	if !need {
+		return nil
+	}
+
+	versions, err := s.ListOrderVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed listing order versions: %w", err)
+	}
+
+	m.byText, m.byTag = map[string]uint{}, map[string]uint{}
+	for _, v := range versions {
+		if v.Text != "" {
+			m.byText[v.Text] = v.OrderID
+		}
+
+		if v.CustomTag50 != "" {
+			m.byTag[v.CustomTag50] = v.OrderID
+		}
+	}
+	return nil
+}
+
+func (m *matcher) match(s *WS, x *Intent) (*Order, string) {
+	try := func(id uint, by string) (*Order, string) {
+		o, ok := m.byID[id]
+		if !ok || m.used[id] {
+			return nil, ""
+		}
+
+		m.used[id] = true
+		return o, by
+	}
+
+	if x.OrderID != 0 {
+		if o, by := try(x.OrderID, "orderId"); o != nil {
+			return o, by
+		}
+	}
+
+	if id, ok := s.OrderIDFor(x.ClOrdID); ok {
+		if o, by := try(id, "clOrdId"); o != nil {
+			return o, by
+		}
+	}
+
+	if id, ok := m.byText[x.Text]; ok && x.Text != "" {
+		if o, by := try(id, "text"); o != nil {
+			return o, by
+		}
+	}
+
+	if id, ok := m.byTag[x.CustomTag50]; ok && x.CustomTag50 != "" {
+		return try(id, "customTag50")
+	}
+
+	return nil, ""
+}
+
+// fills the log hasn't accounted for, and positions the matched orders'
+// fills don't explain
+func gaps(matched []MatchedOrder, positions []*Position, fills []*Fill) ([]FillGap, []PositionGap) {
+	byOrder := map[uint][]*Fill{}
+	for _, v := range fills {
+		byOrder[v.OrderID] = append(byOrder[v.OrderID], v)
+	}
+
+	type key struct{ account, contract int }
+	expected := map[key]int{}
+
+	var fillGaps []FillGap
+	for _, v := range matched {
+		var total uint32
+		for _, f := range byOrder[v.Order.ID] {
+			total += f.Qty
+
+			qty := int(f.Qty)
+			if f.Action == ActionSell {
+				qty = -qty
+			}
+			expected[key{int(v.Order.AccountID), f.ContractID}] += qty
+		}
+
+		if total > v.Intent.FilledQty {
+			fillGaps = append(fillGaps, FillGap{Intent: v.Intent, Fills: byOrder[v.Order.ID], Filled: total})
+		}
+	}
+
+	var posGaps []PositionGap
+	for _, v := range positions {
+		k := key{v.AccountID, v.ContractID}
+		if want := v.PrevPos + expected[k]; v.NetPos != want {
+			posGaps = append(posGaps, PositionGap{AccountID: v.AccountID, ContractID: v.ContractID, Expected: want, Actual: v.NetPos})
+		}
+		delete(expected, k)
+	}
+
+	// fills on our orders with no position to show for them
+	for k, v := range expected {
+		if v != 0 {
+			posGaps = append(posGaps, PositionGap{AccountID: k.account, ContractID: k.contract, Expected: v})
+		}
+	}
+
+	slices.SortFunc(posGaps, func(a, b PositionGap) int {
+		if a.AccountID != b.AccountID {
+			return a.AccountID - b.AccountID
+		}
+		return a.ContractID - b.ContractID
+	})
+	return fillGaps, posGaps
+}
@@ -0,0 +1,333 @@
+package tradovate_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestReconcile(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	srv.SetOrders(
+		&tradovate.Order{ID: 1, AccountID: 1, Status: tradovate.OrderStatusWorking},
+		&tradovate.Order{ID: 2, AccountID: 1, Status: tradovate.OrderStatusFilled},
+		&tradovate.Order{ID: 3, AccountID: 1, Status: tradovate.OrderStatusWorking},
+		&tradovate.Order{ID: 4, AccountID: 1, Status: tradovate.OrderStatusWorking},
+		&tradovate.Order{ID: 5, AccountID: 1, Status: tradovate.OrderStatusCanceled},
+	)
+	srv.SetCommands(&tradovate.Command{ID: 1, OrderID: 2, ClOrdID: "b", CommandType: "New"})
+	srv.SetOrderVersions(&tradovate.OrderVersion{ID: 1, OrderID: 3, Text: "tag-c"})
+	srv.SetFills(&tradovate.Fill{ID: 1, OrderID: 2, ContractID: 5, Action: tradovate.ActionBuy, Qty: 2, Price: 100})
+	srv.SetPositions(
+		&tradovate.Position{ID: 1, AccountID: 1, ContractID: 5, NetPos: 2},
+		&tradovate.Position{ID: 2, AccountID: 1, ContractID: 6, NetPos: 1},
+	)
+	srv.Respond("order/cancelorder", http.StatusOK, map[string]any{"commandId": 9})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	log, err := tradovate.OpenIntentLog(filepath.Join(t.TempDir(), "intents.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	for _, v := range []tradovate.Intent{
+		{ClOrdID: "a", OrderID: 1, Qty: 1},
+		{ClOrdID: "b", Qty: 2},
+		{ClOrdID: "c", Text: "tag-c", Qty: 1},
+		{ClOrdID: "d", Qty: 1},
+	} {
+		if err = log.Record(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := tradovate.NewReconciler(ws, log, tradovate.WithCancelOrphans())
+	x, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("failed reconciling: %v", err)
+	}
+
+	var matched []string
+	for _, v := range x.Matched {
+		matched = append(matched, v.Intent.ClOrdID+":"+v.By)
+	}
+
+	if want := []string{"a:orderId", "b:clOrdId", "c:text"}; !reflect.DeepEqual(want, matched) {
+		t.Errorf("wrong matches: want %v got %v", want, matched)
+	}
+
+	if len(x.Orphans) != 1 || x.Orphans[0].ID != 4 || !reflect.DeepEqual(x.Canceled, []uint{4}) {
+		t.Errorf("order 4 should be an orphan and canceled, got %+v %v", x.Orphans, x.Canceled)
+	}
+
+	if len(x.Missing) != 1 || x.Missing[0].ClOrdID != "d" {
+		t.Errorf("d should be missing, got %+v", x.Missing)
+	}
+
+	if len(x.MissingFills) != 1 || x.MissingFills[0].Intent.ClOrdID != "b" || x.MissingFills[0].Filled != 2 {
+		t.Errorf("b's fill should be missing from the log, got %+v", x.MissingFills)
+	}
+
+	if want := []tradovate.PositionGap{{AccountID: 1, ContractID: 6, Expected: 0, Actual: 1}}; !reflect.DeepEqual(want, x.UnexpectedPositions) {
+		t.Errorf("wrong position gaps: want %+v got %+v", want, x.UnexpectedPositions)
+	}
+
+	intents, err := log.Intents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b := intents[1]; b.OrderID != 2 || !b.Done {
+		t.Errorf("b's order should be logged as done, got %+v", b)
+	}
+
+	// once the strategy accounts for b's fill it's not missing anymore
+	if err = r.Filled("b", 2); err != nil {
+		t.Fatalf("failed marking b filled: %v", err)
+	}
+
+	if x, err = r.Run(ctx); err != nil {
+		t.Fatalf("failed reconciling again: %v", err)
+	}
+
+	if len(x.MissingFills) != 0 {
+		t.Errorf("b's fill is accounted for, got %+v", x.MissingFills)
+	}
+
+	if intents, err = log.Intents(); err != nil || intents[1].FilledQty != 2 {
+		t.Errorf("b's filled qty should be logged, got %+v (err %v)", intents, err)
+	}
+
+	if err = r.Filled("nope", 1); err == nil {
+		t.Error("marking an unknown clOrdId filled should fail")
+	}
+
+	// d was never sent; forgetting it stops it being reported
+	if err = r.Forget("d"); err != nil {
+		t.Fatalf("failed forgetting d: %v", err)
+	}
+
+	if x, err = r.Run(ctx); err != nil {
+		t.Fatalf("failed reconciling again: %v", err)
+	}
+
+	if len(x.Missing) != 0 {
+		t.Errorf("d was forgotten, got %+v", x.Missing)
+	}
+}
+
+func TestReconcilerExpiry(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	log, err := tradovate.OpenIntentLog(filepath.Join(t.TempDir(), "intents.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	for _, v := range []tradovate.Intent{
+		{ClOrdID: "old", Qty: 1, Created: time.Now().Add(-time.Hour)},
+		{ClOrdID: "new", Qty: 1, Created: time.Now()},
+	} {
+		if err = log.Record(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := tradovate.NewReconciler(ws, log, tradovate.WithIntentExpiry(time.Minute))
+	x, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("failed reconciling: %v", err)
+	}
+
+	if len(x.Expired) != 1 || x.Expired[0].ClOrdID != "old" {
+		t.Errorf("old should expire, got %+v", x.Expired)
+	}
+
+	if len(x.Missing) != 1 || x.Missing[0].ClOrdID != "new" {
+		t.Errorf("new should still be missing, got %+v", x.Missing)
+	}
+
+	if x, err = r.Run(ctx); err != nil {
+		t.Fatalf("failed reconciling again: %v", err)
+	}
+
+	if len(x.Expired) != 0 || len(x.Missing) != 1 {
+		t.Errorf("old is logged done and shouldn't come back, got expired %+v missing %+v", x.Expired, x.Missing)
+	}
+}
+
+func TestReconcilerPlaceOrder(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+	srv.Respond("order/placeorder", http.StatusOK, map[string]any{"orderId": 12})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx, tradovate.WithClOrdIDs(tradovate.NewClOrdIDGen("test")))
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	log, err := tradovate.OpenIntentLog(filepath.Join(t.TempDir(), "intents.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	req := &tradovate.OrderReq{Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeMarket}
+	if _, err = tradovate.NewReconciler(ws, log).PlaceOrder(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	intents, err := log.Intents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(intents) != 1 || intents[0].ClOrdID != req.ClientOrderID || intents[0].OrderID != 12 {
+		t.Errorf("intent should be logged with its order ID, got %+v", intents)
+	}
+}
+
+func TestReconcilerBrackets(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+	srv.Respond("order/placeoso", http.StatusOK, map[string]any{"orderId": 20, "osoId1": 21, "osoId2": 22})
+	srv.Respond("order/placeoco", http.StatusOK, map[string]any{"orderId": 30, "ocoId": 31})
+	srv.SetOrders(
+		&tradovate.Order{ID: 20, AccountID: 1, Status: tradovate.OrderStatusWorking},
+		&tradovate.Order{ID: 21, AccountID: 1, Status: tradovate.OrderStatusSuspended},
+		&tradovate.Order{ID: 22, AccountID: 1, Status: tradovate.OrderStatusSuspended},
+		&tradovate.Order{ID: 30, AccountID: 1, Status: tradovate.OrderStatusWorking},
+		&tradovate.Order{ID: 31, AccountID: 1, Status: tradovate.OrderStatusWorking},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx, tradovate.WithClOrdIDs(tradovate.NewClOrdIDGen("test")))
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	log, err := tradovate.OpenIntentLog(filepath.Join(t.TempDir(), "intents.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	r := tradovate.NewReconciler(ws, log, tradovate.WithCancelOrphans())
+	_, err = r.OSO(ctx, &tradovate.OsoReq{
+		Action: tradovate.ActionBuy, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: 5000,
+		Bracket1: &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeLimit, Price: 5010},
+		Bracket2: &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeStop, StopPrice: 4990},
+	})
+	if err != nil {
+		t.Fatalf("failed placing oso: %v", err)
+	}
+
+	_, err = r.OCO(ctx, &tradovate.OcoReq{
+		Action: tradovate.ActionSell, Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: 5020,
+		Other: &tradovate.OtherOrder{Action: tradovate.ActionSell, OrderType: tradovate.OrderTypeStop, StopPrice: 4980},
+	})
+	if err != nil {
+		t.Fatalf("failed placing oco: %v", err)
+	}
+
+	intents, err := log.Intents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []uint
+	for _, v := range intents {
+		ids = append(ids, v.OrderID)
+	}
+
+	if want := []uint{20, 21, 22, 30, 31}; !reflect.DeepEqual(want, ids) {
+		t.Errorf("every leg should be logged with its order ID: want %v got %v", want, ids)
+	}
+
+	x, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("failed reconciling: %v", err)
+	}
+
+	if len(x.Orphans) != 0 || len(x.Canceled) != 0 || len(srv.RequestsFor("order/cancelorder")) != 0 {
+		t.Errorf("the strategy's own brackets aren't orphans, got %+v canceled %v", x.Orphans, x.Canceled)
+	}
+}
+
+func TestReconcilerWatch(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+	srv.SetOrders(&tradovate.Order{ID: 4, AccountID: 1, Status: tradovate.OrderStatusWorking})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx, tradovate.WithMaintenanceDelay(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	log, err := tradovate.OpenIntentLog(filepath.Join(t.TempDir(), "intents.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	results := make(chan *tradovate.Reconciliation, 1)
+	r := tradovate.NewReconciler(ws, log, tradovate.WithReconcileHandler(func(x *tradovate.Reconciliation, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		results <- x
+	}))
+
+	stop := r.Watch()
+	defer stop()
+
+	srv.PushShutdown(tradovate.ShutdownCodeMaintenance, "going down")
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("never reconciled after reconnecting")
+	case x := <-results:
+		if len(x.Orphans) != 1 || x.Orphans[0].ID != 4 {
+			t.Errorf("order 4 should be an orphan, got %+v", x.Orphans)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package tradovate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// Upper bounds on the backoff between reconnect attempts
+const (
+	maxMaintenanceDelay = 5 * time.Minute
+	maxQuotaBackoff     = time.Hour
+)
+
+// dial, handshake and authorize a new session, making it current
+func (s *WS) connect(ctx context.Context) (ss *session, err error) {
+	s.setState(StateConnecting)
+	t, err := s.rest.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting initial token: %w", err)
+	}
+
+	conn, _, err := websocket.Dial(ctx, s.uri, s.dialOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			conn.Close(websocket.StatusInternalError, "failed initial setup: "+err.Error())
+		}
+	}()
+
+	f, err := s.readFrame(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading opening handshake packet with %s: %w", s.uri, err)
+	}
+
+	if f.frameType() != frameTypeOpen {
+		return nil, fmt.Errorf("protocol broken: frame type should be open, but got %+v", f)
+	}
+
+	ss = newSession(conn)
+	s.mu.Lock()
+	s.sess = ss
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.keepalive(ss)
+
+	if s.silence > 0 {
+		s.wg.Add(1)
+		go s.watchdog(ss)
+	}
+
+	s.setState(StateAuthorizing)
+	if err = s.send(ctx, true, "authorize", nil, t.AccessToken, nil); err != nil {
+		s.log.LogAttrs(ctx, slog.LevelError, "websocket authorization failed", slog.Any("err", err))
+		return nil, err
+	}
+
+	return ss, nil
+}
+
+// watch each session end and decide what's next: nothing if the user
+// closed the socket, a reconnect if the server announced a shutdown,
+// and failing the socket for anything else
+func (s *WS) supervise(ss *session) {
+	defer s.wg.Done()
+
+	for {
+		var err error
+		select {
+		case <-s.connCtx.Done():
+			s.finish(context.Cause(s.connCtx))
+			return
+		case err = <-ss.ended:
+		}
+
+		if s.closing.Load() {
+			s.finish(ErrClosed)
+			return
+		}
+
+		s.mu.RLock()
+		msg := ss.shutdown
+		s.mu.RUnlock()
+
+		if msg == nil {
+			s.fail(err)
+			return
+		}
+
+		if ss = s.reconnect(msg); ss == nil {
+			s.finish(context.Cause(s.connCtx))
+			return
+		}
+	}
+}
+
+// reconnect after a shutdown, backing off until it works or the socket
+// is closed. Nil if it was closed first
+func (s *WS) reconnect(msg *ShutdownMsg) *session {
+	delay, limit := s.maintenanceDelay, maxMaintenanceDelay
+	if msg.quota() {
+		delay, limit = s.quotaBackoff, maxQuotaBackoff
+	}
+
+	for {
+		s.setState(StateReconnecting)
+		s.log.LogAttrs(s.connCtx, slog.LevelInfo, "reconnecting",
+			slog.String("code", msg.Code.String()),
+			slog.Duration("delay", delay),
+		)
+
+		t := time.NewTimer(delay)
+		select {
+		case <-s.connCtx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+
+		ss, err := s.connect(s.connCtx)
+		if err == nil {
+			if err = s.restoreMarketData(s.connCtx); err != nil {
+				s.log.LogAttrs(s.connCtx, slog.LevelWarn, "failed restoring market data subscriptions", slog.Any("err", err))
+				go s.errHandler(err)
+			}
+
+			s.fm.resume()
+			s.setState(StateReady, StateAuthorizing)
+			s.metrics.Reconnect()
+			s.log.LogAttrs(s.connCtx, slog.LevelInfo, "websocket reconnected")
+			return ss
+		}
+
+		s.log.LogAttrs(s.connCtx, slog.LevelWarn, "reconnect failed", slog.Any("err", err))
+		delay = min(delay*2, limit)
+	}
+}
@@ -0,0 +1,147 @@
+package tradovate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = `"<redacted>"`
+
+//go:generate enumer -type Direction -json -trimprefix Direction -transform lower
+type Direction byte
+
+const (
+	DirectionUnspecified Direction = iota
+	DirectionIn                    // frame received from the server
+	DirectionOut                   // request sent to the server
+)
+
+// A single line of a recording made with WithRecorder
+type RecordedFrame struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"dir"`
+	Frame     string    `json:"frame"`
+}
+
+// Record every inbound frame and outbound request to w as JSONL, one
+// RecordedFrame per line. Access tokens are redacted. Recordings can be
+// played back with Replay
+func WithRecorder(w io.Writer) WSOpt {
+	return func(s *WS) { s.rec = &recorder{enc: json.NewEncoder(w)} }
+}
+
+type recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (r *recorder) record(d Direction, frame []byte) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the writer is the user's problem; a failed write shouldn't kill
+	// the connection
+	r.enc.Encode(RecordedFrame{Time: time.Now(), Direction: d, Frame: string(frame)})
+}
+
+// requests are path\nid\nquery\nbody, and the only requests carrying the
+// token carry it as the entire body
+func redact(path string, payload []byte) []byte {
+	if path != "authorize" && path != accessTokenURL {
+		return payload
+	}
+
+	parts := strings.SplitN(string(payload), "\n", 4)
+	if len(parts) < 4 {
+		return payload
+	}
+
+	parts[3] = redacted + "\n"
+	return []byte(strings.Join(parts, "\n"))
+}
+
+// Replay a recording made with WithRecorder. Inbound frames are parsed
+// and dispatched exactly as a live socket would, to the handlers set in
+// opts; outbound requests are skipped. Handlers are called synchronously,
+// in the order the frames were recorded, so replays are deterministic.
+//
+// Speed scales the time between frames: 1 replays at the original pace,
+// 10 is ten times faster, and anything <= 0 replays as fast as possible.
+// A shutdown or close frame in the recording stops the replay and is
+// returned as an error, same as it would kill a live connection
+func Replay(ctx context.Context, r io.Reader, speed float64, opts ...WSOpt) error {
+	s := &WS{
+		inline:            true,
+		connCtx:           ctx,
+		done:              make(chan struct{}),
+		stateSubs:         map[int]func(from, to State){},
+		drainSubs:         map[int]func(){},
+		entityHandler:     func(em *EntityMsg) {},
+		chartHandler:      func(cr *Chart) {},
+		marketDataHandler: func(md *MarketData) {},
+		errHandler:        func(err error) {},
+		log:               discardLogger,
+		metrics:           nopMetrics{},
+	}
+
+	for _, v := range opts {
+		v(s)
+	}
+
+	var last time.Time
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		var f RecordedFrame
+		if err := json.Unmarshal(sc.Bytes(), &f); err != nil {
+			return fmt.Errorf("line %d of recording is invalid: %w", line, err)
+		}
+
+		if f.Direction != DirectionIn {
+			continue
+		}
+
+		if speed > 0 && !last.IsZero() {
+			t := time.NewTimer(time.Duration(float64(f.Time.Sub(last)) / speed))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+		last = f.Time
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		x, err := newFrame([]byte(f.Frame))
+		if err != nil {
+			return fmt.Errorf("line %d of recording: %w", line, err)
+		}
+
+		switch x.frameType() {
+		case frameTypeData:
+			err = s.handleDataframe(nil, x.(dataframe).msgs)
+		case frameTypeClose:
+			return fmt.Errorf("line %d of recording: close frame received", line)
+		}
+
+		if err != nil {
+			return fmt.Errorf("line %d of recording: %w", line, err)
+		}
+	}
+
+	return sc.Err()
+}
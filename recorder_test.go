@@ -0,0 +1,106 @@
+package tradovate_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+// buffer safe for the recorder and the test to share
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var rec syncBuffer
+	received := make(chan struct{}, 2)
+	ws, err := srv.NewSocket(ctx,
+		tradovate.WithRecorder(&rec),
+		tradovate.WithEntityHandler(func(*tradovate.EntityMsg) { received <- struct{}{} }),
+		tradovate.WithMarketDataHandler(func(*tradovate.MarketData) { received <- struct{}{} }),
+	)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+
+	if _, err = ws.SubscribeQuoteSymbol(ctx, "ESZ5"); err != nil {
+		t.Fatal(err)
+	}
+
+	order := &tradovate.Order{ID: 9, Status: tradovate.OrderStatusWorking}
+	quote := &tradovate.Quote{ContractID: 3, Trade: tradovate.PriceQty{Price: 10, Size: 1}, Entries: tradovate.QuoteEntryTrade}
+	srv.PushEntity(tradovate.EntityTypeOrder, tradovate.EventTypeCreated, order)
+	<-received
+	srv.PushQuotes(quote)
+	<-received
+	ws.Close()
+
+	recording := rec.String()
+	var dirs []tradovate.Direction
+	sc := bufio.NewScanner(strings.NewReader(recording))
+	for sc.Scan() {
+		var f tradovate.RecordedFrame
+		if err = json.Unmarshal(sc.Bytes(), &f); err != nil {
+			t.Fatalf("recording has invalid line %s: %v", sc.Text(), err)
+		}
+
+		if strings.Contains(f.Frame, "token-") {
+			t.Errorf("recording leaked a token: %s", f.Frame)
+		}
+		dirs = append(dirs, f.Direction)
+	}
+
+	want := []tradovate.Direction{
+		tradovate.DirectionIn,  // o
+		tradovate.DirectionOut, // authorize
+		tradovate.DirectionIn,  // authorize resp
+		tradovate.DirectionOut, // subscribe
+		tradovate.DirectionIn,  // subscribe resp
+		tradovate.DirectionIn,  // props
+		tradovate.DirectionIn,  // md
+	}
+	if !reflect.DeepEqual(want, dirs) {
+		t.Errorf("wrong frames recorded\nwant %v\n got %v\n%s", want, dirs, recording)
+	}
+
+	var replayed []any
+	err = tradovate.Replay(ctx, strings.NewReader(recording), 0,
+		tradovate.WithEntityHandler(func(e *tradovate.EntityMsg) { replayed = append(replayed, e.MustOrder()) }),
+		tradovate.WithMarketDataHandler(func(m *tradovate.MarketData) { replayed = append(replayed, m.Quotes[0]) }),
+	)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	if want := []any{order, quote}; !reflect.DeepEqual(want, replayed) {
+		t.Errorf("replay out of order or wrong\nwant %+v\n got %+v", want, replayed)
+	}
+}
@@ -0,0 +1,202 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/coder/websocket"
+)
+
+type client struct {
+	out      chan []byte
+	overflow Overflow
+	dropped  atomic.Int64
+
+	done   chan struct{}
+	once   sync.Once
+	reason error
+	subs   map[subKey]*held // only touched by the goroutine serving the client
+}
+
+// a subscription's key, with the contract resolved
+type subKey struct {
+	kind       tradovate.SubKind
+	contractID int
+	chart      tradovate.ChartReq
+}
+
+type held struct {
+	release tradovate.Release
+	routes  []route
+	chart   *tradovate.ChartResp
+}
+
+func newClient(queueSize int, overflow Overflow) *client {
+	return &client{
+		out:      make(chan []byte, queueSize),
+		overflow: overflow,
+		done:     make(chan struct{}),
+		subs:     map[subKey]*held{},
+	}
+}
+
+// never blocks
+func (c *client) send(buf []byte) {
+	select {
+	case c.out <- buf:
+	default:
+		if c.overflow == OverflowDisconnect {
+			c.stop(ErrTooSlow)
+			return
+		}
+		c.dropped.Add(1)
+	}
+}
+
+func (c *client) stop(reason error) {
+	c.once.Do(func() {
+		c.reason = reason
+		close(c.done)
+	})
+}
+
+// write the queue out until the client stops or a write fails
+func (c *client) run(ctx context.Context, write func(context.Context, []byte) error) error {
+	for {
+		// a stopped client gets nothing more, even with messages queued
+		select {
+		case <-c.done:
+			return c.reason
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.done:
+			return c.reason
+		case buf := <-c.out:
+			if err := write(ctx, buf); err != nil {
+				return err
+			}
+
+			// what was dropped came after everything still queued
+			if len(c.out) > 0 {
+				continue
+			}
+
+			if n := c.dropped.Swap(0); n > 0 {
+				if err := write(ctx, encode(&Message{Dropped: n})); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (r *Relay) serveStream(w http.ResponseWriter, req *http.Request, subs []Sub) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	c, err := r.connect()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer r.disconnect(c)
+
+	ctx := req.Context()
+	for _, v := range subs {
+		r.subscribe(ctx, c, v)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	c.run(ctx, func(_ context.Context, buf []byte) error {
+		// buf is shared with every other client, don't append to it
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{'\n'}); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+}
+
+func (r *Relay) serveWebsocket(w http.ResponseWriter, req *http.Request, subs []Sub) {
+	c, err := r.connect()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer r.disconnect(c)
+
+	conn, err := websocket.Accept(w, req, r.accept)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	for _, v := range subs {
+		r.subscribe(ctx, c, v)
+	}
+
+	// control messages; subscriptions are only changed here from now on
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+
+		for {
+			_, buf, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+
+			var x Control
+			if err = json.Unmarshal(buf, &x); err != nil {
+				c.send(encode(&Message{Err: "bad control message: " + err.Error()}))
+				continue
+			}
+
+			for _, v := range x.Subscribe {
+				r.subscribe(ctx, c, v)
+			}
+
+			for _, v := range x.Unsubscribe {
+				r.unsubscribe(ctx, c, v)
+			}
+		}
+	}()
+
+	err = c.run(ctx, func(ctx context.Context, buf []byte) error {
+		return conn.Write(ctx, websocket.MessageText, buf)
+	})
+
+	switch {
+	case errors.Is(err, ErrTooSlow):
+		conn.Close(websocket.StatusPolicyViolation, err.Error())
+	case errors.Is(err, ErrClosed):
+		conn.Close(websocket.StatusGoingAway, err.Error())
+	default:
+		conn.Close(websocket.StatusNormalClosure, "")
+	}
+
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,62 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOverflow(mainTest *testing.T) {
+	testCases := []struct {
+		name     string
+		overflow Overflow
+		expected []string
+		err      error
+	}{
+		{
+			name:     "drop",
+			overflow: OverflowDrop,
+			expected: []string{"0", "1", `{"dropped":3}`},
+			err:      context.Canceled,
+		},
+		{
+			name:     "disconnect",
+			overflow: OverflowDisconnect,
+			err:      ErrTooSlow,
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			c := newClient(2, tc.overflow)
+			for _, v := range []string{"0", "1", "2", "3", "4"} {
+				c.send([]byte(v)) // never blocks with nobody reading
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var got []string
+			err := c.run(ctx, func(_ context.Context, buf []byte) error {
+				if got = append(got, string(buf)); len(got) == len(tc.expected) {
+					cancel()
+				}
+				return nil
+			})
+
+			if !errors.Is(err, tc.err) {
+				tt.Errorf("wanted %v, got %v", tc.err, err)
+			}
+
+			if len(got) != len(tc.expected) {
+				tt.Fatalf("want %q got %q", tc.expected, got)
+			}
+
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					tt.Errorf("want %q got %q", tc.expected, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+// Sub is a feed a client wants
+type Sub struct {
+	Kind       tradovate.SubKind   `json:"kind"`
+	Symbol     string              `json:"symbol,omitzero"`
+	ContractID int                 `json:"contractId,omitzero"` // instead of the symbol
+	Chart      *tradovate.ChartReq `json:"chart,omitzero"`      // chart subscriptions only
+}
+
+// Control changes a websocket client's subscriptions
+type Control struct {
+	Subscribe   []Sub `json:"subscribe,omitzero"`
+	Unsubscribe []Sub `json:"unsubscribe,omitzero"`
+}
+
+// Message is what clients get: a line of an HTTP stream, or a websocket
+// message. Exactly one of the market data fields is set, or it's about a
+// subscription or dropped messages
+type Message struct {
+	Quote     *tradovate.Quote
+	DOM       *tradovate.DOM
+	Histogram *tradovate.Histogram
+	Chart     *tradovate.Chart // its ID is one of the ChartResp's
+
+	// Acknowledges a subscribe, or says why a subscribe or unsubscribe
+	// failed when Err is set. Chart subscribes come with the ChartResp
+	Sub       *Sub
+	ChartResp *tradovate.ChartResp
+	Err       string
+
+	Dropped int64 // messages the client missed falling behind, with OverflowDrop
+}
+
+// the market data types' own unmarshalers only read the server's format,
+// so they're relayed as plain structs
+type (
+	quote     tradovate.Quote
+	histogram tradovate.Histogram
+	chart     tradovate.Chart
+)
+
+type message struct {
+	Quote     *quote               `json:"quote,omitempty"`
+	DOM       *tradovate.DOM       `json:"dom,omitempty"`
+	Histogram *histogram           `json:"histogram,omitempty"`
+	Chart     *chart               `json:"chart,omitempty"`
+	Sub       *Sub                 `json:"sub,omitempty"`
+	ChartResp *tradovate.ChartResp `json:"chartResp,omitempty"`
+	Err       string               `json:"err,omitempty"`
+	Dropped   int64                `json:"dropped,omitempty"`
+}
+
+func (m *Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(message{
+		Quote:     (*quote)(m.Quote),
+		DOM:       m.DOM,
+		Histogram: (*histogram)(m.Histogram),
+		Chart:     (*chart)(m.Chart),
+		Sub:       m.Sub,
+		ChartResp: m.ChartResp,
+		Err:       m.Err,
+		Dropped:   m.Dropped,
+	})
+}
+
+func (m *Message) UnmarshalJSON(b []byte) error {
+	var x message
+	if err := json.Unmarshal(b, &x); err != nil {
+		return err
+	}
+
+	*m = Message{
+		Quote:     (*tradovate.Quote)(x.Quote),
+		DOM:       x.DOM,
+		Histogram: (*tradovate.Histogram)(x.Histogram),
+		Chart:     (*tradovate.Chart)(x.Chart),
+		Sub:       x.Sub,
+		ChartResp: x.ChartResp,
+		Err:       x.Err,
+		Dropped:   x.Dropped,
+	}
+	return nil
+}
+
+// Subscriptions from query params: comma separated symbols for quote,
+// dom, histogram and chart. Charts share one request, built from
+// chartType, elementSize, elementSizeUnit and bars (as much as elements),
+// defaulting to the last 100 one minute bars
+func ParseSubs(q url.Values) ([]Sub, error) {
+	var subs []Sub
+	for _, kind := range []tradovate.SubKind{tradovate.SubKindQuote, tradovate.SubKindDOM, tradovate.SubKindHistogram} {
+		for _, v := range symbols(q, strings.ToLower(kind.String())) {
+			subs = append(subs, Sub{Kind: kind, Symbol: v})
+		}
+	}
+
+	charts := symbols(q, "chart")
+	if len(charts) == 0 {
+		return subs, nil
+	}
+
+	req, err := parseChartReq(q)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range charts {
+		subs = append(subs, Sub{Kind: tradovate.SubKindChart, Symbol: v, Chart: req})
+	}
+	return subs, nil
+}
+
+func parseChartReq(q url.Values) (*tradovate.ChartReq, error) {
+	req := &tradovate.ChartReq{
+		UnderlyingType:  tradovate.ChartTypeMinuteBar,
+		ElementSize:     1,
+		ElementSizeUnit: tradovate.SizeUnitUnderlyingUnits,
+	}
+
+	var err error
+	if v := q.Get("chartType"); v != "" {
+		if req.UnderlyingType, err = tradovate.ChartTypeString(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("elementSizeUnit"); v != "" {
+		if req.ElementSizeUnit, err = tradovate.SizeUnitString(v); err != nil {
+			return nil, err
+		}
+	}
+
+	for param, dst := range map[string]*uint32{"elementSize": &req.ElementSize, "bars": &req.AsMuchAsElements} {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s %q: %w", param, v, err)
+		}
+		*dst = uint32(n)
+	}
+
+	if req.AsMuchAsElements == 0 {
+		req.AsMuchAsElements = 100
+	}
+	return req, nil
+}
+
+func symbols(q url.Values, param string) []string {
+	var x []string
+	for _, v := range q[param] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				x = append(x, s)
+			}
+		}
+	}
+	return x
+}
+
+func encode(m *Message) []byte {
+	buf, _ := m.MarshalJSON()
+	return buf
+}
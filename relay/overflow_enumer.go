@@ -0,0 +1,100 @@
+// Code generated by "enumer -type Overflow -trimprefix Overflow -transform lower -json"; DO NOT EDIT.
+
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const _OverflowName = "unspecifieddropdisconnect"
+
+var _OverflowIndex = [...]uint8{0, 11, 15, 25}
+
+const _OverflowLowerName = "unspecifieddropdisconnect"
+
+func (i Overflow) String() string {
+	if i >= Overflow(len(_OverflowIndex)-1) {
+		return fmt.Sprintf("Overflow(%d)", i)
+	}
+	return _OverflowName[_OverflowIndex[i]:_OverflowIndex[i+1]]
+}
+
+// An "invalid array index" compiler error signifies that the constant values have changed.
+// Re-run the stringer command to generate them again.
+func _OverflowNoOp() {
+	var x [1]struct{}
+	_ = x[OverflowUnspecified-(0)]
+	_ = x[OverflowDrop-(1)]
+	_ = x[OverflowDisconnect-(2)]
+}
+
+var _OverflowValues = []Overflow{OverflowUnspecified, OverflowDrop, OverflowDisconnect}
+
+var _OverflowNameToValueMap = map[string]Overflow{
+	_OverflowName[0:11]:       OverflowUnspecified,
+	_OverflowLowerName[0:11]:  OverflowUnspecified,
+	_OverflowName[11:15]:      OverflowDrop,
+	_OverflowLowerName[11:15]: OverflowDrop,
+	_OverflowName[15:25]:      OverflowDisconnect,
+	_OverflowLowerName[15:25]: OverflowDisconnect,
+}
+
+var _OverflowNames = []string{
+	_OverflowName[0:11],
+	_OverflowName[11:15],
+	_OverflowName[15:25],
+}
+
+// OverflowString retrieves an enum value from the enum constants string name.
+// Throws an error if the param is not part of the enum.
+func OverflowString(s string) (Overflow, error) {
+	if val, ok := _OverflowNameToValueMap[s]; ok {
+		return val, nil
+	}
+
+	if val, ok := _OverflowNameToValueMap[strings.ToLower(s)]; ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("%s does not belong to Overflow values", s)
+}
+
+// OverflowValues returns all values of the enum
+func OverflowValues() []Overflow {
+	return _OverflowValues
+}
+
+// OverflowStrings returns a slice of all String values of the enum
+func OverflowStrings() []string {
+	strs := make([]string, len(_OverflowNames))
+	copy(strs, _OverflowNames)
+	return strs
+}
+
+// IsAOverflow returns "true" if the value is listed in the enum definition. "false" otherwise
+func (i Overflow) IsAOverflow() bool {
+	for _, v := range _OverflowValues {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON implements the json.Marshaler interface for Overflow
+func (i Overflow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Overflow
+func (i *Overflow) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Overflow should be a string, got %s", data)
+	}
+
+	var err error
+	*i, err = OverflowString(s)
+	return err
+}
@@ -0,0 +1,415 @@
+// Package relay re-serves one tradovate market data socket to many local
+// clients, since tradovate limits how many market data connections an
+// account can hold.
+//
+// Clients connect over a websocket, or a plain HTTP stream of newline
+// delimited JSON, and only get the feeds they subscribe to. Upstream
+// subscriptions are shared through a tradovate.SubscriptionManager, so
+// the server sees one subscribe no matter how many clients want a feed.
+// A client joining a feed that's already flowing starts from a snapshot:
+// the merged quote, the last DOM or histogram, or the chart's history.
+//
+// Every client has a bounded queue. Handing a message to a client never
+// blocks, so a slow client falls behind on its own without holding up the
+// socket or anyone else; what happens when its queue fills is up to
+// WithOverflow
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/coder/websocket"
+)
+
+const defaultQueueSize = 256
+
+var (
+	ErrNotAttached = errors.New("relay isn't attached to a socket")
+	ErrClosed      = errors.New("relay closed")
+	ErrTooSlow     = errors.New("client fell too far behind")
+)
+
+// What to do with a message for a client whose queue is full
+//
+//go:generate enumer -type Overflow -trimprefix Overflow -transform lower -json
+type Overflow byte
+
+const (
+	OverflowUnspecified Overflow = iota
+	OverflowDrop                 // drop it, telling the client how many it missed once it catches up
+	OverflowDisconnect           // disconnect the client
+)
+
+type Opt func(r *Relay)
+
+// Messages a client can fall behind by. Defaults to 256
+func WithQueueSize(n int) Opt {
+	return func(r *Relay) { r.queueSize = n }
+}
+
+// Defaults to OverflowDrop
+func WithOverflow(o Overflow) Opt {
+	return func(r *Relay) { r.overflow = o }
+}
+
+// Share subscriptions with the rest of the process. Defaults to a
+// manager of the relay's own, made by Attach
+func WithSubscriptionManager(m *tradovate.SubscriptionManager) Opt {
+	return func(r *Relay) { r.subs = m }
+}
+
+// Options for accepting websockets, e.g. the origins allowed to connect
+func WithAcceptOptions(o *websocket.AcceptOptions) Opt {
+	return func(r *Relay) { r.accept = o }
+}
+
+// Handler for errors releasing upstream subscriptions, which happen when
+// clients leave and have nowhere else to go. Defaults to discarding them
+func WithErrHandler(fn func(error)) Opt {
+	return func(r *Relay) { r.errHandler = fn }
+}
+
+// Relay fans a socket's market data out to local clients. Hook its
+// handlers up to a socket,
+//
+//	r := relay.New()
+//	ws, _ := tradovate.NewSocket(ctx, uri, nil, rest,
+//		tradovate.WithMarketDataHandler(r.HandleMarketData),
+//		tradovate.WithChartHandler(r.HandleChart),
+//	)
+//	r.Attach(ws)
+//	http.Handle("/md", r)
+//
+// Clients subscribe with query params, e.g.
+//
+//	/md?quote=ESZ5,NQZ5&dom=ESZ5&chart=ESZ5&chartType=MinuteBar&elementSize=5&bars=100
+//
+// and on a websocket, by sending a Control message at any time. Every
+// message they get is a Message
+type Relay struct {
+	queueSize  int
+	overflow   Overflow
+	accept     *websocket.AcceptOptions
+	errHandler func(error)
+
+	mu      sync.RWMutex
+	ws      *tradovate.WS
+	subs    *tradovate.SubscriptionManager
+	closed  bool
+	ids     map[string]int // symbol -> contract ID
+	clients map[*client]struct{}
+	routes  map[route]map[*client]struct{}
+	snap    *snapshot // for clients subscribing to feeds already flowing
+}
+
+// who gets a message: quotes, DOMs and histograms go by contract ID,
+// charts by subscription ID
+type route struct {
+	kind tradovate.SubKind
+	id   int
+}
+
+func New(opts ...Opt) *Relay {
+	r := &Relay{
+		queueSize:  defaultQueueSize,
+		overflow:   OverflowDrop,
+		errHandler: func(error) {},
+		ids:        map[string]int{},
+		clients:    map[*client]struct{}{},
+		routes:     map[route]map[*client]struct{}{},
+		snap:       newSnapshot(),
+	}
+
+	for _, v := range opts {
+		v(r)
+	}
+	return r
+}
+
+// Serve from the socket, disconnecting every client when it closes.
+// Call the returned func to detach
+func (r *Relay) Attach(ws *tradovate.WS) (detach func()) {
+	r.mu.Lock()
+	r.ws = ws
+	if r.subs == nil {
+		r.subs = tradovate.NewSubscriptionManager(ws)
+	}
+	r.mu.Unlock()
+
+	return ws.OnStateChange(func(_, to tradovate.State) {
+		if to == tradovate.StateClosed {
+			r.Close()
+		}
+	})
+}
+
+// Disconnect every client and turn new ones away
+func (r *Relay) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	for c := range r.clients {
+		c.stop(ErrClosed)
+	}
+}
+
+// Number of connected clients
+func (r *Relay) Clients() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.clients)
+}
+
+// Matches the signature of WithMarketDataHandler
+func (r *Relay) HandleMarketData(md *tradovate.MarketData) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	r.snap.marketData(md)
+	for _, v := range md.Quotes {
+		r.publish(route{tradovate.SubKindQuote, v.ContractID}, &Message{Quote: v})
+	}
+
+	for _, v := range md.DOMs {
+		r.publish(route{tradovate.SubKindDOM, v.ContractID}, &Message{DOM: v})
+	}
+
+	for _, v := range md.Histograms {
+		r.publish(route{tradovate.SubKindHistogram, v.ContractID}, &Message{Histogram: v})
+	}
+}
+
+// Matches the signature of WithChartHandler
+func (r *Relay) HandleChart(c *tradovate.Chart) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	r.snap.chart(c)
+	r.publish(route{tradovate.SubKindChart, c.ID}, &Message{Chart: c})
+}
+
+// Serves websockets and HTTP streams
+func (r *Relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	subs, err := ParseSubs(req.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		r.serveWebsocket(w, req, subs)
+		return
+	}
+
+	r.serveStream(w, req, subs)
+}
+
+// encoded once, however many clients get it
+func (r *Relay) publish(to route, m *Message) {
+	clients := r.routes[to]
+	if len(clients) == 0 {
+		return
+	}
+
+	buf, err := m.MarshalJSON()
+	if err != nil {
+		return
+	}
+
+	for c := range clients {
+		c.send(buf)
+	}
+}
+
+func (r *Relay) connect() (*client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case r.closed:
+		return nil, ErrClosed
+	case r.subs == nil:
+		return nil, ErrNotAttached
+	}
+
+	c := newClient(r.queueSize, r.overflow)
+	r.clients[c] = struct{}{}
+	return c, nil
+}
+
+// drop the client, releasing everything it held
+func (r *Relay) disconnect(c *client) {
+	c.stop(ErrClosed)
+
+	r.mu.Lock()
+	delete(r.clients, c)
+	for _, v := range c.subs {
+		r.unroute(c, v.routes)
+	}
+	r.mu.Unlock()
+
+	ctx := context.Background()
+	for k, v := range c.subs {
+		if err := r.release(ctx, k, v); err != nil {
+			r.errHandler(fmt.Errorf("failed releasing %s %d: %w", k.kind, k.contractID, err))
+		}
+	}
+	c.subs = nil
+}
+
+func (r *Relay) subscribe(ctx context.Context, c *client, s Sub) {
+	k, err := r.key(ctx, s)
+	if err != nil {
+		c.send(encode(&Message{Sub: &s, Err: err.Error()}))
+		return
+	}
+
+	if x, ok := c.subs[k]; ok {
+		c.send(encode(&Message{Sub: &s, ChartResp: x.chart}))
+		return
+	}
+
+	var x held
+	switch s.Kind {
+	case tradovate.SubKindQuote, tradovate.SubKindDOM, tradovate.SubKindHistogram:
+		x.release, err = r.subs.AcquireID(ctx, s.Kind, k.contractID)
+		x.routes = []route{{s.Kind, k.contractID}}
+	case tradovate.SubKindChart:
+		var resp tradovate.ChartResp
+		resp, x.release, err = r.subs.AcquireChartID(ctx, k.contractID, s.Chart)
+		x.chart = &resp
+		x.routes = []route{{s.Kind, resp.HistoricalID}}
+		if resp.RealtimeID != resp.HistoricalID {
+			x.routes = append(x.routes, route{s.Kind, resp.RealtimeID})
+		}
+	}
+
+	if err != nil {
+		c.send(encode(&Message{Sub: &s, Err: err.Error()}))
+		return
+	}
+
+	// everything upstream sent since the acquire is in the snapshot, and
+	// nothing's published while it's sent and the routes added
+	r.mu.Lock()
+	c.send(encode(&Message{Sub: &s, ChartResp: x.chart}))
+	for _, v := range x.routes {
+		for _, m := range r.snap.messages(v) {
+			c.send(encode(m))
+		}
+
+		if r.routes[v] == nil {
+			r.routes[v] = map[*client]struct{}{}
+		}
+		r.routes[v][c] = struct{}{}
+	}
+	r.mu.Unlock()
+
+	c.subs[k] = &x
+}
+
+func (r *Relay) unsubscribe(ctx context.Context, c *client, s Sub) {
+	k, err := r.key(ctx, s)
+	if err != nil {
+		c.send(encode(&Message{Sub: &s, Err: err.Error()}))
+		return
+	}
+
+	x, ok := c.subs[k]
+	if !ok {
+		return
+	}
+	delete(c.subs, k)
+
+	r.mu.Lock()
+	r.unroute(c, x.routes)
+	r.mu.Unlock()
+
+	if err = r.release(ctx, k, x); err != nil {
+		c.send(encode(&Message{Sub: &s, Err: err.Error()}))
+	}
+}
+
+// release a client's hold, forgetting the snapshot once the feed's
+// unsubscribed upstream
+func (r *Relay) release(ctx context.Context, k subKey, x *held) error {
+	if err := x.release(ctx); err != nil {
+		return err
+	}
+
+	for _, v := range r.subs.Active() {
+		if v.Kind == k.kind && v.ContractID == k.contractID && (x.chart == nil || v.ChartResp == *x.chart) {
+			return nil
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range x.routes {
+		if len(r.routes[v]) == 0 {
+			r.snap.forget(v)
+		}
+	}
+	return nil
+}
+
+func (r *Relay) unroute(c *client, routes []route) {
+	for _, v := range routes {
+		delete(r.routes[v], c)
+		if len(r.routes[v]) == 0 {
+			delete(r.routes, v)
+		}
+	}
+}
+
+// what a subscription's held under, with the symbol resolved so a
+// symbol and its contract ID are the same subscription
+func (r *Relay) key(ctx context.Context, s Sub) (subKey, error) {
+	k := subKey{kind: s.Kind, contractID: s.ContractID}
+	switch s.Kind {
+	case tradovate.SubKindQuote, tradovate.SubKindDOM, tradovate.SubKindHistogram:
+	case tradovate.SubKindChart:
+		if s.Chart == nil {
+			return k, fmt.Errorf("chart subscriptions need a chart request")
+		}
+		k.chart = *s.Chart
+	default:
+		return k, fmt.Errorf("unknown subscription kind %s", s.Kind)
+	}
+
+	if k.contractID != 0 {
+		return k, nil
+	}
+
+	if s.Symbol == "" {
+		return k, fmt.Errorf("subscription needs a symbol or contract ID")
+	}
+
+	r.mu.RLock()
+	id, ok := r.ids[s.Symbol]
+	ws := r.ws
+	r.mu.RUnlock()
+	if ok {
+		k.contractID = id
+		return k, nil
+	}
+
+	c, err := ws.FindContract(ctx, s.Symbol)
+	if err != nil {
+		return k, fmt.Errorf("failed resolving %s: %w", s.Symbol, err)
+	}
+
+	r.mu.Lock()
+	r.ids[s.Symbol] = c.ID
+	r.mu.Unlock()
+
+	k.contractID = c.ID
+	return k, nil
+}
@@ -0,0 +1,311 @@
+package relay_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/relay"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+	"github.com/coder/websocket"
+)
+
+func newRelay(t *testing.T, ctx context.Context) (*tradovatetest.Server, *tradovate.WS, *relay.Relay, *httptest.Server) {
+	srv := tradovatetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.SetContracts(&tradovate.Contract{ID: 1, Name: "ESZ5"}, &tradovate.Contract{ID: 2, Name: "NQZ5"})
+
+	r := relay.New()
+	ws, err := srv.NewSocket(ctx, tradovate.WithMarketDataHandler(r.HandleMarketData), tradovate.WithChartHandler(r.HandleChart))
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	r.Attach(ws)
+
+	hs := httptest.NewServer(r)
+	t.Cleanup(hs.Close)
+	return srv, ws, r, hs
+}
+
+// stream the query's messages until the returned func is called
+func stream(t *testing.T, ctx context.Context, url string) (<-chan relay.Message, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("wanted 200, got %d", resp.StatusCode)
+	}
+
+	out := make(chan relay.Message, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(out)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var m relay.Message
+			if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+				return
+			}
+			out <- m
+		}
+	}()
+
+	return out, cancel
+}
+
+func next(t *testing.T, ctx context.Context, c <-chan relay.Message) relay.Message {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for a message")
+	case m := <-c:
+		return m
+	}
+	return relay.Message{}
+}
+
+func eventually(t *testing.T, ctx context.Context, what string, fn func() bool) {
+	t.Helper()
+	for !fn() {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %s", what)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	srv, _, r, hs := newRelay(t, ctx)
+
+	a, closeA := stream(t, ctx, hs.URL+"?quote=ESZ5")
+	if m := next(t, ctx, a); m.Sub == nil || m.Sub.Symbol != "ESZ5" || m.Err != "" {
+		t.Fatalf("wanted a subscribe ack, got %+v", m)
+	}
+
+	b, closeB := stream(t, ctx, hs.URL+"?quote=ESZ5&dom=NQZ5,XXXX")
+	for range 2 {
+		if m := next(t, ctx, b); m.Sub == nil || m.Err != "" {
+			t.Fatalf("wanted a subscribe ack, got %+v", m)
+		}
+	}
+
+	if m := next(t, ctx, b); m.Sub == nil || m.Sub.Symbol != "XXXX" || m.Err == "" {
+		t.Errorf("unknown symbols should fail, got %+v", m)
+	}
+
+	if n := len(srv.RequestsFor("md/subscribeQuote")); n != 1 {
+		t.Errorf("clients should share the upstream subscription, got %d subscribes", n)
+	}
+
+	if err := srv.PushDOMs(&tradovate.DOM{ContractID: 2, Bids: []tradovate.PriceQty{{Price: 20000, Size: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := next(t, ctx, b); m.DOM == nil || m.DOM.Bids[0].Price != 20000 {
+		t.Errorf("wanted the DOM, got %+v", m)
+	}
+
+	if err := srv.PushQuotes(&tradovate.Quote{ContractID: 1, Bid: tradovate.PriceQty{Price: 5000.25, Size: 3}, Entries: tradovate.QuoteEntryBid}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []<-chan relay.Message{a, b} {
+		// a never gets the DOM, so the quote is next for both
+		if m := next(t, ctx, c); m.Quote == nil || m.Quote.ContractID != 1 || m.Quote.Bid.Price != 5000.25 {
+			t.Errorf("wanted the quote, got %+v", m)
+		}
+	}
+
+	closeA()
+	eventually(t, ctx, "a to disconnect", func() bool { return r.Clients() == 1 })
+	if n := len(srv.RequestsFor("md/unsubscribeQuote")); n != 0 {
+		t.Errorf("b still wants quotes, got %d unsubscribes", n)
+	}
+
+	closeB()
+	eventually(t, ctx, "the last client's subscriptions to be released", func() bool {
+		return len(srv.RequestsFor("md/unsubscribeQuote")) == 1 && len(srv.RequestsFor("md/unsubscribeDOM")) == 1
+	})
+}
+
+func TestWebsocket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	srv, ws, _, hs := newRelay(t, ctx)
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(hs.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+
+	read := func() relay.Message {
+		_, buf, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var m relay.Message
+		if err = json.Unmarshal(buf, &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	control := func(x relay.Control) {
+		buf, err := json.Marshal(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = conn.Write(ctx, websocket.MessageText, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sub := relay.Sub{Kind: tradovate.SubKindChart, Symbol: "ESZ5", Chart: &tradovate.ChartReq{
+		UnderlyingType:   tradovate.ChartTypeMinuteBar,
+		ElementSize:      1,
+		ElementSizeUnit:  tradovate.SizeUnitUnderlyingUnits,
+		AsMuchAsElements: 10,
+	}}
+	control(relay.Control{Subscribe: []relay.Sub{sub}})
+
+	ack := read()
+	if ack.Sub == nil || ack.Err != "" || ack.ChartResp == nil {
+		t.Fatalf("wanted a chart ack, got %+v", ack)
+	}
+
+	err = srv.PushChart(&tradovate.Chart{ID: ack.ChartResp.RealtimeID, Bars: []tradovate.Bar{{Open: 1, Close: 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := read(); m.Chart == nil || m.Chart.ID != ack.ChartResp.RealtimeID || len(m.Chart.Bars) != 1 || m.Chart.Bars[0].Close != 2 {
+		t.Errorf("wanted the chart, got %+v", m)
+	}
+
+	control(relay.Control{Unsubscribe: []relay.Sub{sub}})
+	eventually(t, ctx, "the chart to be canceled", func() bool { return len(srv.RequestsFor("md/cancelchart")) == 1 })
+
+	ws.Close()
+	if _, _, err = conn.Read(ctx); websocket.CloseStatus(err) != websocket.StatusGoingAway {
+		t.Errorf("clients should be disconnected when the socket closes, got %v", err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	srv, _, r, hs := newRelay(t, ctx)
+
+	const q = "?quote=ESZ5&dom=ESZ5&chart=ESZ5"
+	a, closeA := stream(t, ctx, hs.URL+q)
+	var resp *tradovate.ChartResp
+	for range 3 {
+		m := next(t, ctx, a)
+		if m.Sub == nil || m.Err != "" {
+			t.Fatalf("wanted a subscribe ack, got %+v", m)
+		}
+		if m.ChartResp != nil {
+			resp = m.ChartResp
+		}
+	}
+
+	t0 := time.UnixMilli(1_700_000_000_000)
+	pushes := []func() error{
+		func() error {
+			return srv.PushQuotes(&tradovate.Quote{ContractID: 1, Bid: tradovate.PriceQty{Price: 5000.25, Size: 3}, Entries: tradovate.QuoteEntryBid})
+		},
+		func() error {
+			return srv.PushQuotes(&tradovate.Quote{ContractID: 1, Offer: tradovate.PriceQty{Price: 5000.5, Size: 2}, Entries: tradovate.QuoteEntryOffer})
+		},
+		func() error {
+			return srv.PushDOMs(&tradovate.DOM{ContractID: 1, Bids: []tradovate.PriceQty{{Price: 5000, Size: 1}}})
+		},
+		func() error {
+			return srv.PushChart(&tradovate.Chart{ID: resp.HistoricalID, Bars: []tradovate.Bar{{Timestamp: t0, Close: 1}}})
+		},
+		func() error {
+			return srv.PushChart(&tradovate.Chart{ID: resp.HistoricalID, Bars: []tradovate.Bar{{Timestamp: t0.Add(time.Minute), Close: 2}}})
+		},
+	}
+
+	// one at a time, so they're all relayed before b joins
+	for _, push := range pushes {
+		if err := push(); err != nil {
+			t.Fatal(err)
+		}
+		next(t, ctx, a)
+	}
+
+	b, closeB := stream(t, ctx, hs.URL+q)
+	var quote, dom, chart bool
+	for range 6 {
+		switch m := next(t, ctx, b); {
+		case m.Err != "":
+			t.Fatalf("subscribe failed: %s", m.Err)
+		case m.Quote != nil:
+			quote = true
+			if m.Quote.Bid.Price != 5000.25 || m.Quote.Offer.Price != 5000.5 {
+				t.Errorf("wanted the merged quote, got %+v", m.Quote)
+			}
+		case m.DOM != nil:
+			dom = true
+			if len(m.DOM.Bids) != 1 || m.DOM.Bids[0].Price != 5000 {
+				t.Errorf("wanted the last DOM, got %+v", m.DOM)
+			}
+		case m.Chart != nil:
+			chart = true
+			if m.Chart.ID != resp.HistoricalID || len(m.Chart.Bars) != 2 || m.Chart.Bars[1].Close != 2 {
+				t.Errorf("wanted the chart's history, got %+v", m.Chart)
+			}
+		}
+	}
+
+	if !quote || !dom || !chart {
+		t.Errorf("late joiners should get a snapshot, got quote %v, dom %v, chart %v", quote, dom, chart)
+	}
+
+	closeA()
+	closeB()
+	eventually(t, ctx, "the clients to disconnect", func() bool {
+		return r.Clients() == 0 && len(srv.RequestsFor("md/unsubscribeQuote")) == 1
+	})
+
+	c, closeC := stream(t, ctx, hs.URL+"?quote=ESZ5")
+	defer closeC()
+	if m := next(t, ctx, c); m.Sub == nil || m.Err != "" {
+		t.Fatalf("wanted a subscribe ack, got %+v", m)
+	}
+
+	if err := srv.PushQuotes(&tradovate.Quote{ContractID: 1, Bid: tradovate.PriceQty{Price: 4999, Size: 1}, Entries: tradovate.QuoteEntryBid}); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := next(t, ctx, c); m.Quote == nil || m.Quote.Bid.Price != 4999 || m.Quote.Offer.Price != 0 {
+		t.Errorf("a feed nobody held shouldn't be replayed, got %+v", m)
+	}
+}
@@ -0,0 +1,157 @@
+package relay
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+// Bars and tick chart frames kept per chart for clients joining late;
+// the oldest go first
+const (
+	maxBars       = 10000
+	maxTickFrames = 1000
+)
+
+// the last of everything upstream sent, so a client subscribing to a
+// feed that's already flowing starts from where everyone else is.
+// Quotes are merged, since they're partial; DOMs and histograms are the
+// latest; charts keep their history
+type snapshot struct {
+	quotes *tradovate.QuoteBook
+
+	mu         sync.Mutex
+	doms       map[int]*tradovate.DOM
+	histograms map[int]*tradovate.Histogram
+	charts     map[int]*chartHistory
+}
+
+type chartHistory struct {
+	last   *tradovate.Chart   // for the fields that aren't bars or ticks
+	bars   []tradovate.Bar    // merged by timestamp, oldest first
+	frames []*tradovate.Chart // tick charts' frames, since ticks are relative to their frame
+}
+
+func newSnapshot() *snapshot {
+	return &snapshot{
+		quotes:     tradovate.NewQuoteBook(),
+		doms:       map[int]*tradovate.DOM{},
+		histograms: map[int]*tradovate.Histogram{},
+		charts:     map[int]*chartHistory{},
+	}
+}
+
+func (s *snapshot) marketData(md *tradovate.MarketData) {
+	s.quotes.Handle(md)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// handlers run as goroutines, so older ones can come last
+	for _, v := range md.DOMs {
+		if x, ok := s.doms[v.ContractID]; !ok || !v.Timestamp.Before(x.Timestamp) {
+			s.doms[v.ContractID] = v
+		}
+	}
+
+	for _, v := range md.Histograms {
+		if x, ok := s.histograms[v.ContractID]; !ok || !v.Timestamp.Before(x.Timestamp) {
+			s.histograms[v.ContractID] = v
+		}
+	}
+}
+
+func (s *snapshot) chart(c *tradovate.Chart) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.charts[c.ID]
+	if !ok {
+		h = &chartHistory{}
+		s.charts[c.ID] = h
+	}
+	h.last = c
+
+	if len(c.Ticks) > 0 {
+		if h.frames = append(h.frames, c); len(h.frames) > maxTickFrames {
+			h.frames = slices.Delete(h.frames, 0, len(h.frames)-maxTickFrames)
+		}
+	}
+
+	for _, b := range c.Bars {
+		i, found := slices.BinarySearchFunc(h.bars, b, func(x, y tradovate.Bar) int { return x.Timestamp.Compare(y.Timestamp) })
+		if found {
+			h.bars[i] = b // the bar's still forming
+		} else {
+			h.bars = slices.Insert(h.bars, i, b)
+		}
+	}
+
+	if len(h.bars) > maxBars {
+		h.bars = slices.Delete(h.bars, 0, len(h.bars)-maxBars)
+	}
+}
+
+// what a client subscribing to the route needs to catch up
+func (s *snapshot) messages(to route) []*Message {
+	switch to.kind {
+	case tradovate.SubKindQuote:
+		q, ok := s.quotes.Get(to.id)
+		if !ok {
+			return nil
+		}
+		return []*Message{{Quote: &q}}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch to.kind {
+	case tradovate.SubKindDOM:
+		if x, ok := s.doms[to.id]; ok {
+			return []*Message{{DOM: x}}
+		}
+	case tradovate.SubKindHistogram:
+		if x, ok := s.histograms[to.id]; ok {
+			return []*Message{{Histogram: x}}
+		}
+	case tradovate.SubKindChart:
+		h, ok := s.charts[to.id]
+		if !ok {
+			return nil
+		}
+
+		var x []*Message
+		if len(h.bars) > 0 {
+			c := *h.last
+			c.Bars, c.Ticks = slices.Clone(h.bars), nil
+			x = append(x, &Message{Chart: &c})
+		}
+
+		for _, v := range h.frames {
+			x = append(x, &Message{Chart: v})
+		}
+		return x
+	}
+
+	return nil
+}
+
+// drop what's kept for a feed nobody's subscribed to anymore, so a
+// later subscribe doesn't start from stale data
+func (s *snapshot) forget(to route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch to.kind {
+	case tradovate.SubKindQuote:
+		s.quotes.Forget(to.id)
+	case tradovate.SubKindDOM:
+		delete(s.doms, to.id)
+	case tradovate.SubKindHistogram:
+		delete(s.histograms, to.id)
+	case tradovate.SubKindChart:
+		delete(s.charts, to.id)
+	}
+}
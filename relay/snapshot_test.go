@@ -0,0 +1,32 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+func TestSnapshotChartCaps(t *testing.T) {
+	s := newSnapshot()
+	t0 := time.UnixMilli(1_700_000_000_000)
+
+	bars := make([]tradovate.Bar, maxBars+5)
+	for i := range bars {
+		bars[i] = tradovate.Bar{Timestamp: t0.Add(time.Duration(i) * time.Minute), Close: float64(i)}
+	}
+	s.chart(&tradovate.Chart{ID: 1, Bars: bars})
+
+	for range maxTickFrames + 5 {
+		s.chart(&tradovate.Chart{ID: 2, Ticks: []tradovate.Tick{{}}})
+	}
+
+	x := s.messages(route{tradovate.SubKindChart, 1})
+	if len(x) != 1 || len(x[0].Chart.Bars) != maxBars || x[0].Chart.Bars[0].Close != 5 {
+		t.Errorf("wanted the last %d bars, got %d messages", maxBars, len(x))
+	}
+
+	if x = s.messages(route{tradovate.SubKindChart, 2}); len(x) != maxTickFrames {
+		t.Errorf("wanted the last %d tick frames, got %d", maxTickFrames, len(x))
+	}
+}
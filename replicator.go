@@ -0,0 +1,673 @@
+package tradovate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrNotReplicated is wrapped by divergences for lead orders a follower
+// never got a copy of
+var ErrNotReplicated = errors.New("order not replicated")
+
+// Follower is an account a Replicator copies the lead's orders to
+type Follower struct {
+	Scope      *AccountScope
+	Multiplier float64 // of the lead's qty, rounded; defaults to 1
+	MaxQty     uint32  // caps the scaled qty; 0 for no cap
+}
+
+func (f *Follower) qty(lead uint32) uint32 {
+	m := f.Multiplier
+	if m == 0 {
+		m = 1
+	}
+
+	q := uint32(math.Round(float64(lead) * m))
+	if f.MaxQty != 0 && q > f.MaxQty {
+		q = f.MaxQty
+	}
+	return q
+}
+
+// Divergence is a follower that stopped mirroring the lead: a copy that
+// couldn't be placed, modified or canceled, or was rejected
+type Divergence struct {
+	At          time.Time
+	AccountID   int
+	LeadOrderID uint
+	OrderID     uint   // the follower's copy, if it got one
+	Op          string // place, modify or cancel
+	Err         error
+}
+
+type ReplicatorOpt func(*Replicator)
+
+// Called with every divergence as it happens, possibly from several
+// goroutines at once. Defaults to discarding them; Divergences has them
+// all either way
+func WithDivergenceHandler(fn func(Divergence)) ReplicatorOpt {
+	return func(r *Replicator) { r.handler = fn }
+}
+
+// How long to wait after an order shows up for the rest of its OCO or
+// OSO, since each arrives as its own entity. Defaults to 100ms
+func WithBracketWindow(d time.Duration) ReplicatorOpt {
+	return func(r *Replicator) { r.window = d }
+}
+
+// Replicator copies a lead account's orders to followers: new orders
+// are placed scaled to each follower, with OCOs and OSO brackets placed
+// as OCOs and OSOs; modifies and cancels follow. Feed it entity events
+// with Handle. Only orders created while it's running are copied, and
+// copies are automated orders
+type Replicator struct {
+	lead      *AccountScope
+	followers []Follower
+	window    time.Duration
+	handler   func(Divergence)
+
+	mu          sync.Mutex
+	orders      map[uint]*leadOrder
+	versions    map[uint]*OrderVersion // for orders not seen yet
+	copies      map[uint]*orderCopy    // follower order ID ->
+	groups      map[uint]*orderGroup   // by the group's first order, or the brackets' parent
+	byOco       map[uint]uint          // OCO ID -> group
+	symbols     map[uint]string
+	divergences []Divergence
+	unreported  []Divergence
+
+	// requests are sent after unlocking, so placements can be in flight
+	// when their copies' own order entities arrive
+	queued   []queuedReq
+	inflight int
+	early    map[uint]*Order // follower orders seen while placements are in flight
+}
+
+type queuedReq struct {
+	ctx      context.Context
+	follower int
+	send     func(context.Context)
+}
+
+type leadOrder struct {
+	*Order
+	version *OrderVersion
+	group   uint
+	placed  bool
+	copies  []*orderCopy
+}
+
+type orderCopy struct {
+	lead     uint
+	follower int
+	id       uint
+	status   OrderStatus
+}
+
+// an order and the orders it's placed with, waiting out the window
+type orderGroup struct {
+	ctx    context.Context // outlives the event that started the group
+	orders []uint
+	timer  *time.Timer
+}
+
+func NewReplicator(lead *AccountScope, followers []Follower, opts ...ReplicatorOpt) *Replicator {
+	r := &Replicator{
+		lead:      lead,
+		followers: followers,
+		window:    100 * time.Millisecond,
+		handler:   func(Divergence) {},
+		orders:    map[uint]*leadOrder{},
+		versions:  map[uint]*OrderVersion{},
+		copies:    map[uint]*orderCopy{},
+		groups:    map[uint]*orderGroup{},
+		byOco:     map[uint]uint{},
+		symbols:   map[uint]string{},
+		early:     map[uint]*Order{},
+	}
+
+	for _, v := range opts {
+		v(r)
+	}
+	return r
+}
+
+// Apply an Order or OrderVersion entity; anything else is ignored. The
+// lead's order entities start and cancel copies, its versions carry the
+// price and qty to place and modify them with, and the followers' order
+// entities report rejections.
+//
+// Handle returns once the follower requests it starts are answered. Each
+// follower's are sent in order on a goroutine of its own, so it waits on
+// the slowest follower rather than on every round trip in turn
+func (r *Replicator) Handle(ctx context.Context, e *EntityMsg) error {
+	switch e.Type {
+	case EntityTypeOrder:
+		x, err := e.Order()
+		if err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		defer r.unlock()
+		r.order(ctx, e.Event, x)
+	case EntityTypeOrderVersion:
+		x, err := e.OrderVersion()
+		if err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		defer r.unlock()
+		r.version(ctx, x)
+	}
+
+	return nil
+}
+
+// Copy orders still waiting out the bracket window now
+func (r *Replicator) Flush() {
+	r.mu.Lock()
+	defer r.unlock()
+
+	keys := make([]uint, 0, len(r.groups))
+	for k := range r.groups {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		r.groups[k].timer.Stop()
+		r.flush(k)
+	}
+}
+
+// Every divergence so far, oldest first
+func (r *Replicator) Divergences() []Divergence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.divergences)
+}
+
+// unlock, then report divergences found while locked and send the
+// requests queued, a goroutine per follower. Nothing's sent holding the
+// lock
+func (r *Replicator) unlock() {
+	x, reqs := r.unreported, r.queued
+	r.unreported, r.queued = nil, nil
+	r.mu.Unlock()
+
+	for _, v := range x {
+		r.handler(v)
+	}
+
+	byFollower := map[int][]queuedReq{}
+	for _, v := range reqs {
+		byFollower[v.follower] = append(byFollower[v.follower], v)
+	}
+
+	var wg sync.WaitGroup
+	for _, v := range byFollower {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, req := range v {
+				req.send(req.ctx)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// send to a follower after unlocking
+func (r *Replicator) queue(ctx context.Context, follower int, send func(context.Context)) {
+	r.queued = append(r.queued, queuedReq{ctx: ctx, follower: follower, send: send})
+}
+
+func (r *Replicator) order(ctx context.Context, event EventType, x *Order) {
+	if c, ok := r.copies[x.ID]; ok {
+		c.status = x.Status
+		if x.Status == OrderStatusRejected {
+			r.diverge(c.follower, c.lead, c.id, "place", errors.New("rejected"))
+		}
+		if finalStatus(x.Status) {
+			delete(r.copies, x.ID)
+		}
+		return
+	}
+
+	if x.AccountID != uint(r.lead.ID()) {
+		delete(r.versions, x.ID)
+		if r.inflight > 0 && r.follower(int(x.AccountID)) {
+			r.early[x.ID] = x
+		}
+		return
+	}
+
+	o, ok := r.orders[x.ID]
+	if !ok {
+		if event != EventTypeCreated || finalStatus(x.Status) && x.Status != OrderStatusFilled {
+			delete(r.versions, x.ID)
+			return
+		}
+
+		o = &leadOrder{Order: x, version: r.versions[x.ID]}
+		delete(r.versions, x.ID)
+		r.orders[x.ID] = o
+		r.join(ctx, o)
+		return
+	}
+
+	o.Order = x
+	if !o.placed || !finalStatus(x.Status) {
+		return
+	}
+
+	if canceled(x.Status) {
+		r.cancel(ctx, o)
+	}
+	delete(r.orders, x.ID)
+}
+
+func (r *Replicator) version(ctx context.Context, v *OrderVersion) {
+	if _, ok := r.copies[v.OrderID]; ok {
+		return
+	}
+
+	o, ok := r.orders[v.OrderID]
+	if !ok {
+		r.versions[v.OrderID] = v
+		return
+	}
+
+	prev := o.version
+	o.version = v
+	if !o.placed || prev == nil || prev.ID == v.ID {
+		return
+	}
+
+	for _, c := range o.copies {
+		r.modify(ctx, o, c)
+	}
+}
+
+func (r *Replicator) cancel(ctx context.Context, o *leadOrder) {
+	for _, c := range o.copies {
+		r.cancelCopy(ctx, o, c)
+	}
+}
+
+// bring a copy up to the lead order's version
+func (r *Replicator) modify(ctx context.Context, o *leadOrder, c *orderCopy) {
+	if finalStatus(c.status) {
+		return
+	}
+
+	v, f := o.version, &r.followers[c.follower]
+	req := &ModifyOrderReq{
+		OrderID:     c.id,
+		OrderQty:    f.qty(v.OrderQty),
+		OrderType:   v.OrderType,
+		Price:       v.Price,
+		StopPrice:   v.StopPrice,
+		TimeInForce: v.TimeInForce,
+		ExpireTime:  v.ExpireTime,
+		IsAutomated: true,
+	}
+
+	r.queue(ctx, c.follower, func(ctx context.Context) {
+		if _, err := f.Scope.ModifyOrder(ctx, req); err != nil {
+			r.mu.Lock()
+			defer r.unlock()
+			r.diverge(c.follower, o.ID, c.id, "modify", err)
+		}
+	})
+}
+
+func (r *Replicator) cancelCopy(ctx context.Context, o *leadOrder, c *orderCopy) {
+	if finalStatus(c.status) {
+		return
+	}
+
+	f := &r.followers[c.follower]
+	r.queue(ctx, c.follower, func(ctx context.Context) {
+		if _, err := f.Scope.CancelOrder(ctx, c.id); err != nil {
+			r.mu.Lock()
+			defer r.unlock()
+			r.diverge(c.follower, o.ID, c.id, "cancel", err)
+		}
+	})
+}
+
+// add the order to the group it's placed with, starting one if it's first
+func (r *Replicator) join(ctx context.Context, o *leadOrder) {
+	key := o.ID
+	switch {
+	case o.ParentID != 0:
+		key = o.ParentID
+	case o.OcoID != 0:
+		if k, ok := r.byOco[o.OcoID]; ok {
+			key = k
+		} else if other, ok := r.orders[o.OcoID]; ok {
+			key = other.group
+		}
+		r.byOco[o.OcoID] = key
+	}
+
+	o.group = key
+	if g, ok := r.groups[key]; ok {
+		g.orders = append(g.orders, o.ID)
+		return
+	}
+
+	// requests have the socket's timeout; the event's ctx is done by the
+	// time the window is
+	g := &orderGroup{ctx: context.WithoutCancel(ctx), orders: []uint{o.ID}}
+	g.timer = time.AfterFunc(r.window, func() {
+		r.mu.Lock()
+		defer r.unlock()
+
+		if r.groups[key] == g {
+			r.flush(key)
+		}
+	})
+	r.groups[key] = g
+}
+
+func (r *Replicator) flush(key uint) {
+	g := r.groups[key]
+	delete(r.groups, key)
+	for k, v := range r.byOco {
+		if v == key {
+			delete(r.byOco, k)
+		}
+	}
+
+	defer func() {
+		for _, id := range g.orders {
+			if o, ok := r.orders[id]; ok && finalStatus(o.Status) {
+				delete(r.orders, id) // filled before the window was up
+			}
+		}
+	}()
+
+	var root *leadOrder
+	var brackets, others []*leadOrder
+	entryCanceled := false
+	for _, id := range g.orders {
+		o := r.orders[id]
+		o.placed = true
+
+		switch {
+		case canceled(o.Status):
+			delete(r.orders, id)
+			entryCanceled = entryCanceled || id == key
+			continue
+		case o.version == nil:
+			r.divergeAll(o, fmt.Errorf("%w: no order version", ErrNotReplicated))
+			continue
+		}
+
+		switch {
+		case id == key:
+			root = o
+		case o.ParentID == key:
+			brackets = append(brackets, o)
+		default:
+			others = append(others, o)
+		}
+	}
+
+	switch {
+	case root == nil:
+		for _, v := range brackets {
+			if entryCanceled {
+				break // the server cancels them with it
+			}
+			r.divergeAll(v, fmt.Errorf("%w: bracket showed up after its entry was copied", ErrNotReplicated))
+		}
+		for _, v := range others {
+			r.place(g.ctx, v, nil, nil)
+		}
+	case len(others) == 0 && len(brackets) <= 2:
+		r.place(g.ctx, root, nil, brackets)
+	case len(others) == 1 && len(brackets) == 0:
+		r.place(g.ctx, root, others[0], nil)
+	default:
+		for _, v := range append(append([]*leadOrder{root}, others...), brackets...) {
+			r.divergeAll(v, fmt.Errorf("%w: can't place %d orders together", ErrNotReplicated, len(g.orders)))
+		}
+	}
+}
+
+// place a copy of o for every follower; as an OCO with other, or an OSO
+// with brackets. The requests are built now and sent after unlocking
+func (r *Replicator) place(ctx context.Context, o, other *leadOrder, brackets []*leadOrder) {
+	v := o.version
+	legs := append([]*leadOrder{o}, brackets...)
+	if other != nil {
+		legs = append(legs, other)
+	}
+
+	used := make([]*OrderVersion, len(legs))
+	for i, l := range legs {
+		used[i] = l.version
+	}
+
+	for i := range r.followers {
+		f := &r.followers[i]
+
+		qty := f.qty(v.OrderQty)
+		if qty == 0 {
+			r.diverge(i, o.ID, 0, "place", fmt.Errorf("%w: qty scaled to zero", ErrNotReplicated))
+			continue
+		}
+
+		var send func(ctx context.Context, symbol string) ([]uint, error)
+		switch {
+		case other != nil:
+			req := &OcoReq{
+				Action:      o.Action,
+				OrderQty:    uint(qty),
+				OrderType:   v.OrderType,
+				Price:       v.Price,
+				StopPrice:   v.StopPrice,
+				TimeInForce: v.TimeInForce,
+				ExpireTime:  v.ExpireTime,
+				IsAutomated: true,
+				Other:       otherOrder(other),
+			}
+			send = func(ctx context.Context, symbol string) ([]uint, error) {
+				req.Symbol = symbol
+				x, err := f.Scope.OCO(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				return []uint{x.OrderID, x.OcoID}, nil
+			}
+		case len(brackets) > 0:
+			req := &OsoReq{
+				Action:      o.Action,
+				OrderQty:    uint(qty),
+				OrderType:   v.OrderType,
+				Price:       v.Price,
+				StopPrice:   v.StopPrice,
+				TimeInForce: v.TimeInForce,
+				ExpireTime:  v.ExpireTime,
+				IsAutomated: true,
+				Bracket1:    otherOrder(brackets[0]),
+			}
+			if len(brackets) > 1 {
+				req.Bracket2 = otherOrder(brackets[1])
+			}
+			send = func(ctx context.Context, symbol string) ([]uint, error) {
+				req.Symbol = symbol
+				x, err := f.Scope.OSO(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				return []uint{x.OrderID, x.Oso1ID, x.Oso2ID}[:len(legs)], nil
+			}
+		default:
+			req := &OrderReq{
+				Action:      o.Action,
+				OrderQty:    qty,
+				OrderType:   v.OrderType,
+				Price:       v.Price,
+				StopPrice:   v.StopPrice,
+				TimeInForce: v.TimeInForce,
+				ExpireTime:  v.ExpireTime,
+				IsAutomated: true,
+			}
+			send = func(ctx context.Context, symbol string) ([]uint, error) {
+				req.Symbol = symbol
+				id, err := f.Scope.PlaceOrder(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				return []uint{id}, nil
+			}
+		}
+
+		r.inflight++
+		r.queue(ctx, i, func(ctx context.Context) {
+			symbol, err := r.symbol(ctx, o.ContractID)
+			if err != nil {
+				err = fmt.Errorf("%w: %w", ErrNotReplicated, err)
+			}
+
+			var ids []uint
+			if err == nil {
+				ids, err = send(ctx, symbol)
+			}
+
+			r.mu.Lock()
+			defer r.unlock()
+			r.placed(ctx, i, legs, used, ids, err)
+		})
+	}
+}
+
+// record the copies a placement made, catching them up with whatever
+// happened to the lead's orders while it was in flight
+func (r *Replicator) placed(ctx context.Context, follower int, legs []*leadOrder, used []*OrderVersion, ids []uint, err error) {
+	defer func() {
+		if r.inflight--; r.inflight == 0 {
+			clear(r.early)
+		}
+	}()
+
+	if err != nil {
+		r.diverge(follower, legs[0].ID, 0, "place", err)
+		return
+	}
+
+	for i, l := range legs {
+		c := r.copy(follower, l, ids[i])
+		if c == nil {
+			continue
+		}
+
+		switch {
+		case finalStatus(c.status):
+		case canceled(l.Status):
+			r.cancelCopy(ctx, l, c)
+		case l.version != used[i]:
+			r.modify(ctx, l, c)
+		}
+	}
+}
+
+func (r *Replicator) copy(follower int, o *leadOrder, id uint) *orderCopy {
+	if id == 0 {
+		return nil
+	}
+
+	c := &orderCopy{lead: o.ID, follower: follower, id: id}
+	o.copies = append(o.copies, c)
+
+	// its own entity may have beaten the placement back
+	if x, ok := r.early[id]; ok {
+		delete(r.early, id)
+		c.status = x.Status
+		if x.Status == OrderStatusRejected {
+			r.diverge(follower, o.ID, id, "place", errors.New("rejected"))
+		}
+		if finalStatus(x.Status) {
+			return c
+		}
+	}
+
+	r.copies[id] = c
+	return c
+}
+
+// the contract's symbol, fetched without holding the lock
+func (r *Replicator) symbol(ctx context.Context, contractID uint) (string, error) {
+	r.mu.Lock()
+	s, ok := r.symbols[contractID]
+	r.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	c, err := r.lead.WS().GetContract(ctx, int(contractID))
+	if err != nil {
+		return "", fmt.Errorf("failed getting contract %d: %w", contractID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.symbols[contractID] = c.Name
+	return c.Name, nil
+}
+
+func (r *Replicator) follower(accountID int) bool {
+	for _, v := range r.followers {
+		if v.Scope.ID() == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Replicator) diverge(follower int, leadOrderID, orderID uint, op string, err error) {
+	d := Divergence{
+		At:          time.Now(),
+		AccountID:   r.followers[follower].Scope.ID(),
+		LeadOrderID: leadOrderID,
+		OrderID:     orderID,
+		Op:          op,
+		Err:         err,
+	}
+
+	r.divergences = append(r.divergences, d)
+	r.unreported = append(r.unreported, d)
+}
+
+func (r *Replicator) divergeAll(o *leadOrder, err error) {
+	for i := range r.followers {
+		r.diverge(i, o.ID, 0, "place", err)
+	}
+}
+
+func otherOrder(o *leadOrder) *OtherOrder {
+	return &OtherOrder{
+		Action:      o.Action,
+		OrderType:   o.version.OrderType,
+		Price:       o.version.Price,
+		StopPrice:   o.version.StopPrice,
+		TimeInForce: o.version.TimeInForce,
+		ExpireTime:  o.version.ExpireTime,
+	}
+}
+
+// done without filling
+func canceled(s OrderStatus) bool {
+	return s == OrderStatusCanceled || s == OrderStatusExpired || s == OrderStatusRejected
+}
@@ -0,0 +1,275 @@
+package tradovate_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestReplicator(t *testing.T) {
+	type placed struct {
+		AccountID uint    `json:"accountId"`
+		OrderID   uint    `json:"orderId"`
+		OrderQty  uint32  `json:"orderQty"`
+		Price     float64 `json:"price"`
+	}
+
+	srv := tradovatetest.NewServer(
+		tradovatetest.WithHandler("order/placeorder", func(r *tradovatetest.Request) tradovatetest.Response {
+			var x placed
+			r.Decode(&x)
+			if x.AccountID == 3 {
+				return tradovatetest.Response{Status: http.StatusOK, Body: map[string]any{"failureReason": "TradingLocked"}}
+			}
+			return tradovatetest.Response{Status: http.StatusOK, Body: map[string]any{"orderId": 100 + x.AccountID}}
+		}),
+		tradovatetest.WithHandler("order/placeoso", func(r *tradovatetest.Request) tradovatetest.Response {
+			var x placed
+			r.Decode(&x)
+			id := 200 + 10*x.AccountID
+			return tradovatetest.Response{Status: http.StatusOK, Body: map[string]any{"orderId": id, "osoId1": id + 1, "osoId2": id + 2}}
+		}),
+	)
+	defer srv.Close()
+
+	srv.SetAccounts(&tradovate.Account{ID: 1, Name: "LEAD"}, &tradovate.Account{ID: 2, Name: "F2"}, &tradovate.Account{ID: 3, Name: "F3"})
+	srv.SetContracts(&tradovate.Contract{ID: 5, Name: "ESZ5"})
+	srv.Respond("order/modifyorder", http.StatusOK, map[string]any{"commandId": 1})
+	srv.Respond("order/cancelorder", http.StatusOK, map[string]any{"commandId": 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	var scopes []*tradovate.AccountScope
+	for _, v := range []string{"LEAD", "F2", "F3"} {
+		x, err := tradovate.NewAccountScope(ctx, ws, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scopes = append(scopes, x)
+	}
+
+	var (
+		mu       sync.Mutex
+		reported []tradovate.Divergence
+	)
+	r := tradovate.NewReplicator(scopes[0], []tradovate.Follower{
+		{Scope: scopes[1], Multiplier: 2, MaxQty: 3},
+		{Scope: scopes[2]},
+	},
+		tradovate.WithBracketWindow(time.Hour),
+		tradovate.WithDivergenceHandler(func(d tradovate.Divergence) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, d)
+		}),
+	)
+
+	handle := func(kind tradovate.EntityType, event tradovate.EventType, v any) {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = r.Handle(ctx, &tradovate.EntityMsg{Type: kind, Event: event, Data: buf}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// followers are sent to concurrently, so by account
+	sent := func(path string) []placed {
+		var x []placed
+		for _, v := range srv.RequestsFor(path) {
+			var p placed
+			if err := v.Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			x = append(x, p)
+		}
+
+		slices.SortStableFunc(x, func(a, b placed) int { return int(a.AccountID) - int(b.AccountID) })
+		return x
+	}
+
+	// a limit order, its version showing up first
+	handle(tradovate.EntityTypeOrderVersion, tradovate.EventTypeCreated, &tradovate.OrderVersion{ID: 1, OrderID: 10, OrderQty: 2, OrderType: tradovate.OrderTypeLimit, Price: 5000})
+	handle(tradovate.EntityTypeOrder, tradovate.EventTypeCreated, &tradovate.Order{ID: 10, AccountID: 1, ContractID: 5, Action: tradovate.ActionBuy, Status: tradovate.OrderStatusWorking})
+	// an order from before, not copied
+	handle(tradovate.EntityTypeOrder, tradovate.EventTypeUpdated, &tradovate.Order{ID: 9, AccountID: 1, ContractID: 5, Action: tradovate.ActionBuy, Status: tradovate.OrderStatusWorking})
+	r.Flush()
+
+	want := []placed{{AccountID: 2, OrderQty: 3, Price: 5000}, {AccountID: 3, OrderQty: 2, Price: 5000}}
+	if got := sent("order/placeorder"); !reflect.DeepEqual(want, got) {
+		t.Errorf("wrong orders placed: want %+v got %+v", want, got)
+	}
+
+	handle(tradovate.EntityTypeOrderVersion, tradovate.EventTypeCreated, &tradovate.OrderVersion{ID: 2, OrderID: 10, OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: 4999})
+	if got, want := sent("order/modifyorder"), []placed{{OrderID: 102, OrderQty: 2, Price: 4999}}; !reflect.DeepEqual(want, got) {
+		t.Errorf("wrong modifies: want %+v got %+v", want, got)
+	}
+
+	handle(tradovate.EntityTypeOrder, tradovate.EventTypeUpdated, &tradovate.Order{ID: 10, AccountID: 1, ContractID: 5, Status: tradovate.OrderStatusCanceled})
+	if got, want := sent("order/cancelorder"), []placed{{OrderID: 102}}; !reflect.DeepEqual(want, got) {
+		t.Errorf("wrong cancels: want %+v got %+v", want, got)
+	}
+
+	// an OSO, the brackets showing up before the entry
+	for _, v := range []*tradovate.Order{
+		{ID: 21, AccountID: 1, ContractID: 5, Action: tradovate.ActionSell, ParentID: 20, OcoID: 1, Status: tradovate.OrderStatusSuspended},
+		{ID: 22, AccountID: 1, ContractID: 5, Action: tradovate.ActionSell, ParentID: 20, OcoID: 1, Status: tradovate.OrderStatusSuspended},
+		{ID: 20, AccountID: 1, ContractID: 5, Action: tradovate.ActionBuy, Status: tradovate.OrderStatusFilled},
+	} {
+		handle(tradovate.EntityTypeOrder, tradovate.EventTypeCreated, v)
+		handle(tradovate.EntityTypeOrderVersion, tradovate.EventTypeCreated, &tradovate.OrderVersion{ID: v.ID, OrderID: v.ID, OrderQty: 1, OrderType: tradovate.OrderTypeLimit, Price: float64(5000 + v.ID)})
+	}
+	r.Flush()
+
+	want = []placed{{AccountID: 2, OrderQty: 2, Price: 5020}, {AccountID: 3, OrderQty: 1, Price: 5020}}
+	if got := sent("order/placeoso"); !reflect.DeepEqual(want, got) {
+		t.Errorf("wrong OSOs placed: want %+v got %+v", want, got)
+	}
+
+	var oso struct {
+		Bracket1, Bracket2 *tradovate.OtherOrder
+	}
+	if err = srv.RequestsFor("order/placeoso")[0].Decode(&oso); err != nil {
+		t.Fatal(err)
+	}
+
+	if oso.Bracket1 == nil || oso.Bracket1.Price != 5021 || oso.Bracket2 == nil || oso.Bracket2.Price != 5022 {
+		t.Errorf("both brackets should be copied, got %+v %+v", oso.Bracket1, oso.Bracket2)
+	}
+
+	// the follower's stop is rejected, and a bracket shows up too late
+	handle(tradovate.EntityTypeOrder, tradovate.EventTypeUpdated, &tradovate.Order{ID: 232, AccountID: 3, Status: tradovate.OrderStatusRejected})
+	handle(tradovate.EntityTypeOrder, tradovate.EventTypeCreated, &tradovate.Order{ID: 23, AccountID: 1, ContractID: 5, ParentID: 20, Status: tradovate.OrderStatusSuspended})
+	handle(tradovate.EntityTypeOrderVersion, tradovate.EventTypeCreated, &tradovate.OrderVersion{ID: 23, OrderID: 23, OrderQty: 1, OrderType: tradovate.OrderTypeStop, StopPrice: 4990})
+	r.Flush()
+
+	type divergence struct {
+		AccountID           int
+		LeadOrderID, Copied uint
+		Op                  string
+		NotReplicated       bool
+	}
+
+	var got []divergence
+	for _, v := range r.Divergences() {
+		got = append(got, divergence{v.AccountID, v.LeadOrderID, v.OrderID, v.Op, errors.Is(v.Err, tradovate.ErrNotReplicated)})
+	}
+
+	slices.SortStableFunc(got, func(a, b divergence) int {
+		if a.LeadOrderID != b.LeadOrderID {
+			return int(a.LeadOrderID) - int(b.LeadOrderID)
+		}
+		return a.AccountID - b.AccountID
+	})
+
+	wantDivergences := []divergence{
+		{AccountID: 3, LeadOrderID: 10, Op: "place"},
+		{AccountID: 3, LeadOrderID: 22, Copied: 232, Op: "place"},
+		{AccountID: 2, LeadOrderID: 23, Op: "place", NotReplicated: true},
+		{AccountID: 3, LeadOrderID: 23, Op: "place", NotReplicated: true},
+	}
+	if !reflect.DeepEqual(wantDivergences, got) {
+		t.Errorf("wrong divergences: want %+v got %+v", wantDivergences, got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != len(got) {
+		t.Errorf("every divergence should be reported, got %d of %d", len(reported), len(got))
+	}
+}
+
+func TestReplicatorInFlight(t *testing.T) {
+	var (
+		r      *tradovate.Replicator
+		handle func(tradovate.EntityType, tradovate.EventType, any)
+	)
+
+	srv := tradovatetest.NewServer(tradovatetest.WithHandler("order/placeorder", func(*tradovatetest.Request) tradovatetest.Response {
+		// the lead cancels while the copy's being placed, and the copy's
+		// own entity comes back before the response does
+		handle(tradovate.EntityTypeOrder, tradovate.EventTypeUpdated, &tradovate.Order{ID: 10, AccountID: 1, ContractID: 5, Status: tradovate.OrderStatusCanceled})
+		handle(tradovate.EntityTypeOrder, tradovate.EventTypeCreated, &tradovate.Order{ID: 100, AccountID: 2, ContractID: 5, Status: tradovate.OrderStatusWorking})
+		return tradovatetest.Response{Status: http.StatusOK, Body: map[string]any{"orderId": 100}}
+	}))
+	defer srv.Close()
+
+	srv.SetAccounts(&tradovate.Account{ID: 1, Name: "LEAD"}, &tradovate.Account{ID: 2, Name: "F2"})
+	srv.SetContracts(&tradovate.Contract{ID: 5, Name: "ESZ5"})
+	srv.Respond("order/cancelorder", http.StatusOK, map[string]any{"commandId": 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	lead, err := tradovate.NewAccountScope(ctx, ws, "LEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower, err := tradovate.NewAccountScope(ctx, ws, "F2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r = tradovate.NewReplicator(lead, []tradovate.Follower{{Scope: follower}}, tradovate.WithBracketWindow(time.Hour))
+
+	// the event's ctx is done long before the window is
+	eventCtx, eventCancel := context.WithCancel(ctx)
+	handle = func(kind tradovate.EntityType, event tradovate.EventType, v any) {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err = r.Handle(eventCtx, &tradovate.EntityMsg{Type: kind, Event: event, Data: buf}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	handle(tradovate.EntityTypeOrderVersion, tradovate.EventTypeCreated, &tradovate.OrderVersion{ID: 1, OrderID: 10, OrderQty: 1, OrderType: tradovate.OrderTypeMarket})
+	handle(tradovate.EntityTypeOrder, tradovate.EventTypeCreated, &tradovate.Order{ID: 10, AccountID: 1, ContractID: 5, Action: tradovate.ActionBuy, Status: tradovate.OrderStatusWorking})
+	eventCancel()
+	r.Flush()
+
+	if d := r.Divergences(); len(d) != 0 {
+		t.Fatalf("the copy should be placed, got %+v", d)
+	}
+
+	var canceled struct {
+		OrderID uint `json:"orderId"`
+	}
+
+	reqs := srv.RequestsFor("order/cancelorder")
+	if len(reqs) != 1 {
+		t.Fatalf("the copy should be canceled once placed, got %d cancels", len(reqs))
+	}
+
+	if err = reqs[0].Decode(&canceled); err != nil || canceled.OrderID != 100 {
+		t.Errorf("wrong order canceled: %+v (err %v)", canceled, err)
+	}
+}
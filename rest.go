@@ -5,7 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,6 +23,19 @@ type REST struct {
 	tokenManager
 	baseURL string
 	h       *http.Client
+	log     *slog.Logger
+	metrics Metrics
+}
+
+type RESTOpt func(r *REST)
+
+// Log requests and token fetches. Defaults to discarding everything
+func WithRESTLogger(l *slog.Logger) RESTOpt {
+	return func(r *REST) {
+		if l != nil {
+			r.log = l
+		}
+	}
 }
 
 // Credentials for getting a token
@@ -36,19 +52,47 @@ type Creds struct {
 	Secret   uuid.UUID `json:"sec"`
 }
 
-func NewREST(baseURL string, h *http.Client, o *Creds) *REST {
-	return &REST{
+// Keeps the password and secret out of logs
+func (c *Creds) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("name", c.Name),
+		slog.String("appId", c.AppID),
+		slog.String("cid", c.ClientID),
+	)
+}
+
+func NewREST(baseURL string, h *http.Client, o *Creds, opts ...RESTOpt) *REST {
+	r := &REST{
 		tokenManager: tokenManager{creds: o},
 		baseURL:      baseURL,
 		h:            h,
+		log:          discardLogger,
+		metrics:      nopMetrics{},
 	}
+
+	for _, v := range opts {
+		v(r)
+	}
+
+	return r
 }
 
-func (r *REST) do(ctx context.Context, method, path string, reqBody, target any) error {
+func (r *REST) do(ctx context.Context, method, path string, reqBody, target any) (err error) {
+	start, status := time.Now(), 0
+	defer func() {
+		logRequest(ctx, r.log, err, "rest request",
+			slog.String("method", method),
+			slog.String("path", path),
+			slog.Int("status", status),
+			since(start),
+		)
+	}()
+
 	buf, err := json.Marshal(reqBody)
 	if err != nil {
 		return err
 	}
+	logPayload(ctx, r.log, "rest request body", buf, slog.String("path", path))
 
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -74,12 +118,19 @@ func (r *REST) do(ctx context.Context, method, path string, reqBody, target any)
 	if err != nil {
 		return err
 	}
+	status = resp.StatusCode
 
 	if resp.StatusCode >= 300 {
 		return newRespErrFromREST(resp)
 	}
+	defer resp.Body.Close()
+
+	if buf, err = io.ReadAll(resp.Body); err != nil {
+		return fmt.Errorf("failed reading resp: %w", err)
+	}
+	logPayload(ctx, r.log, "rest response body", buf, slog.String("path", path))
 
-	if err = json.NewDecoder(resp.Body).Decode(target); err != nil {
+	if err = json.Unmarshal(buf, target); err != nil {
 		return fmt.Errorf("failed decoding resp: %w", err)
 	}
 
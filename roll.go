@@ -0,0 +1,477 @@
+package tradovate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RollState is what a RollRule decides on
+type RollState struct {
+	Now     time.Time
+	Current *ContractMaturity
+	Next    *ContractMaturity
+
+	// latest quotes for each, nil unless the Roller was given a QuoteBook
+	// and has seen one
+	CurrentQuote *Quote
+	NextQuote    *Quote
+}
+
+// RollRule reports whether it's time to roll from Current to Next.
+// Expired contracts always roll, whatever the rule says
+type RollRule func(s *RollState) bool
+
+// Roll n days before the current contract expires
+func RollDaysBefore(n int) RollRule {
+	return func(s *RollState) bool {
+		return !s.Now.Before(s.Current.ExpirationDate.AddDate(0, 0, -n))
+	}
+}
+
+// Roll once the next contract trades more volume than the current one
+func RollOnVolume() RollRule {
+	return func(s *RollState) bool {
+		return s.CurrentQuote != nil && s.NextQuote != nil &&
+			s.NextQuote.TotalTradeVolume > s.CurrentQuote.TotalTradeVolume
+	}
+}
+
+// Roll once the next contract has more open interest than the current one
+func RollOnOpenInterest() RollRule {
+	return func(s *RollState) bool {
+		return s.CurrentQuote != nil && s.NextQuote != nil &&
+			s.NextQuote.OpenInterest > s.CurrentQuote.OpenInterest
+	}
+}
+
+// Roll as soon as any of the rules says to
+func RollOnAny(rules ...RollRule) RollRule {
+	return func(s *RollState) bool {
+		return slices.ContainsFunc(rules, func(r RollRule) bool { return r(s) })
+	}
+}
+
+// RollEvent is sent to the roll handler after subscriptions have moved
+type RollEvent struct {
+	Root   string
+	From   *Contract
+	To     *Contract
+	At     time.Time
+	Charts []ChartRoll // followed charts, which get new IDs on the new contract
+}
+
+type ChartRoll struct {
+	Req  *ChartReq
+	From ChartResp
+	To   ChartResp
+}
+
+type RollOpt func(r *Roller)
+
+// When to roll. Defaults to RollDaysBefore(0), rolling at expiry
+func WithRollRule(x RollRule) RollOpt {
+	return func(r *Roller) { r.rule = x }
+}
+
+// Called after each roll, once subscriptions have moved
+func WithRollHandler(fn func(RollEvent)) RollOpt {
+	return func(r *Roller) { r.onRoll = fn }
+}
+
+// Quotes for the volume and open interest rules. The roller keeps quote
+// subscriptions on the current and next contracts so the book has them;
+// you still need to feed it from the market data handler
+func WithRollQuotes(b *QuoteBook) RollOpt {
+	return func(r *Roller) { r.book = b }
+}
+
+// How often Run checks whether to roll. Defaults to 1m
+func WithRollCheckEvery(d time.Duration) RollOpt {
+	return func(r *Roller) { r.every = d }
+}
+
+// Roller tracks the front month of a product, e.g. NQH5 for NQ, and
+// moves the subscriptions it follows to the next contract when it rolls
+type Roller struct {
+	m      *SubscriptionManager
+	root   string
+	rule   RollRule
+	onRoll func(RollEvent)
+	book   *QuoteBook
+	every  time.Duration
+
+	// ops is one check, follow or close at a time, held across their round
+	// trips; mu only guards current, so Current never waits on the server
+	ops        sync.Mutex
+	mu         sync.Mutex
+	product    *Product
+	maturities []*ContractMaturity // unexpired, soonest first; the first is current
+	current    *Contract
+	next       *Contract // nil if nothing's listed after current
+	follows    []*follow
+	watching   []Release // quotes for the rules
+}
+
+type follow struct {
+	kind    SubKind
+	chart   *ChartReq
+	resp    ChartResp
+	release Release
+}
+
+// Resolve the front month of root according to the roll rule
+func NewRoller(ctx context.Context, m *SubscriptionManager, root string, opts ...RollOpt) (*Roller, error) {
+	r := &Roller{
+		m:      m,
+		root:   root,
+		rule:   RollDaysBefore(0),
+		onRoll: func(RollEvent) {},
+		every:  time.Minute,
+	}
+
+	for _, v := range opts {
+		v(r)
+	}
+
+	p, err := m.s.FindProduct(ctx, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed finding product %s: %w", root, err)
+	}
+	r.product = p
+
+	if r.maturities, err = r.load(ctx); err != nil {
+		return nil, err
+	}
+
+	// skip whatever the rule would've already rolled past
+	for len(r.maturities) > 1 && r.rule(r.state(nil, nil)) {
+		r.maturities = r.maturities[1:]
+	}
+
+	if err = r.resolve(ctx); err != nil {
+		return nil, err
+	}
+
+	if err = r.watch(ctx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// The front month contract
+func (r *Roller) Current() *Contract {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Keep a quote, DOM or histogram subscription on the front month
+func (r *Roller) Follow(ctx context.Context, kind SubKind) error {
+	r.ops.Lock()
+	defer r.ops.Unlock()
+
+	release, err := r.m.AcquireID(ctx, kind, r.current.ID)
+	if err != nil {
+		return err
+	}
+
+	r.follows = append(r.follows, &follow{kind: kind, release: release})
+	return nil
+}
+
+// Keep a chart subscription on the front month. Its IDs change on each
+// roll; the new ones are in the RollEvent
+func (r *Roller) FollowChart(ctx context.Context, req *ChartReq) (ChartResp, error) {
+	r.ops.Lock()
+	defer r.ops.Unlock()
+
+	resp, release, err := r.m.AcquireChartID(ctx, r.current.ID, req)
+	if err != nil {
+		return ChartResp{}, err
+	}
+
+	r.follows = append(r.follows, &follow{kind: SubKindChart, chart: req, resp: resp, release: release})
+	return resp, nil
+}
+
+// Roll if the current contract expired or the rule says to, reporting
+// whether it did
+func (r *Roller) Check(ctx context.Context) (bool, error) {
+	r.ops.Lock()
+
+	if r.next == nil { // maybe it's listed by now
+		if err := r.listNext(ctx); err != nil {
+			r.ops.Unlock()
+			return false, err
+		}
+	}
+
+	if len(r.maturities) < 2 {
+		r.ops.Unlock()
+		return false, nil
+	}
+
+	var cur, next *Quote
+	if r.book != nil {
+		if q, ok := r.book.Get(r.current.ID); ok {
+			cur = &q
+		}
+
+		if q, ok := r.book.Get(r.next.ID); ok {
+			next = &q
+		}
+	}
+
+	s := r.state(cur, next)
+	if s.Now.Before(s.Current.ExpirationDate) && !r.rule(s) {
+		r.ops.Unlock()
+		return false, nil
+	}
+
+	e, err := r.roll(ctx)
+	r.ops.Unlock()
+	if err != nil {
+		return false, err
+	}
+
+	r.onRoll(e)
+	return true, nil
+}
+
+// Check every interval until ctx is done. Failed checks are logged and
+// retried on the next one
+func (r *Roller) Run(ctx context.Context) error {
+	t := time.NewTicker(r.every)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		if _, err := r.Check(ctx); err != nil {
+			r.m.s.log.LogAttrs(ctx, slog.LevelWarn, "roll check failed", slog.String("root", r.root), slog.Any("err", err))
+		}
+	}
+}
+
+// Release every subscription the roller holds
+func (r *Roller) Close(ctx context.Context) error {
+	r.ops.Lock()
+	defer r.ops.Unlock()
+
+	var errs []error
+	for _, v := range r.follows {
+		errs = append(errs, v.release(ctx))
+	}
+	r.follows = nil
+
+	errs = append(errs, r.unwatch(ctx))
+	return errors.Join(errs...)
+}
+
+// move everything to the next contract; r.ops held
+func (r *Roller) roll(ctx context.Context) (RollEvent, error) {
+	from, to := r.current, r.next
+
+	// subscribe on the new contract before letting go of the old one, so
+	// there's no gap in the feed
+	moved := make([]*follow, len(r.follows))
+	for i, v := range r.follows {
+		f := &follow{kind: v.kind, chart: v.chart}
+
+		var err error
+		if v.kind == SubKindChart {
+			f.resp, f.release, err = r.m.AcquireChartID(ctx, to.ID, v.chart)
+		} else {
+			f.release, err = r.m.AcquireID(ctx, v.kind, to.ID)
+		}
+
+		if err != nil {
+			for _, x := range moved[:i] {
+				x.release(ctx)
+			}
+			return RollEvent{}, fmt.Errorf("failed moving %s subscription to %s: %w", v.kind, to.Name, err)
+		}
+
+		moved[i] = f
+	}
+
+	e := RollEvent{Root: r.root, From: from, To: to, At: time.Now()}
+	for i, v := range r.follows {
+		if err := v.release(ctx); err != nil {
+			r.m.s.log.LogAttrs(ctx, slog.LevelWarn, "failed releasing old contract's subscription",
+				slog.String("contract", from.Name),
+				slog.String("kind", v.kind.String()),
+				slog.Any("err", err),
+			)
+		}
+
+		if v.kind == SubKindChart {
+			e.Charts = append(e.Charts, ChartRoll{Req: v.chart, From: v.resp, To: moved[i].resp})
+		}
+	}
+	r.follows = moved
+
+	if err := r.unwatch(ctx); err != nil {
+		r.m.s.log.LogAttrs(ctx, slog.LevelWarn, "failed releasing roll quotes", slog.Any("err", err))
+	}
+
+	r.maturities = r.maturities[1:]
+	r.mu.Lock()
+	r.current, r.next = to, nil
+	r.mu.Unlock()
+	if len(r.maturities) > 1 { // otherwise the next check looks again
+		next, err := r.contract(ctx, r.maturities[1])
+		if err != nil {
+			r.m.s.log.LogAttrs(ctx, slog.LevelWarn, "failed finding the next contract", slog.Any("err", err))
+		}
+		r.next = next
+	}
+
+	if err := r.watch(ctx); err != nil {
+		r.m.s.log.LogAttrs(ctx, slog.LevelWarn, "failed watching quotes for the roll rule", slog.Any("err", err))
+	}
+
+	r.m.s.log.LogAttrs(ctx, slog.LevelInfo, "rolled contract",
+		slog.String("root", r.root),
+		slog.String("from", from.Name),
+		slog.String("to", to.Name),
+	)
+	return e, nil
+}
+
+// the product's unexpired maturities, soonest first
+func (r *Roller) load(ctx context.Context) ([]*ContractMaturity, error) {
+	x, err := r.m.s.ListContractMaturities(ctx, r.product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing %s maturities: %w", r.root, err)
+	}
+
+	now := time.Now()
+	x = slices.DeleteFunc(x, func(m *ContractMaturity) bool {
+		return m.Archived || !m.ExpirationDate.After(now)
+	})
+
+	// never go back to what's been rolled past
+	if r.current != nil && len(r.maturities) > 0 {
+		cur := r.maturities[0]
+		x = slices.DeleteFunc(x, func(m *ContractMaturity) bool { return m.ExpirationDate.Before(cur.ExpirationDate) })
+	}
+
+	if len(x) == 0 {
+		return nil, fmt.Errorf("no unexpired maturities listed for %s", r.root)
+	}
+
+	slices.SortFunc(x, func(a, b *ContractMaturity) int { return a.ExpirationDate.Compare(b.ExpirationDate) })
+	return x, nil
+}
+
+// reload maturities, picking up the next contract if it's been listed.
+// Current stays first even if it's expired and dropped off the list, so
+// the check rolls it like any other expiry
+func (r *Roller) listNext(ctx context.Context) error {
+	x, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	if cur := r.maturities[0]; x[0].ID != cur.ID {
+		x = append([]*ContractMaturity{cur}, x...)
+	}
+
+	if len(x) < 2 {
+		return nil
+	}
+
+	next, err := r.contract(ctx, x[1])
+	if err != nil {
+		return err
+	}
+	r.maturities, r.next = x, next
+
+	if err = r.unwatch(ctx); err != nil {
+		return err
+	}
+	return r.watch(ctx)
+}
+
+// look up the contracts for the first two maturities
+func (r *Roller) resolve(ctx context.Context) error {
+	c, err := r.contract(ctx, r.maturities[0])
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.current, r.next = c, nil
+	r.mu.Unlock()
+
+	if len(r.maturities) > 1 {
+		if r.next, err = r.contract(ctx, r.maturities[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Roller) contract(ctx context.Context, m *ContractMaturity) (*Contract, error) {
+	x, err := r.m.s.ListMaturityContracts(ctx, m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing contracts for %s %d: %w", r.root, m.ExpirationMonth, err)
+	}
+
+	if len(x) == 0 {
+		return nil, fmt.Errorf("no contract listed for %s %d", r.root, m.ExpirationMonth)
+	}
+
+	return x[0], nil
+}
+
+func (r *Roller) state(cur, next *Quote) *RollState {
+	s := &RollState{Now: time.Now(), Current: r.maturities[0], CurrentQuote: cur, NextQuote: next}
+	if len(r.maturities) > 1 {
+		s.Next = r.maturities[1]
+	}
+	return s
+}
+
+// subscribe to quotes on current and next for the rule
+func (r *Roller) watch(ctx context.Context) error {
+	if r.book == nil {
+		return nil
+	}
+
+	for _, c := range []*Contract{r.current, r.next} {
+		if c == nil {
+			continue
+		}
+
+		release, err := r.m.AcquireID(ctx, SubKindQuote, c.ID)
+		if err != nil {
+			return err
+		}
+		r.watching = append(r.watching, release)
+	}
+
+	return nil
+}
+
+func (r *Roller) unwatch(ctx context.Context) error {
+	var errs []error
+	for _, release := range r.watching {
+		errs = append(errs, release(ctx))
+	}
+
+	r.watching = nil
+	return errors.Join(errs...)
+}
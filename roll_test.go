@@ -0,0 +1,223 @@
+package tradovate_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func newRollServer() *tradovatetest.Server {
+	now := time.Now()
+	contracts := map[string][]*tradovate.Contract{
+		"100": {{ID: 1, Name: "NQH5", ContractMaturityID: 100}},
+		"101": {{ID: 2, Name: "NQM5", ContractMaturityID: 101}},
+	}
+
+	return tradovatetest.NewServer(
+		tradovatetest.WithHandler("product/find", func(*tradovatetest.Request) tradovatetest.Response {
+			return tradovatetest.Response{Status: 200, Body: &tradovate.Product{ID: 10, Name: "NQ"}}
+		}),
+		tradovatetest.WithHandler("contractMaturity/deps", func(*tradovatetest.Request) tradovatetest.Response {
+			return tradovatetest.Response{Status: 200, Body: []*tradovate.ContractMaturity{
+				{ID: 101, ProductID: 10, ExpirationMonth: 202506, ExpirationDate: now.AddDate(0, 0, 90)},
+				{ID: 99, ProductID: 10, ExpirationMonth: 202412, ExpirationDate: now.AddDate(0, 0, -10), Archived: true},
+				{ID: 100, ProductID: 10, ExpirationMonth: 202503, ExpirationDate: now.AddDate(0, 0, 3)},
+			}}
+		}),
+		tradovatetest.WithHandler("contract/deps", func(r *tradovatetest.Request) tradovatetest.Response {
+			return tradovatetest.Response{Status: 200, Body: contracts[r.Query.Get("masterid")]}
+		}),
+	)
+}
+
+func TestRollerResolve(mainTest *testing.T) {
+	srv := newRollServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		mainTest.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	testCases := []struct {
+		name     string
+		rule     tradovate.RollRule
+		expected string
+	}{
+		{name: "front month", rule: tradovate.RollDaysBefore(0), expected: "NQH5"},
+		{name: "inside the roll window", rule: tradovate.RollDaysBefore(5), expected: "NQM5"},
+		{name: "crossover without quotes", rule: tradovate.RollOnVolume(), expected: "NQH5"},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			r, err := tradovate.NewRoller(ctx, tradovate.NewSubscriptionManager(ws), "NQ", tradovate.WithRollRule(tc.rule))
+			if err != nil {
+				tt.Fatalf("failed resolving: %v", err)
+			}
+
+			if got := r.Current().Name; got != tc.expected {
+				tt.Errorf("wrong front month: want %s got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRollerMovesSubscriptions(t *testing.T) {
+	srv := newRollServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	book := tradovate.NewQuoteBook()
+	events := make(chan tradovate.RollEvent, 1)
+	r, err := tradovate.NewRoller(ctx, tradovate.NewSubscriptionManager(ws), "NQ",
+		tradovate.WithRollRule(tradovate.RollOnVolume()),
+		tradovate.WithRollQuotes(book),
+		tradovate.WithRollHandler(func(e tradovate.RollEvent) { events <- e }),
+	)
+	if err != nil {
+		t.Fatalf("failed resolving: %v", err)
+	}
+
+	if err = r.Follow(ctx, tradovate.SubKindDOM); err != nil {
+		t.Fatalf("failed following DOM: %v", err)
+	}
+
+	chart, err := r.FollowChart(ctx, &tradovate.ChartReq{UnderlyingType: tradovate.ChartTypeTick, AsMuchAsElements: 1})
+	if err != nil {
+		t.Fatalf("failed following chart: %v", err)
+	}
+
+	volume := func(id int, v float64) {
+		book.Update(&tradovate.Quote{ContractID: id, Timestamp: time.Now(), TotalTradeVolume: v, Entries: tradovate.QuoteEntryTotalTradeVolume})
+	}
+
+	volume(1, 100)
+	volume(2, 50)
+	if rolled, err := r.Check(ctx); rolled || err != nil {
+		t.Fatalf("shouldn't roll before the crossover, got %v %v", rolled, err)
+	}
+
+	volume(2, 150)
+	if rolled, err := r.Check(ctx); !rolled || err != nil {
+		t.Fatalf("should roll after the crossover, got %v %v", rolled, err)
+	}
+
+	e := <-events
+	if e.From.Name != "NQH5" || e.To.Name != "NQM5" || r.Current().Name != "NQM5" {
+		t.Errorf("wrong roll: %s -> %s, current %s", e.From.Name, e.To.Name, r.Current().Name)
+	}
+
+	if len(e.Charts) != 1 || e.Charts[0].From != chart || e.Charts[0].To == chart {
+		t.Errorf("chart should move with new IDs, got %+v", e.Charts)
+	}
+
+	want := map[tradovatetest.Subscription]bool{
+		{Kind: "dom", Symbol: "2"}:   true,
+		{Kind: "quote", Symbol: "2"}: true, // watching for the rule
+		{Kind: "chart", Symbol: "2"}: true,
+	}
+
+	subs := srv.Subscriptions()
+	for _, v := range subs {
+		if !want[v] {
+			t.Errorf("unexpected subscription left after rolling: %+v", v)
+		}
+	}
+
+	if len(subs) != len(want) {
+		t.Errorf("wanted %d subscriptions, got %+v", len(want), subs)
+	}
+
+	if err = r.Close(ctx); err != nil {
+		t.Fatalf("failed closing: %v", err)
+	}
+
+	if subs = srv.Subscriptions(); len(subs) != 0 {
+		t.Errorf("close should release everything, got %+v", subs)
+	}
+}
+
+func TestRollerExpiresBeforeNextListed(t *testing.T) {
+	expiry := time.Now().Add(100 * time.Millisecond)
+	var listed atomic.Bool
+
+	srv := tradovatetest.NewServer(
+		tradovatetest.WithHandler("product/find", func(*tradovatetest.Request) tradovatetest.Response {
+			return tradovatetest.Response{Status: 200, Body: &tradovate.Product{ID: 10, Name: "NQ"}}
+		}),
+		tradovatetest.WithHandler("contractMaturity/deps", func(*tradovatetest.Request) tradovatetest.Response {
+			x := []*tradovate.ContractMaturity{{ID: 100, ProductID: 10, ExpirationMonth: 202503, ExpirationDate: expiry}}
+			if listed.Load() {
+				x = append(x, &tradovate.ContractMaturity{ID: 101, ProductID: 10, ExpirationMonth: 202506, ExpirationDate: expiry.AddDate(0, 0, 90)})
+			}
+			return tradovatetest.Response{Status: 200, Body: x}
+		}),
+		tradovatetest.WithHandler("contract/deps", func(r *tradovatetest.Request) tradovatetest.Response {
+			return tradovatetest.Response{Status: 200, Body: map[string][]*tradovate.Contract{
+				"100": {{ID: 1, Name: "NQH5", ContractMaturityID: 100}},
+				"101": {{ID: 2, Name: "NQM5", ContractMaturityID: 101}},
+			}[r.Query.Get("masterid")]}
+		}),
+	)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	events := make(chan tradovate.RollEvent, 1)
+	r, err := tradovate.NewRoller(ctx, tradovate.NewSubscriptionManager(ws), "NQ",
+		tradovate.WithRollHandler(func(e tradovate.RollEvent) { events <- e }),
+	)
+	if err != nil {
+		t.Fatalf("failed resolving: %v", err)
+	}
+
+	if err = r.Follow(ctx, tradovate.SubKindDOM); err != nil {
+		t.Fatalf("failed following DOM: %v", err)
+	}
+
+	if rolled, err := r.Check(ctx); rolled || err != nil {
+		t.Fatalf("nothing to roll to yet, got %v %v", rolled, err)
+	}
+
+	// NQH5 expires, and only then is NQM5 listed
+	time.Sleep(time.Until(expiry))
+	listed.Store(true)
+
+	if rolled, err := r.Check(ctx); !rolled || err != nil {
+		t.Fatalf("should roll once the current contract expires, got %v %v", rolled, err)
+	}
+
+	e := <-events
+	if e.From.Name != "NQH5" || e.To.Name != "NQM5" || r.Current().Name != "NQM5" {
+		t.Errorf("wrong roll: %s -> %s, current %s", e.From.Name, e.To.Name, r.Current().Name)
+	}
+
+	subs := srv.Subscriptions()
+	if want := (tradovatetest.Subscription{Kind: "dom", Symbol: "2"}); len(subs) != 1 || subs[0] != want {
+		t.Errorf("the DOM should follow the roll, got %+v", subs)
+	}
+}
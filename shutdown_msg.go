@@ -1,10 +1,38 @@
 package tradovate
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"strings"
+	"time"
+
+	"github.com/coder/websocket"
 )
 
+// Called when the server announces it's shutting the connection down.
+// The socket drains and reconnects on its own; this is for knowing
+// about it. Quota shutdowns are also sent to the error handler.
+//
+// Each call will run as a goroutine
+func WithShutdownHandler(x func(*ShutdownMsg)) WSOpt {
+	return func(s *WS) { s.shutdownHandler = x }
+}
+
+// How long to wait before reconnecting after a Maintenance shutdown.
+// Failed attempts double it, up to 5m. Defaults to 30s
+func WithMaintenanceDelay(d time.Duration) WSOpt {
+	return func(s *WS) { s.maintenanceDelay = d }
+}
+
+// How long to wait before reconnecting after a connection or IP quota
+// shutdown. Failed attempts double it, up to 1h. Defaults to 5m, since
+// reconnecting any sooner only keeps the quota exhausted
+func WithQuotaBackoff(d time.Duration) WSOpt {
+	return func(s *WS) { s.quotaBackoff = d }
+}
+
 //go:generate enumer -type ShutdownCode -trimprefix ShutdownCode -json
 type ShutdownCode byte
 
@@ -31,7 +59,58 @@ func (s *ShutdownMsg) Error() string {
 	return sb.String()
 }
 
-func decode[X any](e *EntityMsg) (X, error) {
+func (s *ShutdownMsg) quota() bool {
+	return s.Code == ShutdownCodeConnectionQuotaReached || s.Code == ShutdownCodeIPQuotaReached
+}
+
+// the server is going away: hold off new requests, let the ones in
+// flight finish, then close so the supervisor can reconnect
+func (s *WS) shutdown(ss *session, x *ShutdownMsg) {
+	level := slog.LevelWarn
+	if x.quota() {
+		level = slog.LevelError
+	}
+
+	s.log.LogAttrs(s.connCtx, level, "shutdown received",
+		slog.String("code", x.Code.String()),
+		slog.String("reason", x.Reason),
+	)
+
+	s.mu.Lock()
+	ss.shutdown = x
+	s.mu.Unlock()
+
+	s.fm.drain()
+	s.setState(StateDraining)
+	s.dispatch(func() { s.shutdownHandler(x) })
+	if x.quota() {
+		go s.errHandler(x)
+	}
+
+	s.background(func() {
+		s.fm.waitIdle(s.connCtx)
+		ss.end(x)
+		ss.conn.Close(websocket.StatusNormalClosure, "draining for shutdown")
+	})
+}
+
+// Stop accepting new requests, wait for the ones in flight to finish,
+// then Close. Requests made while draining fail with ErrDraining. If
+// ctx ends first the socket is closed anyway
+func (s *WS) Drain(ctx context.Context) error {
+	s.log.LogAttrs(ctx, slog.LevelInfo, "draining websocket")
+	s.fm.drain()
+	s.setState(StateDraining)
+
+	err := s.fm.waitIdle(ctx)
+	return errors.Join(err, s.Close())
+}
+
+func decode[X any](e *EntityMsg) (*X, error) {
 	var x X
-	return x, json.Unmarshal(e.Data, x)
+	if err := json.Unmarshal(e.Data, &x); err != nil {
+		return nil, err
+	}
+
+	return &x, nil
 }
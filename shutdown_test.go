@@ -0,0 +1,213 @@
+package tradovate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestShutdown(mainTest *testing.T) {
+	testCases := []struct {
+		name       string
+		code       tradovate.ShutdownCode
+		opts       []tradovate.WSOpt
+		expectErrs bool // quota shutdowns also go to the err handler
+	}{
+		{
+			name: "maintenance drains then reconnects",
+			code: tradovate.ShutdownCodeMaintenance,
+			opts: []tradovate.WSOpt{tradovate.WithMaintenanceDelay(50 * time.Millisecond)},
+		},
+		{
+			name:       "quota backs off then reconnects",
+			code:       tradovate.ShutdownCodeIPQuotaReached,
+			opts:       []tradovate.WSOpt{tradovate.WithQuotaBackoff(50 * time.Millisecond)},
+			expectErrs: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			srv := tradovatetest.NewServer()
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			var ws *tradovate.WS
+			shutdowns, errs, drained := make(chan *tradovate.ShutdownMsg, 1), make(chan error, 1), make(chan error, 1)
+			opts := append(tc.opts,
+				tradovate.WithShutdownHandler(func(m *tradovate.ShutdownMsg) {
+					_, err := ws.ListAccounts(ctx)
+					drained <- err
+					shutdowns <- m
+				}),
+				tradovate.WithErrHandler(func(err error) { errs <- err }),
+			)
+
+			ws, err := srv.NewSocket(ctx, opts...)
+			if err != nil {
+				tt.Fatalf("failed connecting: %v", err)
+			}
+			defer ws.Close()
+
+			srv.PushShutdown(tc.code, "going down")
+
+			select {
+			case m := <-shutdowns:
+				if m.Code != tc.code {
+					tt.Errorf("wrong code: want %s got %s", tc.code, m.Code)
+				}
+			case <-ctx.Done():
+				tt.Fatal("shutdown handler never called")
+			}
+
+			// draining or already reconnecting, depending on how soon the handler runs
+			if err = <-drained; !errors.Is(err, tradovate.ErrNotReady) {
+				tt.Errorf("requests while shutting down should fail fast, got %v", err)
+			}
+
+			for len(srv.RequestsFor("authorize")) < 2 {
+				select {
+				case <-ctx.Done():
+					tt.Fatal("never reconnected")
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+
+			for {
+				if _, err = ws.ListAccounts(ctx); !errors.Is(err, tradovate.ErrNotReady) {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if err != nil {
+				tt.Errorf("requests should work after reconnecting, got %v", err)
+			}
+
+			select {
+			case err = <-errs:
+				var m *tradovate.ShutdownMsg
+				if !tc.expectErrs || !errors.As(err, &m) {
+					tt.Errorf("unexpected error: %v", err)
+				}
+			default:
+				if tc.expectErrs {
+					tt.Error("quota shutdown should reach the error handler")
+				}
+			}
+		})
+	}
+}
+
+func TestWSDrain(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+
+	if err = ws.Drain(ctx); err != nil {
+		t.Errorf("drain should close cleanly, got %v", err)
+	}
+
+	if _, err = ws.ListAccounts(ctx); !errors.Is(err, tradovate.ErrNotReady) {
+		t.Errorf("wanted ErrNotReady after draining, got %v", err)
+	}
+}
+
+func TestShutdownRestoresMarketData(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	md, charts := make(chan *tradovate.MarketData, 8), make(chan *tradovate.Chart, 8)
+	ws, err := srv.NewSocket(ctx,
+		tradovate.WithMaintenanceDelay(10*time.Millisecond),
+		tradovate.WithMarketDataHandler(func(m *tradovate.MarketData) { md <- m }),
+		tradovate.WithChartHandler(func(c *tradovate.Chart) { charts <- c }),
+	)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	if _, err = ws.SubscribeQuoteSymbol(ctx, "ESZ5"); err != nil {
+		t.Fatalf("failed subscribing: %v", err)
+	}
+
+	resp, err := ws.GetChartSymbol(ctx, "ESZ5", &tradovate.ChartReq{UnderlyingType: tradovate.ChartTypeMinuteBar, ElementSize: 1})
+	if err != nil {
+		t.Fatalf("failed getting chart: %v", err)
+	}
+
+	srv.PushShutdown(tradovate.ShutdownCodeMaintenance, "going down")
+
+	for {
+		if _, err = ws.ListAccounts(ctx); !errors.Is(err, tradovate.ErrNotReady) && len(srv.RequestsFor("authorize")) == 2 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("never reconnected")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if n := len(srv.RequestsFor("md/subscribeQuote")); n != 2 {
+		t.Errorf("quote should be subscribed again after reconnecting, got %d subscribes", n)
+	}
+
+	if n := len(srv.Subscriptions()); n != 2 {
+		t.Errorf("server should have the quote and chart again, got %+v", srv.Subscriptions())
+	}
+
+	q := &tradovate.Quote{ContractID: 7, Bid: tradovate.PriceQty{Price: 5000, Size: 3}, Entries: tradovate.QuoteEntryBid}
+	if err = srv.PushQuotes(q); err != nil {
+		t.Fatalf("failed pushing quotes: %v", err)
+	}
+
+	select {
+	case m := <-md:
+		if len(m.Quotes) != 1 || m.Quotes[0].ContractID != 7 {
+			t.Errorf("wrong quotes: %+v", m.Quotes)
+		}
+	case <-ctx.Done():
+		t.Fatal("quotes never arrived after reconnecting")
+	}
+
+	// the server gave the chart new IDs; callers keep the ones they have
+	if err = srv.PushChart(&tradovate.Chart{ID: resp.HistoricalID + 1}); err != nil {
+		t.Fatalf("failed pushing chart: %v", err)
+	}
+
+	select {
+	case c := <-charts:
+		if c.ID != resp.HistoricalID {
+			t.Errorf("chart should keep its original ID %d, got %d", resp.HistoricalID, c.ID)
+		}
+	case <-ctx.Done():
+		t.Fatal("chart never arrived after reconnecting")
+	}
+
+	if err = ws.CancelChart(ctx, resp.HistoricalID); err != nil {
+		t.Fatalf("failed canceling chart: %v", err)
+	}
+
+	if subs := srv.Subscriptions(); len(subs) != 1 {
+		t.Errorf("canceling should reach the chart's new ID, got %+v", subs)
+	}
+}
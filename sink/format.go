@@ -0,0 +1,236 @@
+package sink
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// File format to write
+//
+//go:generate enumer -type Format -trimprefix Format -transform lower -json
+type Format byte
+
+const (
+	FormatUnspecified Format = iota
+	FormatCSV
+	FormatParquet
+)
+
+// rowWriter writes rows of one schema to a single file
+type rowWriter[T any] interface {
+	write(rows []T) error
+	flush() error
+	close() error
+}
+
+func newRowWriter[T any](f Format, file *os.File) (rowWriter[T], error) {
+	switch f {
+	case FormatCSV:
+		w := &csvWriter[T]{f: file, w: csv.NewWriter(file)}
+		if err := w.w.Write(columns[T]()); err != nil {
+			return nil, err
+		}
+		return w, nil
+	case FormatParquet:
+		return &parquetWriter[T]{f: file, w: parquet.NewGenericWriter[T](file)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %s", f)
+	}
+}
+
+type csvWriter[T any] struct {
+	f *os.File
+	w *csv.Writer
+}
+
+func (c *csvWriter[T]) write(rows []T) error {
+	for i := range rows {
+		if err := c.w.Write(encodeCSV(reflect.ValueOf(&rows[i]).Elem())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter[T]) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter[T]) close() error {
+	if err := c.flush(); err != nil {
+		c.f.Close()
+		return err
+	}
+	return c.f.Close()
+}
+
+// parquet buffers a row group in memory; flushing writes it out. The file
+// isn't readable until it's closed and the footer is written
+type parquetWriter[T any] struct {
+	f *os.File
+	w *parquet.GenericWriter[T]
+}
+
+func (p *parquetWriter[T]) write(rows []T) error {
+	_, err := p.w.Write(rows)
+	return err
+}
+
+func (p *parquetWriter[T]) flush() error { return p.w.Flush() }
+
+func (p *parquetWriter[T]) close() error {
+	if err := p.w.Close(); err != nil {
+		p.f.Close()
+		return err
+	}
+	return p.f.Close()
+}
+
+// read every row of a file, going by its extension for the format
+func readRows[T any](path string) ([]T, error) {
+	switch {
+	case strings.HasSuffix(path, "."+FormatParquet.String()):
+		return parquet.ReadFile[T](path)
+	case strings.HasSuffix(path, "."+FormatCSV.String()):
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readCSV[T](f)
+	default:
+		return nil, fmt.Errorf("can't tell the format of %s from its extension", path)
+	}
+}
+
+func readCSV[T any](r io.Reader) ([]T, error) {
+	c := csv.NewReader(r)
+	c.ReuseRecord = true
+
+	header, err := c.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// map the header onto the schema so column order doesn't matter
+	want := columns[T]()
+	idx := make([]int, len(want))
+	for i, name := range want {
+		idx[i] = -1
+		for j, h := range header {
+			if h == name {
+				idx[i] = j
+			}
+		}
+
+		if idx[i] == -1 {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	var rows []T
+	for line := 2; ; line++ {
+		rec, err := c.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var x T
+		v := reflect.ValueOf(&x).Elem()
+		for i, j := range idx {
+			if err = decodeCSV(v.Field(i), rec[j]); err != nil {
+				return nil, fmt.Errorf("line %d, column %s: %w", line, want[i], err)
+			}
+		}
+		rows = append(rows, x)
+	}
+}
+
+// column names of a row type, from its parquet tags
+func columns[T any]() []string {
+	t := reflect.TypeFor[T]()
+	x := make([]string, t.NumField())
+	for i := range x {
+		x[i], _, _ = strings.Cut(t.Field(i).Tag.Get("parquet"), ",")
+	}
+	return x
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func encodeCSV(v reflect.Value) []string {
+	x := make([]string, v.NumField())
+	for i := range x {
+		f := v.Field(i)
+		switch {
+		case f.Type() == timeType:
+			if t := f.Interface().(time.Time); !t.IsZero() {
+				x[i] = t.Format(time.RFC3339Nano)
+			}
+		case f.CanInt():
+			x[i] = strconv.FormatInt(f.Int(), 10)
+		case f.CanFloat():
+			x[i] = strconv.FormatFloat(f.Float(), 'g', -1, 64)
+		case f.Kind() == reflect.Bool:
+			x[i] = strconv.FormatBool(f.Bool())
+		case f.Kind() == reflect.String:
+			x[i] = f.String()
+		default:
+			panic(fmt.Sprintf("sink: no CSV encoding for %s", f.Type()))
+		}
+	}
+	return x
+}
+
+func decodeCSV(f reflect.Value, s string) error {
+	switch {
+	case f.Type() == timeType:
+		if s == "" {
+			return nil
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(t))
+	case f.CanInt():
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case f.CanFloat():
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case f.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case f.Kind() == reflect.String:
+		f.SetString(s)
+	default:
+		return fmt.Errorf("no CSV decoding for %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package sink
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+// Every file of a record type under dir, in either format, sorted by
+// date, contract and then the order they were written
+func Files(dir string, r Record) ([]string, error) {
+	var x []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		if strings.HasPrefix(d.Name(), r.String()+"-") {
+			x = append(x, path)
+		}
+		return nil
+	})
+
+	slices.Sort(x)
+	return x, err
+}
+
+// Read the quotes back out of a file written by a Sink. The format is
+// taken from the extension
+func ReadQuotes(path string) ([]*tradovate.Quote, error) {
+	rows, err := readRows[quoteRow](path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading quotes from %s: %w", path, err)
+	}
+
+	x := make([]*tradovate.Quote, len(rows))
+	for i := range rows {
+		x[i] = rows[i].quote()
+	}
+	return x, nil
+}
+
+// Read the DOMs back out of a file written by a Sink. Empty DOMs have no
+// rows to write, so they don't come back
+func ReadDOMs(path string) ([]*tradovate.DOM, error) {
+	rows, err := readRows[domRow](path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading DOMs from %s: %w", path, err)
+	}
+
+	var x []*tradovate.DOM
+	for i, v := range rows {
+		// a level 0 bid, or a level 0 offer with no bids before it, starts
+		// the next DOM
+		if i == 0 || v.Level == 0 && (v.Side == "bid" || rows[i-1].Side == "offer") ||
+			v.ContractID != rows[i-1].ContractID || !v.Timestamp.Equal(rows[i-1].Timestamp) {
+			x = append(x, &tradovate.DOM{ContractID: int(v.ContractID), Timestamp: v.Timestamp})
+		}
+
+		d, level := x[len(x)-1], tradovate.PriceQty{Price: v.Price, Size: v.Size}
+		switch v.Side {
+		case "bid":
+			d.Bids = append(d.Bids, level)
+		case "offer":
+			d.Offers = append(d.Offers, level)
+		default:
+			return nil, fmt.Errorf("failed reading DOMs from %s: unknown side %q", path, v.Side)
+		}
+	}
+	return x, nil
+}
+
+// Read the histograms back out of a file written by a Sink
+func ReadHistograms(path string) ([]*tradovate.Histogram, error) {
+	rows, err := readRows[histogramRow](path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading histograms from %s: %w", path, err)
+	}
+
+	var x []*tradovate.Histogram
+	for i, v := range rows {
+		tradeDate, err := time.ParseInLocation(time.DateOnly, v.TradeDate, nyseTimezone)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading histograms from %s: bad trade date: %w", path, err)
+		}
+
+		// the rows of one histogram have increasing keys; anything else
+		// starts the next
+		if i == 0 || v.Key == "" || rows[i-1].Key == "" || v.Key <= rows[i-1].Key ||
+			v.ContractID != rows[i-1].ContractID || !v.Timestamp.Equal(rows[i-1].Timestamp) ||
+			v.Base != rows[i-1].Base || v.Refresh != rows[i-1].Refresh {
+			x = append(x, &tradovate.Histogram{
+				ContractID: int(v.ContractID),
+				Timestamp:  v.Timestamp,
+				TradeDate:  tradeDate,
+				Base:       v.Base,
+				Refresh:    v.Refresh,
+				Items:      map[string]float64{},
+			})
+		}
+
+		if v.Key != "" {
+			x[len(x)-1].Items[v.Key] = v.Volume
+		}
+	}
+	return x, nil
+}
+
+// Read the bars back out of a file written by a Sink
+func ReadBars(path string) ([]tradovate.Bar, error) {
+	rows, err := readRows[barRow](path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading bars from %s: %w", path, err)
+	}
+
+	x := make([]tradovate.Bar, len(rows))
+	for i := range rows {
+		x[i] = rows[i].bar()
+	}
+	return x, nil
+}
+
+// Read the ticks back out of a file written by a Sink, as tick charts.
+// Consecutive ticks from the same chart with the same base make up one
+func ReadTicks(path string) ([]*tradovate.Chart, error) {
+	rows, err := readRows[tickRow](path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading ticks from %s: %w", path, err)
+	}
+
+	var x []*tradovate.Chart
+	for i, v := range rows {
+		if i == 0 || v.ChartID != rows[i-1].ChartID || v.Source != rows[i-1].Source || v.BasePrice != rows[i-1].BasePrice ||
+			!v.BaseTimestamp.Equal(rows[i-1].BaseTimestamp) || v.TickSize != rows[i-1].TickSize {
+			x = append(x, &tradovate.Chart{
+				ID:            int(v.ChartID),
+				Source:        v.Source,
+				BasePrice:     int(v.BasePrice),
+				BaseTimestamp: v.BaseTimestamp,
+				TickSize:      v.TickSize,
+			})
+		}
+
+		c := x[len(x)-1]
+		c.Ticks = append(c.Ticks, v.tick())
+	}
+	return x, nil
+}
@@ -0,0 +1,252 @@
+package sink
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+// Which kind of record a file holds
+//
+//go:generate enumer -type Record -trimprefix Record -transform lower -json
+type Record byte
+
+const (
+	RecordUnspecified Record = iota
+	RecordQuote
+	RecordDOM
+	RecordBar
+	RecordTick
+	RecordHistogram
+)
+
+// The schemas. Column names come from the parquet tags and are shared by
+// both formats. Anything with more than one value per message (DOM
+// levels, histogram items) is a row per value
+
+type quoteRow struct {
+	ContractID       int64     `parquet:"contract_id"`
+	Timestamp        time.Time `parquet:"timestamp,timestamp(nanosecond)"`
+	Entries          int32     `parquet:"entries"` // tradovate.QuoteEntry bitmask
+	BidPrice         float64   `parquet:"bid_price"`
+	BidSize          float64   `parquet:"bid_size"`
+	OfferPrice       float64   `parquet:"offer_price"`
+	OfferSize        float64   `parquet:"offer_size"`
+	TradePrice       float64   `parquet:"trade_price"`
+	TradeSize        float64   `parquet:"trade_size"`
+	TotalTradeVolume float64   `parquet:"total_trade_volume"`
+	OpenInterest     float64   `parquet:"open_interest"`
+	OpeningPrice     float64   `parquet:"opening_price"`
+	LowPrice         float64   `parquet:"low_price"`
+	HighPrice        float64   `parquet:"high_price"`
+	SettlementPrice  float64   `parquet:"settlement_price"`
+}
+
+type domRow struct {
+	ContractID int64     `parquet:"contract_id"`
+	Timestamp  time.Time `parquet:"timestamp,timestamp(nanosecond)"`
+	Side       string    `parquet:"side"`  // bid or offer
+	Level      int32     `parquet:"level"` // 0 is the top of the book
+	Price      float64   `parquet:"price"`
+	Size       float64   `parquet:"size"`
+}
+
+type barRow struct {
+	ContractID  int64     `parquet:"contract_id"`
+	ChartID     int64     `parquet:"chart_id"`
+	Timestamp   time.Time `parquet:"timestamp,timestamp(nanosecond)"`
+	Open        float64   `parquet:"open"`
+	High        float64   `parquet:"high"`
+	Low         float64   `parquet:"low"`
+	Close       float64   `parquet:"close"`
+	UpVolume    float64   `parquet:"up_volume"`
+	DownVolume  float64   `parquet:"down_volume"`
+	UpTicks     float64   `parquet:"up_ticks"`
+	DownTicks   float64   `parquet:"down_ticks"`
+	BidVolume   float64   `parquet:"bid_volume"`
+	OfferVolume float64   `parquet:"offer_volume"`
+}
+
+// ticks keep the chart's base and their relative fields so they read
+// back exactly; timestamp and price are the absolute values worked out
+// for convenience
+type tickRow struct {
+	ContractID    int64     `parquet:"contract_id"`
+	ChartID       int64     `parquet:"chart_id"`
+	Timestamp     time.Time `parquet:"timestamp,timestamp(nanosecond)"`
+	Price         float64   `parquet:"price"`
+	Source        string    `parquet:"source"`
+	BasePrice     int64     `parquet:"base_price"`
+	BaseTimestamp time.Time `parquet:"base_timestamp,timestamp(nanosecond)"`
+	TickSize      float64   `parquet:"tick_size"`
+	ID            int64     `parquet:"id"`
+	RelativeTime  int64     `parquet:"relative_time"`
+	RelativePrice int64     `parquet:"relative_price"`
+	Volume        int64     `parquet:"volume"`
+	RelativeBid   float64   `parquet:"relative_bid_price"`
+	RelativeAsk   float64   `parquet:"relative_ask_price"`
+	BidSize       float64   `parquet:"bid_size"`
+	AskSize       float64   `parquet:"ask_size"`
+}
+
+// trade dates are midnight in New York, same as tradovate.Histogram's
+var nyseTimezone = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("America/New_York", -5*60*60)
+	}
+	return loc
+}()
+
+// a histogram without items is still written, as a single row with an
+// empty key, so refreshes that clear everything aren't lost
+type histogramRow struct {
+	ContractID int64     `parquet:"contract_id"`
+	Timestamp  time.Time `parquet:"timestamp,timestamp(nanosecond)"`
+	TradeDate  string    `parquet:"trade_date"` // YYYY-MM-DD
+	Base       float64   `parquet:"base"`
+	Refresh    bool      `parquet:"refresh"`
+	Key        string    `parquet:"key"`
+	Volume     float64   `parquet:"volume"`
+}
+
+func newQuoteRow(q *tradovate.Quote) quoteRow {
+	return quoteRow{
+		ContractID:       int64(q.ContractID),
+		Timestamp:        q.Timestamp,
+		Entries:          int32(q.Entries),
+		BidPrice:         q.Bid.Price,
+		BidSize:          q.Bid.Size,
+		OfferPrice:       q.Offer.Price,
+		OfferSize:        q.Offer.Size,
+		TradePrice:       q.Trade.Price,
+		TradeSize:        q.Trade.Size,
+		TotalTradeVolume: q.TotalTradeVolume,
+		OpenInterest:     q.OpenInterest,
+		OpeningPrice:     q.OpeningPrice,
+		LowPrice:         q.LowPrice,
+		HighPrice:        q.HighPrice,
+		SettlementPrice:  q.SettlementPrice,
+	}
+}
+
+func (r *quoteRow) quote() *tradovate.Quote {
+	return &tradovate.Quote{
+		ContractID:       int(r.ContractID),
+		Timestamp:        r.Timestamp,
+		Bid:              tradovate.PriceQty{Price: r.BidPrice, Size: r.BidSize},
+		Offer:            tradovate.PriceQty{Price: r.OfferPrice, Size: r.OfferSize},
+		Trade:            tradovate.PriceQty{Price: r.TradePrice, Size: r.TradeSize},
+		TotalTradeVolume: r.TotalTradeVolume,
+		OpenInterest:     r.OpenInterest,
+		OpeningPrice:     r.OpeningPrice,
+		LowPrice:         r.LowPrice,
+		HighPrice:        r.HighPrice,
+		SettlementPrice:  r.SettlementPrice,
+		Entries:          tradovate.QuoteEntry(r.Entries),
+	}
+}
+
+func newDOMRows(d *tradovate.DOM) []domRow {
+	x := make([]domRow, 0, len(d.Bids)+len(d.Offers))
+	for i, v := range d.Bids {
+		x = append(x, domRow{ContractID: int64(d.ContractID), Timestamp: d.Timestamp, Side: "bid", Level: int32(i), Price: v.Price, Size: v.Size})
+	}
+
+	for i, v := range d.Offers {
+		x = append(x, domRow{ContractID: int64(d.ContractID), Timestamp: d.Timestamp, Side: "offer", Level: int32(i), Price: v.Price, Size: v.Size})
+	}
+
+	return x
+}
+
+func newBarRow(contractID, chartID int, b *tradovate.Bar) barRow {
+	return barRow{
+		ContractID:  int64(contractID),
+		ChartID:     int64(chartID),
+		Timestamp:   b.Timestamp,
+		Open:        b.Open,
+		High:        b.High,
+		Low:         b.Low,
+		Close:       b.Close,
+		UpVolume:    b.UpVolume,
+		DownVolume:  b.DownVolume,
+		UpTicks:     b.UpTicks,
+		DownTicks:   b.DownTicks,
+		BidVolume:   b.BidVolume,
+		OfferVolume: b.OfferVolume,
+	}
+}
+
+func (r *barRow) bar() tradovate.Bar {
+	return tradovate.Bar{
+		Timestamp:   r.Timestamp,
+		Open:        r.Open,
+		High:        r.High,
+		Low:         r.Low,
+		Close:       r.Close,
+		UpVolume:    r.UpVolume,
+		DownVolume:  r.DownVolume,
+		UpTicks:     r.UpTicks,
+		DownTicks:   r.DownTicks,
+		BidVolume:   r.BidVolume,
+		OfferVolume: r.OfferVolume,
+	}
+}
+
+func newTickRow(contractID int, c *tradovate.Chart, t *tradovate.Tick) tickRow {
+	return tickRow{
+		ContractID:    int64(contractID),
+		ChartID:       int64(c.ID),
+		Timestamp:     c.BaseTimestamp.Add(time.Duration(t.RelativeTime) * time.Millisecond),
+		Price:         float64(c.BasePrice+t.RelativePrice) * c.TickSize,
+		Source:        c.Source,
+		BasePrice:     int64(c.BasePrice),
+		BaseTimestamp: c.BaseTimestamp,
+		TickSize:      c.TickSize,
+		ID:            int64(t.ID),
+		RelativeTime:  int64(t.RelativeTime),
+		RelativePrice: int64(t.RelativePrice),
+		Volume:        int64(t.Volume),
+		RelativeBid:   t.RelativeBidPrice,
+		RelativeAsk:   t.RelativeAskPrice,
+		BidSize:       t.BidSize,
+		AskSize:       t.AskSize,
+	}
+}
+
+func (r *tickRow) tick() tradovate.Tick {
+	return tradovate.Tick{
+		ID:               int(r.ID),
+		RelativeTime:     int(r.RelativeTime),
+		RelativePrice:    int(r.RelativePrice),
+		Volume:           int(r.Volume),
+		RelativeBidPrice: r.RelativeBid,
+		RelativeAskPrice: r.RelativeAsk,
+		BidSize:          r.BidSize,
+		AskSize:          r.AskSize,
+	}
+}
+
+func newHistogramRows(h *tradovate.Histogram) []histogramRow {
+	row := histogramRow{
+		ContractID: int64(h.ContractID),
+		Timestamp:  h.Timestamp,
+		TradeDate:  h.TradeDate.Format(time.DateOnly),
+		Base:       h.Base,
+		Refresh:    h.Refresh,
+	}
+
+	if len(h.Items) == 0 {
+		return []histogramRow{row}
+	}
+
+	x := make([]histogramRow, 0, len(h.Items))
+	for _, k := range slices.Sorted(maps.Keys(h.Items)) {
+		row.Key, row.Volume = k, h.Items[k]
+		x = append(x, row)
+	}
+	return x
+}
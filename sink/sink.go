@@ -0,0 +1,332 @@
+// Package sink captures a tradovate market data feed to disk as CSV or
+// Parquet files, and reads them back.
+//
+// Quotes, DOMs, histograms and chart bars and ticks each get their own
+// schema. Files are partitioned by date and contract,
+//
+//	<dir>/date=2025-03-10/contract=3570918/quote-000001.parquet
+//
+// and rotate when the date changes or they reach a row limit. Dates are
+// the UTC date of each record's timestamp
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+const defaultMaxRows = 1_000_000
+
+// ErrUntrackedChart is reported for chart messages whose subscription
+// wasn't registered with TrackChart, since there's no telling which
+// contract they're for
+var ErrUntrackedChart = errors.New("chart isn't tracked")
+
+type Opt func(s *Sink)
+
+// Formats to write. Each gets its own files. Defaults to Parquet
+func WithFormats(f ...Format) Opt {
+	return func(s *Sink) { s.formats = f }
+}
+
+// Start a new file once one has this many rows. Defaults to 1,000,000
+func WithMaxRows(n int) Opt {
+	return func(s *Sink) { s.maxRows = n }
+}
+
+// Flush every open file this often. Parquet flushes write out a row
+// group, so flushing too often makes for small ones. Defaults to 0, only
+// flushing when files rotate or the sink closes
+func WithFlushInterval(d time.Duration) Opt {
+	return func(s *Sink) { s.flushEvery = d }
+}
+
+// Handler for write errors, which happen inside the socket's handlers and
+// have nowhere else to go. Defaults to discarding them
+func WithErrHandler(fn func(error)) Opt {
+	return func(s *Sink) { s.errHandler = fn }
+}
+
+// Sink writes market data to rotating files. Hook its handlers up to a
+// socket,
+//
+//	s, _ := sink.New("capture")
+//	ws, _ := tradovate.NewSocket(ctx, uri, nil, rest,
+//		tradovate.WithMarketDataHandler(s.HandleMarketData),
+//		tradovate.WithChartHandler(s.HandleChart),
+//	)
+//	s.Attach(ws)
+//
+// and Attach it so it's flushed and closed with the socket
+type Sink struct {
+	dir        string
+	formats    []Format
+	maxRows    int
+	flushEvery time.Duration
+	errHandler func(error)
+
+	mu     sync.Mutex
+	closed bool
+	charts map[int]int // chart subscription ID -> contract ID
+	files  map[fileKey]*file
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type fileKey struct {
+	contractID int
+	record     Record
+	format     Format
+}
+
+type file struct {
+	date string
+	rows int
+	w    any // rowWriter of the record's row type
+}
+
+func New(dir string, opts ...Opt) (*Sink, error) {
+	s := &Sink{
+		dir:        dir,
+		formats:    []Format{FormatParquet},
+		maxRows:    defaultMaxRows,
+		errHandler: func(error) {},
+		charts:     map[int]int{},
+		files:      map[fileKey]*file{},
+		stop:       make(chan struct{}),
+	}
+
+	for _, v := range opts {
+		v(s)
+	}
+
+	if len(s.formats) == 0 {
+		return nil, fmt.Errorf("no formats to write")
+	}
+
+	for _, v := range s.formats {
+		if v != FormatCSV && v != FormatParquet {
+			return nil, fmt.Errorf("unknown format %s", v)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed creating %s: %w", dir, err)
+	}
+
+	if s.flushEvery > 0 {
+		s.wg.Add(1)
+		go s.flusher()
+	}
+
+	return s, nil
+}
+
+// Flush and close the sink when the socket closes, so everything it
+// received is on disk by the time WS.Close returns. Call the returned
+// func to detach
+func (s *Sink) Attach(ws *tradovate.WS) (detach func()) {
+	// after the socket's handlers return, or whatever they're still
+	// writing is dropped
+	return ws.OnDrained(func() {
+		if err := s.Close(); err != nil {
+			s.errHandler(err)
+		}
+	})
+}
+
+// Say which contract a chart subscription is for, so its bars and ticks
+// can be partitioned. Both IDs in the response are tracked
+func (s *Sink) TrackChart(resp tradovate.ChartResp, contractID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.charts[resp.HistoricalID] = contractID
+	s.charts[resp.RealtimeID] = contractID
+}
+
+func (s *Sink) UntrackChart(resp tradovate.ChartResp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.charts, resp.HistoricalID)
+	delete(s.charts, resp.RealtimeID)
+}
+
+// Write the quotes, DOMs and histograms. Matches the signature of
+// WithMarketDataHandler
+func (s *Sink) HandleMarketData(md *tradovate.MarketData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range md.Quotes {
+		s.report(write(s, v.ContractID, RecordQuote, v.Timestamp, newQuoteRow(v)))
+	}
+
+	for _, v := range md.DOMs {
+		s.report(write(s, v.ContractID, RecordDOM, v.Timestamp, newDOMRows(v)...))
+	}
+
+	for _, v := range md.Histograms {
+		s.report(write(s, v.ContractID, RecordHistogram, v.Timestamp, newHistogramRows(v)...))
+	}
+}
+
+// Write the chart's bars and ticks. The chart has to be tracked with
+// TrackChart first. Matches the signature of WithChartHandler
+func (s *Sink) HandleChart(c *tradovate.Chart) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(c.Bars) == 0 && len(c.Ticks) == 0 {
+		return
+	}
+
+	contractID, ok := s.charts[c.ID]
+	if !ok {
+		s.report(fmt.Errorf("%w: %d", ErrUntrackedChart, c.ID))
+		return
+	}
+
+	for i := range c.Bars {
+		b := &c.Bars[i]
+		s.report(write(s, contractID, RecordBar, b.Timestamp, newBarRow(contractID, c.ID, b)))
+	}
+
+	for i := range c.Ticks {
+		row := newTickRow(contractID, c, &c.Ticks[i])
+		s.report(write(s, contractID, RecordTick, row.Timestamp, row))
+	}
+}
+
+// Flush every open file
+func (s *Sink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, v := range s.files {
+		if err := v.w.(interface{ flush() error }).flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush and close every file. Records arriving after are dropped
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+
+	var errs []error
+	for k, v := range s.files {
+		if err := v.w.(interface{ close() error }).close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(s.files, k)
+	}
+	s.mu.Unlock()
+
+	close(s.stop)
+	s.wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (s *Sink) flusher() {
+	defer s.wg.Done()
+
+	t := time.NewTicker(s.flushEvery)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			s.report(s.Flush())
+		}
+	}
+}
+
+func (s *Sink) report(err error) {
+	if err != nil {
+		s.errHandler(err)
+	}
+}
+
+// write rows to every format's current file for the contract, rotating
+// first if the date moved or the file's full. s.mu held
+func write[T any](s *Sink, contractID int, r Record, at time.Time, rows ...T) error {
+	if s.closed || len(rows) == 0 {
+		return nil
+	}
+
+	date := at.UTC().Format(time.DateOnly)
+	var errs []error
+	for _, format := range s.formats {
+		k := fileKey{contractID: contractID, record: r, format: format}
+		f, ok := s.files[k]
+		if ok && (f.date != date || f.rows >= s.maxRows) {
+			delete(s.files, k)
+			if err := f.w.(rowWriter[T]).close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed closing %s file: %w", r, err))
+			}
+			ok = false
+		}
+
+		if !ok {
+			w, err := open[T](s.dir, k, date)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			f = &file{date: date, w: w}
+			s.files[k] = f
+		}
+
+		if err := f.w.(rowWriter[T]).write(rows); err != nil {
+			errs = append(errs, fmt.Errorf("failed writing %s: %w", r, err))
+			continue
+		}
+		f.rows += len(rows)
+	}
+
+	return errors.Join(errs...)
+}
+
+// open the next unused file in the partition. Files are never
+// overwritten, so going back to an earlier date starts another file
+func open[T any](dir string, k fileKey, date string) (rowWriter[T], error) {
+	part := filepath.Join(dir, "date="+date, fmt.Sprintf("contract=%d", k.contractID))
+	if err := os.MkdirAll(part, 0o755); err != nil {
+		return nil, fmt.Errorf("failed creating partition: %w", err)
+	}
+
+	for seq := 1; ; seq++ {
+		path := filepath.Join(part, fmt.Sprintf("%s-%06d.%s", k.record, seq, k.format))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed creating %s: %w", path, err)
+		}
+
+		w, err := newRowWriter[T](k.format, f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed starting %s: %w", path, err)
+		}
+		return w, nil
+	}
+}
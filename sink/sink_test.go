@@ -0,0 +1,269 @@
+package sink_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/sink"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestSinkRoundTrip(mainTest *testing.T) {
+	at := time.Date(2025, 3, 10, 14, 30, 0, 123456789, time.UTC)
+
+	nyse, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		mainTest.Skipf("no timezone data: %v", err)
+	}
+
+	quote := &tradovate.Quote{
+		ContractID: 7,
+		Timestamp:  at,
+		Bid:        tradovate.PriceQty{Price: 5000.25, Size: 3},
+		Trade:      tradovate.PriceQty{Price: 5000.5, Size: 1},
+		Entries:    tradovate.QuoteEntryBid | tradovate.QuoteEntryTrade,
+	}
+
+	dom := &tradovate.DOM{
+		ContractID: 7,
+		Timestamp:  at,
+		Bids:       []tradovate.PriceQty{{Price: 5000.25, Size: 3}, {Price: 5000, Size: 8}},
+		Offers:     []tradovate.PriceQty{{Price: 5000.5, Size: 2}},
+	}
+
+	histogram := &tradovate.Histogram{
+		ContractID: 7,
+		Timestamp:  at,
+		TradeDate:  time.Date(2025, 3, 10, 0, 0, 0, 0, nyse),
+		Base:       5000,
+		Items:      map[string]float64{"0": 10, "1": 4},
+		Refresh:    true,
+	}
+
+	chart := &tradovate.Chart{
+		ID:            4,
+		Td:            time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		Bars:          []tradovate.Bar{{Timestamp: at, Open: 1, High: 2, Low: 0.5, Close: 1.5, UpVolume: 10}},
+		Source:        "db",
+		BasePrice:     20000,
+		BaseTimestamp: at.Truncate(time.Millisecond),
+		TickSize:      0.25,
+		Ticks:         []tradovate.Tick{{ID: 1, RelativeTime: 5, RelativePrice: 2, Volume: 3, BidSize: 1}},
+	}
+
+	testCases := []struct {
+		name   string
+		format sink.Format
+	}{
+		{name: "csv", format: sink.FormatCSV},
+		{name: "parquet", format: sink.FormatParquet},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			dir := tt.TempDir()
+			s, err := sink.New(dir, sink.WithFormats(tc.format), sink.WithErrHandler(func(err error) { tt.Error(err) }))
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			srv := tradovatetest.NewServer()
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			received := make(chan struct{}, 8)
+			ws, err := srv.NewSocket(ctx,
+				tradovate.WithMarketDataHandler(func(md *tradovate.MarketData) { s.HandleMarketData(md); received <- struct{}{} }),
+				tradovate.WithChartHandler(func(c *tradovate.Chart) { s.HandleChart(c); received <- struct{}{} }),
+			)
+			if err != nil {
+				tt.Fatalf("failed connecting: %v", err)
+			}
+			s.Attach(ws)
+			s.TrackChart(tradovate.ChartResp{HistoricalID: 4, RealtimeID: 5}, 7)
+
+			pushes := []func() error{
+				func() error { return srv.PushQuotes(quote) },
+				func() error { return srv.PushDOMs(dom) },
+				func() error { return srv.PushHistograms(histogram) },
+				func() error { return srv.PushChart(chart) },
+			}
+
+			for _, push := range pushes {
+				if err = push(); err != nil {
+					tt.Fatal(err)
+				}
+
+				select {
+				case <-ctx.Done():
+					tt.Fatal("never received market data")
+				case <-received:
+				}
+			}
+
+			// closing the socket should close the files so they can be read
+			ws.Close()
+
+			read := func(r sink.Record) string {
+				files, err := sink.Files(dir, r)
+				if err != nil || len(files) != 1 {
+					tt.Fatalf("wanted one %s file, got %v (err %v)", r, files, err)
+				}
+
+				want := filepath.Join(dir, "date=2025-03-10", "contract=7", r.String()+"-000001."+tc.format.String())
+				if files[0] != want {
+					tt.Errorf("wrong path: want %s got %s", want, files[0])
+				}
+				return files[0]
+			}
+
+			quotes, err := sink.ReadQuotes(read(sink.RecordQuote))
+			if err != nil || len(quotes) != 1 || !reflect.DeepEqual(quotes[0], quote) {
+				tt.Errorf("wrong quotes: %+v (err %v)", quotes, err)
+			}
+
+			doms, err := sink.ReadDOMs(read(sink.RecordDOM))
+			if err != nil || len(doms) != 1 || !reflect.DeepEqual(doms[0], dom) {
+				tt.Errorf("wrong DOMs: %+v (err %v)", doms, err)
+			}
+
+			histograms, err := sink.ReadHistograms(read(sink.RecordHistogram))
+			if err != nil || len(histograms) != 1 || !reflect.DeepEqual(histograms[0], histogram) {
+				tt.Errorf("wrong histograms: %+v (err %v)", histograms, err)
+			}
+
+			bars, err := sink.ReadBars(read(sink.RecordBar))
+			if err != nil || !reflect.DeepEqual(bars, chart.Bars) {
+				tt.Errorf("wrong bars: %+v (err %v)", bars, err)
+			}
+
+			ticks, err := sink.ReadTicks(read(sink.RecordTick))
+			want := &tradovate.Chart{ID: 4, Source: "db", BasePrice: 20000, BaseTimestamp: chart.BaseTimestamp, TickSize: 0.25, Ticks: chart.Ticks}
+			if err != nil || len(ticks) != 1 || !reflect.DeepEqual(ticks[0], want) {
+				tt.Errorf("wrong ticks: %+v (err %v)", ticks, err)
+			}
+		})
+	}
+}
+
+func TestSinkRotates(t *testing.T) {
+	dir := t.TempDir()
+	s, err := sink.New(dir, sink.WithFormats(sink.FormatCSV), sink.WithMaxRows(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2025, 3, 10, 23, 59, 0, 0, time.UTC)
+	var quotes []*tradovate.Quote
+	for i := range 5 {
+		quotes = append(quotes, &tradovate.Quote{ContractID: 1, Timestamp: day.Add(time.Duration(i) * 30 * time.Second)})
+	}
+	quotes = append(quotes, &tradovate.Quote{ContractID: 2, Timestamp: day})
+
+	s.HandleMarketData(&tradovate.MarketData{Quotes: quotes})
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := sink.Files(dir, sink.RecordQuote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two on the 10th fill a file, the other three go to the 11th
+	want := []string{
+		"date=2025-03-10/contract=1/quote-000001.csv",
+		"date=2025-03-10/contract=2/quote-000001.csv",
+		"date=2025-03-11/contract=1/quote-000001.csv",
+		"date=2025-03-11/contract=1/quote-000002.csv",
+	}
+
+	var got []string
+	var total int
+	for _, v := range files {
+		rel, _ := filepath.Rel(dir, v)
+		got = append(got, filepath.ToSlash(rel))
+
+		q, err := sink.ReadQuotes(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += len(q)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wrong files:\nwant %v\ngot  %v", want, got)
+	}
+
+	if total != len(quotes) {
+		t.Errorf("wanted %d quotes back, got %d", len(quotes), total)
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "date=2025-03-10", "contract=1", "quote-000002.csv")); err == nil {
+		t.Error("the 10th shouldn't have rotated")
+	}
+}
+
+func TestSinkWaitsForHandlers(t *testing.T) {
+	dir := t.TempDir()
+	s, err := sink.New(dir, sink.WithFormats(sink.FormatCSV), sink.WithErrHandler(func(err error) { t.Error(err) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entered, proceed := make(chan struct{}), make(chan struct{})
+	ws, err := srv.NewSocket(ctx, tradovate.WithMarketDataHandler(func(md *tradovate.MarketData) {
+		close(entered)
+		<-proceed
+		s.HandleMarketData(md)
+	}))
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	s.Attach(ws)
+
+	q := &tradovate.Quote{ContractID: 7, Timestamp: time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC), Bid: tradovate.PriceQty{Price: 5000, Size: 3}, Entries: tradovate.QuoteEntryBid}
+	if err = srv.PushQuotes(q); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("handler never ran")
+	case <-entered:
+	}
+
+	// the socket's closed while the handler's still holding its quote
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		ws.Close()
+	}()
+
+	<-ws.Done()
+	close(proceed)
+	<-closed
+
+	files, err := sink.Files(dir, sink.RecordQuote)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("wanted one quote file, got %v (err %v)", files, err)
+	}
+
+	quotes, err := sink.ReadQuotes(files[0])
+	if err != nil || len(quotes) != 1 {
+		t.Errorf("the handler's quote should be written before the sink closes, got %+v (err %v)", quotes, err)
+	}
+}
@@ -3,8 +3,11 @@ package tradovate
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"encoding/json"
@@ -22,8 +25,9 @@ const (
 
 type WSOpt func(s *WS)
 
-// Attaches a timeout to each WS request. Defaults to 5s
-// if you don't set
+// Attaches a timeout to each WS request whose context has no
+// deadline. Defaults to 5s if you don't set. A context deadline
+// always wins, whether it's shorter or longer
 func WithTimeout(t time.Duration) WSOpt {
 	return func(s *WS) { s.fm.timeout = t }
 }
@@ -67,18 +71,51 @@ func WithMarketDataHandler(x func(*MarketData)) WSOpt {
 
 // Websocket client to the tradovate API
 type WS struct {
-	connCtx    context.Context
+	connCtx    context.Context // lives until Close or a fatal error
 	connCancel context.CancelFunc
 
-	pingRetries uint8
-	rest        *REST
-	ws          *websocket.Conn
-	fm          fanoutMutex
+	uri      string
+	dialOpts *websocket.DialOptions
+
+	pingRetries      uint8
+	silence          time.Duration
+	maintenanceDelay time.Duration
+	quotaBackoff     time.Duration
+	rest             *REST
+	fm               fanoutMutex
+
+	mu        sync.RWMutex
+	sess      *session // current connection, replaced on reconnect
+	closing   atomic.Bool
+	wg        sync.WaitGroup // everything Close waits on
+	state     atomic.Uint32
+	lastFrame atomic.Int64 // unix nanos
+	rtt       rttWindow
+
+	stateMu   sync.Mutex // serializes transitions
+	done      chan struct{}
+	err       error // why it closed
+	subMu     sync.Mutex
+	subSeq    int
+	stateSubs map[int]func(from, to State)
+	drainSubs map[int]func() // nil once they've run
+	drainOnce sync.Once
 
 	entityHandler     func(*EntityMsg)
 	chartHandler      func(*Chart)
 	marketDataHandler func(*MarketData)
+	shutdownHandler   func(*ShutdownMsg)
 	errHandler        func(error)
+
+	log     *slog.Logger
+	metrics Metrics
+	trace   *orderTracer
+	rec     *recorder
+	inline  bool // call handlers synchronously; only used by Replay
+
+	clOrdGen ClOrdIDGen
+	clOrds   clOrdIndex
+	md       mdRegistry // restored after a reconnect
 }
 
 func NewSocket(ctx context.Context, uri string, dialOpts *websocket.DialOptions, rest *REST, opts ...WSOpt) (*WS, error) {
@@ -93,65 +130,113 @@ func NewSocket(ctx context.Context, uri string, dialOpts *websocket.DialOptions,
 	connCtx, cancel := context.WithCancel(ctx)
 
 	s := &WS{
-		pingRetries: 5,
-		connCtx:     connCtx,
-		connCancel:  cancel,
-		rest:        rest,
+		pingRetries:      5,
+		silence:          15 * time.Second,
+		maintenanceDelay: 30 * time.Second,
+		quotaBackoff:     5 * time.Minute,
+		connCtx:          connCtx,
+		connCancel:       cancel,
+		uri:              uri,
+		dialOpts:         dialOpts,
+		rest:             rest,
+		done:             make(chan struct{}),
+		stateSubs:        map[int]func(from, to State){},
+		drainSubs:        map[int]func(){},
 		fm: fanoutMutex{
 			acc:     1,
 			timeout: time.Second * 5,
+			waiting: map[int]*socketReq{},
 		},
 		entityHandler:     func(em *EntityMsg) {},
 		chartHandler:      func(cr *Chart) {},
 		marketDataHandler: func(md *MarketData) {},
+		shutdownHandler:   func(sm *ShutdownMsg) {},
 		errHandler:        func(err error) {},
+		log:               discardLogger,
+		metrics:           nopMetrics{},
 	}
 
 	for _, v := range opts {
 		v(s)
 	}
+	s.log = s.log.With(slog.String("uri", uri))
 
-	t, err := rest.Token(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed getting initial token: %w", err)
-	}
-
-	s.ws, _, err = websocket.Dial(ctx, uri, dialOpts)
+	ss, err := s.connect(ctx)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
-	defer func() {
-		if err != nil {
-			s.ws.Close(websocket.StatusInternalError, "failed initial setup: "+err.Error())
-		}
+	s.setState(StateReady, StateAuthorizing)
+	s.wg.Add(1)
+	go s.supervise(ss)
+	go func() {
+		<-s.done
+		s.wg.Wait()
+		s.drained()
 	}()
 
-	f, err := s.readFrame(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed reading opening handshake packet with %s: %w", uri, err)
-	}
-
-	if f.frameType() != frameTypeOpen {
-		return nil, fmt.Errorf("protocol broken: frame type should be open, but got %+v", f)
-	}
-
-	go s.keepalive(connCtx)
-	return s, s.do(ctx, "authorize", nil, t.AccessToken, nil)
+	s.log.LogAttrs(ctx, slog.LevelInfo, "websocket connected")
+	return s, nil
 }
 
+// Close the connection, then wait for the read loop and any running
+// handlers to return. Don't call it from a handler, it'll wait on itself
 func (s *WS) Close() error {
-	return s.ws.Close(websocket.StatusNormalClosure, "client initiated close")
+	s.log.LogAttrs(s.connCtx, slog.LevelInfo, "closing websocket")
+	s.closing.Store(true)
+	s.finish(ErrClosed)
+
+	err := s.conn().Close(websocket.StatusNormalClosure, "client initiated close")
+	s.connCancel()
+	s.wg.Wait()
+	s.drained()
+	return err
+}
+
+func (s *WS) conn() *websocket.Conn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sess.conn
 }
 
 func (s *WS) do(ctx context.Context, path string, queryParams url.Values, body, target any) error {
+	return s.send(ctx, false, path, queryParams, body, target)
+}
+
+// send a request and wait for the response. Only authorization may
+// skip the drain and state checks, to reconnect while new requests are
+// held off
+func (s *WS) send(ctx context.Context, skipDrain bool, path string, queryParams url.Values, body, target any) (err error) {
+	if !skipDrain {
+		if err = s.ready(); err != nil {
+			return err
+		}
+	}
+
+	start, status := time.Now(), 0
 	sb := strings.Builder{}
 
 	sb.WriteString(path)
 	sb.WriteRune('\n')
 
-	mu := s.fm.request()
+	mu, err := s.fm.request(ctx, skipDrain)
+	if err != nil {
+		return err
+	}
+	defer mu.cancel() // if it never made it to wait
 	sb.WriteString(fmt.Sprint(mu.id))
+	s.metrics.InFlight(1)
+	defer s.metrics.InFlight(-1)
+	traceRequestID(ctx, mu.id)
+	defer func() {
+		logRequest(ctx, s.log, err, "ws request",
+			slog.String("path", path),
+			slog.Int("id", mu.id),
+			slog.Int("status", status),
+			since(start),
+		)
+	}()
 	sb.WriteRune('\n')
 
 	if len(queryParams) > 0 {
@@ -167,20 +252,28 @@ func (s *WS) do(ctx context.Context, path string, queryParams url.Values, body,
 	}
 
 	payload := []byte(sb.String())
-	if err := s.ws.Write(ctx, websocket.MessageText, payload); err != nil {
+	if err := s.conn().Write(ctx, websocket.MessageText, payload); err != nil {
 		return err
 	}
+	safe := redact(path, payload)
+	s.rec.record(DirectionOut, safe)
+	logPayload(ctx, s.log, "ws frame sent", safe)
 
+	start = time.Now()
 	resp, err := mu.wait(ctx, s.connCtx)
+	rtt := time.Since(start)
+	s.metrics.Request(path, rtt, err)
 	if err != nil {
 		return err
 	}
+	s.rtt.add(rtt)
+	status = resp.Status
 
 	if resp.Status >= 300 {
 		return newRespErrFromSocket(resp)
 	}
 
-	if target != nil {
+	if target != nil && len(resp.Data) > 0 {
 		return json.Unmarshal(resp.Data, target)
 	}
 
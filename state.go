@@ -0,0 +1,154 @@
+package tradovate
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+var (
+	// ErrNotReady is returned for requests made while the socket isn't
+	// Ready, rather than writing to a connection that's going away
+	ErrNotReady = errors.New("socket not ready")
+
+	// ErrClosed is what Err reports after you call Close
+	ErrClosed = errors.New("socket closed")
+)
+
+//go:generate enumer -type State -trimprefix State -json
+type State byte
+
+const (
+	StateConnecting State = iota
+	StateAuthorizing
+	StateReady
+	StateReconnecting // waiting to reconnect after the server shut the connection
+	StateDraining     // finishing requests in flight; new ones fail with ErrDraining
+	StateClosed
+)
+
+// The state the socket's in right now
+func (s *WS) State() State {
+	return State(s.state.Load())
+}
+
+// Closed once the socket is, whether you closed it or the connection
+// failed. Reconnects after a server shutdown don't close it
+func (s *WS) Done() <-chan struct{} {
+	return s.done
+}
+
+// Nil until Done is closed. After that, ErrClosed if you called Close,
+// otherwise whatever killed the connection
+func (s *WS) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Call fn on every state change from now on. Calls are synchronous and
+// in order, as the transition happens, so keep it quick and don't call
+// Close from it. Call the returned func to unsubscribe
+func (s *WS) OnStateChange(fn func(from, to State)) (unsubscribe func()) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	s.subSeq++
+	id := s.subSeq
+	s.stateSubs[id] = fn
+
+	return func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		delete(s.stateSubs, id)
+	}
+}
+
+// Call fn once the socket has closed and every handler has returned, so
+// nothing more will come from it. Close returns after fn does. If that's
+// already happened fn runs now. Call the returned func to unsubscribe
+func (s *WS) OnDrained(fn func()) (unsubscribe func()) {
+	s.subMu.Lock()
+	if s.drainSubs == nil {
+		s.subMu.Unlock()
+		fn()
+		return func() {}
+	}
+
+	s.subSeq++
+	id := s.subSeq
+	s.drainSubs[id] = fn
+	s.subMu.Unlock()
+
+	return func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		delete(s.drainSubs, id)
+	}
+}
+
+// run the drain subscribers once. Concurrent callers wait for them
+func (s *WS) drained() {
+	s.drainOnce.Do(func() {
+		s.subMu.Lock()
+		subs := slices.Collect(maps.Values(s.drainSubs))
+		s.drainSubs = nil
+		s.subMu.Unlock()
+
+		for _, fn := range subs {
+			fn()
+		}
+	})
+}
+
+// fail fast unless the socket is ready for requests
+func (s *WS) ready() error {
+	switch x := s.State(); x {
+	case StateReady:
+		return nil
+	case StateDraining:
+		return ErrDraining
+	default:
+		return fmt.Errorf("%w: socket is %s", ErrNotReady, x)
+	}
+}
+
+// move to a new state, only if the current one is in from when given.
+// Closed is final
+func (s *WS) setState(to State, from ...State) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	cur := s.State()
+	if cur == to || cur == StateClosed || len(from) > 0 && !slices.Contains(from, cur) {
+		return
+	}
+
+	s.state.Store(uint32(to))
+	if to == StateClosed {
+		close(s.done)
+	}
+
+	s.subMu.Lock()
+	subs := slices.Collect(maps.Values(s.stateSubs))
+	s.subMu.Unlock()
+
+	for _, fn := range subs {
+		fn(cur, to)
+	}
+}
+
+// close for good with the reason Err reports. The first reason wins
+func (s *WS) finish(err error) {
+	s.stateMu.Lock()
+	if s.err == nil && s.State() != StateClosed {
+		s.err = err
+	}
+	s.stateMu.Unlock()
+
+	s.setState(StateClosed)
+}
@@ -0,0 +1,112 @@
+// Code generated by "enumer -type State -trimprefix State -json"; DO NOT EDIT.
+
+package tradovate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const _StateName = "ConnectingAuthorizingReadyReconnectingDrainingClosed"
+
+var _StateIndex = [...]uint8{0, 10, 21, 26, 38, 46, 52}
+
+const _StateLowerName = "connectingauthorizingreadyreconnectingdrainingclosed"
+
+func (i State) String() string {
+	if i >= State(len(_StateIndex)-1) {
+		return fmt.Sprintf("State(%d)", i)
+	}
+	return _StateName[_StateIndex[i]:_StateIndex[i+1]]
+}
+
+// An "invalid array index" compiler error signifies that the constant values have changed.
+// Re-run the stringer command to generate them again.
+func _StateNoOp() {
+	var x [1]struct{}
+	_ = x[StateConnecting-(0)]
+	_ = x[StateAuthorizing-(1)]
+	_ = x[StateReady-(2)]
+	_ = x[StateReconnecting-(3)]
+	_ = x[StateDraining-(4)]
+	_ = x[StateClosed-(5)]
+}
+
+var _StateValues = []State{StateConnecting, StateAuthorizing, StateReady, StateReconnecting, StateDraining, StateClosed}
+
+var _StateNameToValueMap = map[string]State{
+	_StateName[0:10]:       StateConnecting,
+	_StateLowerName[0:10]:  StateConnecting,
+	_StateName[10:21]:      StateAuthorizing,
+	_StateLowerName[10:21]: StateAuthorizing,
+	_StateName[21:26]:      StateReady,
+	_StateLowerName[21:26]: StateReady,
+	_StateName[26:38]:      StateReconnecting,
+	_StateLowerName[26:38]: StateReconnecting,
+	_StateName[38:46]:      StateDraining,
+	_StateLowerName[38:46]: StateDraining,
+	_StateName[46:52]:      StateClosed,
+	_StateLowerName[46:52]: StateClosed,
+}
+
+var _StateNames = []string{
+	_StateName[0:10],
+	_StateName[10:21],
+	_StateName[21:26],
+	_StateName[26:38],
+	_StateName[38:46],
+	_StateName[46:52],
+}
+
+// StateString retrieves an enum value from the enum constants string name.
+// Throws an error if the param is not part of the enum.
+func StateString(s string) (State, error) {
+	if val, ok := _StateNameToValueMap[s]; ok {
+		return val, nil
+	}
+
+	if val, ok := _StateNameToValueMap[strings.ToLower(s)]; ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("%s does not belong to State values", s)
+}
+
+// StateValues returns all values of the enum
+func StateValues() []State {
+	return _StateValues
+}
+
+// StateStrings returns a slice of all String values of the enum
+func StateStrings() []string {
+	strs := make([]string, len(_StateNames))
+	copy(strs, _StateNames)
+	return strs
+}
+
+// IsAState returns "true" if the value is listed in the enum definition. "false" otherwise
+func (i State) IsAState() bool {
+	for _, v := range _StateValues {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON implements the json.Marshaler interface for State
+func (i State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for State
+func (i *State) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("State should be a string, got %s", data)
+	}
+
+	var err error
+	*i, err = StateString(s)
+	return err
+}
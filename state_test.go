@@ -0,0 +1,138 @@
+package tradovate_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestState(mainTest *testing.T) {
+	testCases := []struct {
+		name          string
+		act           func(*tradovatetest.Server, *tradovate.WS)
+		expected      []tradovate.State
+		expectedClose error // nil if it should still be open
+	}{
+		{
+			name: "shutdown reconnects",
+			act: func(srv *tradovatetest.Server, _ *tradovate.WS) {
+				srv.PushShutdown(tradovate.ShutdownCodeMaintenance, "")
+			},
+			expected: []tradovate.State{
+				tradovate.StateDraining,
+				tradovate.StateReconnecting,
+				tradovate.StateConnecting,
+				tradovate.StateAuthorizing,
+				tradovate.StateReady,
+			},
+		},
+		{
+			name:          "close",
+			act:           func(_ *tradovatetest.Server, ws *tradovate.WS) { ws.Close() },
+			expected:      []tradovate.State{tradovate.StateClosed},
+			expectedClose: tradovate.ErrClosed,
+		},
+		{
+			name:     "server drops the connection",
+			act:      func(srv *tradovatetest.Server, _ *tradovate.WS) { srv.Disconnect() },
+			expected: []tradovate.State{tradovate.StateClosed},
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			srv := tradovatetest.NewServer()
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			ws, err := srv.NewSocket(ctx, tradovate.WithMaintenanceDelay(10*time.Millisecond))
+			if err != nil {
+				tt.Fatalf("failed connecting: %v", err)
+			}
+			defer ws.Close()
+
+			if s := ws.State(); s != tradovate.StateReady {
+				tt.Fatalf("should be ready once connected, got %s", s)
+			}
+
+			var mu sync.Mutex
+			var got []tradovate.State
+			settled := make(chan struct{})
+			ws.OnStateChange(func(from, to tradovate.State) {
+				mu.Lock()
+				defer mu.Unlock()
+				got = append(got, to)
+				if len(got) == len(tc.expected) {
+					close(settled)
+				}
+			})
+
+			tc.act(srv, ws)
+
+			select {
+			case <-settled:
+			case <-ctx.Done():
+				tt.Fatal("never reached the final state")
+			}
+
+			mu.Lock()
+			if !reflect.DeepEqual(tc.expected, got) {
+				tt.Errorf("wrong transitions: want %v got %v", tc.expected, got)
+			}
+			mu.Unlock()
+
+			final := tc.expected[len(tc.expected)-1]
+			if final != tradovate.StateClosed {
+				if err = ws.Err(); err != nil {
+					tt.Errorf("should still be open, got %v", err)
+				}
+				return
+			}
+
+			<-ws.Done()
+			if err = ws.Err(); err == nil || tc.expectedClose != nil && !errors.Is(err, tc.expectedClose) {
+				tt.Errorf("wrong close reason: want %v got %v", tc.expectedClose, err)
+			}
+
+			if _, err = ws.ListAccounts(ctx); !errors.Is(err, tradovate.ErrNotReady) {
+				tt.Errorf("requests should fail fast once closed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOnDrained(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+
+	var calls int
+	ws.OnDrained(func() { calls++ })
+	ws.OnDrained(func() { t.Error("unsubscribed, shouldn't run") })()
+
+	ws.Close()
+	if calls != 1 {
+		t.Errorf("should run once before Close returns, got %d calls", calls)
+	}
+
+	late := false
+	ws.OnDrained(func() { late = true })
+	if !late {
+		t.Error("subscribing after the drain should run right away")
+	}
+}
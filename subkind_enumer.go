@@ -0,0 +1,108 @@
+// Code generated by "enumer -type SubKind -trimprefix SubKind -json"; DO NOT EDIT.
+
+package tradovate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const _SubKindName = "UnspecifiedQuoteDOMHistogramChart"
+
+var _SubKindIndex = [...]uint8{0, 11, 16, 19, 28, 33}
+
+const _SubKindLowerName = "unspecifiedquotedomhistogramchart"
+
+func (i SubKind) String() string {
+	if i >= SubKind(len(_SubKindIndex)-1) {
+		return fmt.Sprintf("SubKind(%d)", i)
+	}
+	return _SubKindName[_SubKindIndex[i]:_SubKindIndex[i+1]]
+}
+
+// An "invalid array index" compiler error signifies that the constant values have changed.
+// Re-run the stringer command to generate them again.
+func _SubKindNoOp() {
+	var x [1]struct{}
+	_ = x[SubKindUnspecified-(0)]
+	_ = x[SubKindQuote-(1)]
+	_ = x[SubKindDOM-(2)]
+	_ = x[SubKindHistogram-(3)]
+	_ = x[SubKindChart-(4)]
+}
+
+var _SubKindValues = []SubKind{SubKindUnspecified, SubKindQuote, SubKindDOM, SubKindHistogram, SubKindChart}
+
+var _SubKindNameToValueMap = map[string]SubKind{
+	_SubKindName[0:11]:       SubKindUnspecified,
+	_SubKindLowerName[0:11]:  SubKindUnspecified,
+	_SubKindName[11:16]:      SubKindQuote,
+	_SubKindLowerName[11:16]: SubKindQuote,
+	_SubKindName[16:19]:      SubKindDOM,
+	_SubKindLowerName[16:19]: SubKindDOM,
+	_SubKindName[19:28]:      SubKindHistogram,
+	_SubKindLowerName[19:28]: SubKindHistogram,
+	_SubKindName[28:33]:      SubKindChart,
+	_SubKindLowerName[28:33]: SubKindChart,
+}
+
+var _SubKindNames = []string{
+	_SubKindName[0:11],
+	_SubKindName[11:16],
+	_SubKindName[16:19],
+	_SubKindName[19:28],
+	_SubKindName[28:33],
+}
+
+// SubKindString retrieves an enum value from the enum constants string name.
+// Throws an error if the param is not part of the enum.
+func SubKindString(s string) (SubKind, error) {
+	if val, ok := _SubKindNameToValueMap[s]; ok {
+		return val, nil
+	}
+
+	if val, ok := _SubKindNameToValueMap[strings.ToLower(s)]; ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("%s does not belong to SubKind values", s)
+}
+
+// SubKindValues returns all values of the enum
+func SubKindValues() []SubKind {
+	return _SubKindValues
+}
+
+// SubKindStrings returns a slice of all String values of the enum
+func SubKindStrings() []string {
+	strs := make([]string, len(_SubKindNames))
+	copy(strs, _SubKindNames)
+	return strs
+}
+
+// IsASubKind returns "true" if the value is listed in the enum definition. "false" otherwise
+func (i SubKind) IsASubKind() bool {
+	for _, v := range _SubKindValues {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON implements the json.Marshaler interface for SubKind
+func (i SubKind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for SubKind
+func (i *SubKind) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("SubKind should be a string, got %s", data)
+	}
+
+	var err error
+	*i, err = SubKindString(s)
+	return err
+}
@@ -0,0 +1,264 @@
+package tradovate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+//go:generate enumer -type SubKind -trimprefix SubKind -json
+type SubKind byte
+
+const (
+	SubKindUnspecified SubKind = iota
+	SubKindQuote
+	SubKindDOM
+	SubKindHistogram
+	SubKindChart
+)
+
+// Release gives back a subscription. The last release unsubscribes; if
+// that fails the subscription is still held, so release it again to
+// retry. Calling it after it succeeded is a no-op
+type Release func(ctx context.Context) error
+
+// ActiveSub is a subscription someone's holding
+type ActiveSub struct {
+	Kind       SubKind
+	ContractID int
+	Symbol     string // empty if only ever acquired by ID
+	Refs       int
+	Chart      *ChartReq // chart subscriptions only
+	ChartResp  ChartResp // chart subscriptions only
+}
+
+// SubscriptionManager shares market data subscriptions between everything
+// in a process using the same WS. Subscriptions are counted per contract,
+// whether they were acquired by symbol or ID, so the server only sees a
+// subscribe on the first acquire and an unsubscribe on the last release.
+//
+// Don't mix it with the WS's own Subscribe/Unsubscribe calls for the same
+// contracts, or an unsubscribe there will pull the feed out from under it
+type SubscriptionManager struct {
+	s *WS
+
+	mu      sync.Mutex
+	subs    map[subKey]*subEntry
+	ids     map[string]int // symbol -> contract ID
+	symbols map[int]string // contract ID -> symbol
+}
+
+type subKey struct {
+	kind       SubKind
+	contractID int
+	chart      ChartReq
+}
+
+type subEntry struct {
+	mu    sync.Mutex // held across the subscribe/unsubscribe round trip
+	refs  int
+	chart ChartResp
+	gone  bool // out of the map; acquires that raced it find another
+}
+
+func NewSubscriptionManager(s *WS) *SubscriptionManager {
+	return &SubscriptionManager{
+		s:       s,
+		subs:    map[subKey]*subEntry{},
+		ids:     map[string]int{},
+		symbols: map[int]string{},
+	}
+}
+
+// Acquire a quote, DOM or histogram subscription by symbol. Use
+// AcquireChart for charts
+func (m *SubscriptionManager) Acquire(ctx context.Context, kind SubKind, symbol string) (Release, error) {
+	id, err := m.resolve(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.AcquireID(ctx, kind, id)
+}
+
+// Acquire a quote, DOM or histogram subscription by contract ID. Use
+// AcquireChartID for charts
+func (m *SubscriptionManager) AcquireID(ctx context.Context, kind SubKind, id int) (Release, error) {
+	var sub, unsub func(context.Context, int) error
+	switch kind {
+	case SubKindQuote:
+		sub = func(ctx context.Context, id int) error { _, err := m.s.SubscribeQuoteID(ctx, id); return err }
+		unsub = m.s.UnsubscribeQuoteID
+	case SubKindDOM:
+		sub, unsub = m.s.SubscribeDOMID, m.s.UnsubscribeDOMID
+	case SubKindHistogram:
+		sub, unsub = m.s.SubscribeHistogramID, m.s.UnsubscribeHistogramID
+	default:
+		return nil, fmt.Errorf("can't acquire a %s subscription with Acquire", kind)
+	}
+
+	_, release, err := m.acquire(ctx, subKey{kind: kind, contractID: id},
+		func(ctx context.Context) (ChartResp, error) { return ChartResp{}, sub(ctx, id) },
+		func(ctx context.Context, _ ChartResp) error { return unsub(ctx, id) },
+	)
+	return release, err
+}
+
+// Acquire a chart subscription by symbol. Everyone asking for the same
+// contract and request shares one chart, and gets the same ChartResp
+func (m *SubscriptionManager) AcquireChart(ctx context.Context, symbol string, r *ChartReq) (ChartResp, Release, error) {
+	id, err := m.resolve(ctx, symbol)
+	if err != nil {
+		return ChartResp{}, nil, err
+	}
+
+	return m.AcquireChartID(ctx, id, r)
+}
+
+// Acquire a chart subscription by contract ID. Everyone asking for the
+// same contract and request shares one chart, and gets the same ChartResp
+func (m *SubscriptionManager) AcquireChartID(ctx context.Context, id int, r *ChartReq) (ChartResp, Release, error) {
+	if r == nil {
+		return ChartResp{}, nil, fmt.Errorf("no chart request passed")
+	}
+
+	return m.acquire(ctx, subKey{kind: SubKindChart, contractID: id, chart: *r},
+		func(ctx context.Context) (ChartResp, error) { return m.s.GetChartID(ctx, id, r) },
+		func(ctx context.Context, c ChartResp) error { return m.s.CancelChart(ctx, c.HistoricalID) },
+	)
+}
+
+// Every subscription with at least one holder
+func (m *SubscriptionManager) Active() []ActiveSub {
+	m.mu.Lock()
+	keys := make([]subKey, 0, len(m.subs))
+	entries := make([]*subEntry, 0, len(m.subs))
+	for k, v := range m.subs {
+		keys, entries = append(keys, k), append(entries, v)
+	}
+	m.mu.Unlock()
+
+	var x []ActiveSub
+	for i, k := range keys {
+		e := entries[i]
+		e.mu.Lock()
+		refs, chart := e.refs, e.chart
+		e.mu.Unlock()
+
+		if refs == 0 {
+			continue
+		}
+
+		a := ActiveSub{Kind: k.kind, ContractID: k.contractID, Symbol: m.symbol(k.contractID), Refs: refs}
+		if k.kind == SubKindChart {
+			a.Chart, a.ChartResp = &k.chart, chart
+		}
+		x = append(x, a)
+	}
+
+	slices.SortFunc(x, func(a, b ActiveSub) int {
+		if a.ContractID != b.ContractID {
+			return a.ContractID - b.ContractID
+		}
+		return int(a.Kind) - int(b.Kind)
+	})
+	return x
+}
+
+func (m *SubscriptionManager) acquire(
+	ctx context.Context,
+	k subKey,
+	sub func(context.Context) (ChartResp, error),
+	unsub func(context.Context, ChartResp) error,
+) (ChartResp, Release, error) {
+	var e *subEntry
+	for e == nil || e.gone {
+		if e != nil {
+			e.mu.Unlock()
+		}
+
+		m.mu.Lock()
+		var ok bool
+		if e, ok = m.subs[k]; !ok {
+			e = &subEntry{}
+			m.subs[k] = e
+		}
+		m.mu.Unlock()
+
+		e.mu.Lock()
+	}
+	defer e.mu.Unlock()
+
+	if e.refs == 0 {
+		c, err := sub(ctx)
+		if err != nil {
+			m.remove(k, e)
+			return ChartResp{}, nil, err
+		}
+		e.chart = c
+	}
+	e.refs++
+
+	var released bool // under e.mu
+	release := func(ctx context.Context) error {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		if released {
+			return nil
+		}
+
+		if e.refs == 1 {
+			if err := unsub(ctx, e.chart); err != nil {
+				return err
+			}
+			m.remove(k, e)
+		}
+
+		e.refs--
+		released = true
+		return nil
+	}
+
+	return e.chart, release, nil
+}
+
+// drop an entry nobody holds, so churning contracts don't pile up. e.mu
+// held
+func (m *SubscriptionManager) remove(k subKey, e *subEntry) {
+	e.gone = true
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.subs[k] == e {
+		delete(m.subs, k)
+	}
+}
+
+// contract ID for a symbol, looked up once
+func (m *SubscriptionManager) resolve(ctx context.Context, symbol string) (int, error) {
+	m.mu.Lock()
+	id, ok := m.ids[symbol]
+	m.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	c, err := m.s.FindContract(ctx, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed resolving %s: %w", symbol, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ids[symbol], m.symbols[c.ID] = c.ID, symbol
+	return c.ID, nil
+}
+
+// symbol for a contract ID, if it was ever acquired by symbol
+func (m *SubscriptionManager) symbol(id int) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.symbols[id]
+}
@@ -0,0 +1,231 @@
+package tradovate_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+func TestSubscriptionManager(mainTest *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+	srv.SetContracts(&tradovate.Contract{ID: 1, Name: "ESZ5"}, &tradovate.Contract{ID: 2, Name: "NQZ5"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		mainTest.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	testCases := []struct {
+		name      string
+		kind      tradovate.SubKind
+		sub       string
+		unsub     string
+		acquire   []func(m *tradovate.SubscriptionManager) (tradovate.Release, error)
+		expectErr bool
+	}{
+		{
+			name:  "symbol and ID share a quote subscription",
+			kind:  tradovate.SubKindQuote,
+			sub:   "md/subscribeQuote",
+			unsub: "md/unsubscribeQuote",
+			acquire: []func(m *tradovate.SubscriptionManager) (tradovate.Release, error){
+				func(m *tradovate.SubscriptionManager) (tradovate.Release, error) {
+					return m.Acquire(ctx, tradovate.SubKindQuote, "ESZ5")
+				},
+				func(m *tradovate.SubscriptionManager) (tradovate.Release, error) {
+					return m.AcquireID(ctx, tradovate.SubKindQuote, 1)
+				},
+				func(m *tradovate.SubscriptionManager) (tradovate.Release, error) {
+					return m.Acquire(ctx, tradovate.SubKindQuote, "ESZ5")
+				},
+			},
+		},
+		{
+			name:  "charts with the same request are shared",
+			kind:  tradovate.SubKindChart,
+			sub:   "md/getchart",
+			unsub: "md/cancelchart",
+			acquire: []func(m *tradovate.SubscriptionManager) (tradovate.Release, error){
+				func(m *tradovate.SubscriptionManager) (tradovate.Release, error) {
+					_, r, err := m.AcquireChart(ctx, "NQZ5", &tradovate.ChartReq{UnderlyingType: tradovate.ChartTypeTick, AsMuchAsElements: 10})
+					return r, err
+				},
+				func(m *tradovate.SubscriptionManager) (tradovate.Release, error) {
+					_, r, err := m.AcquireChartID(ctx, 2, &tradovate.ChartReq{UnderlyingType: tradovate.ChartTypeTick, AsMuchAsElements: 10})
+					return r, err
+				},
+			},
+		},
+		{
+			name: "unknown symbol",
+			kind: tradovate.SubKindDOM,
+			acquire: []func(m *tradovate.SubscriptionManager) (tradovate.Release, error){
+				func(m *tradovate.SubscriptionManager) (tradovate.Release, error) {
+					return m.Acquire(ctx, tradovate.SubKindDOM, "nope")
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			m := tradovate.NewSubscriptionManager(ws)
+			subs, unsubs := len(srv.RequestsFor(tc.sub)), len(srv.RequestsFor(tc.unsub))
+
+			var releases []tradovate.Release
+			for _, fn := range tc.acquire {
+				r, err := fn(m)
+				if (err != nil) != tc.expectErr {
+					tt.Fatalf("wanted error %v, got %v", tc.expectErr, err)
+				}
+				releases = append(releases, r)
+			}
+
+			if tc.expectErr {
+				if a := m.Active(); len(a) != 0 {
+					tt.Errorf("failed acquires shouldn't be active, got %+v", a)
+				}
+				return
+			}
+
+			if n := len(srv.RequestsFor(tc.sub)) - subs; n != 1 {
+				tt.Errorf("wanted a single subscribe, got %d", n)
+			}
+
+			active := m.Active()
+			if len(active) != 1 || active[0].Kind != tc.kind || active[0].Refs != len(releases) {
+				tt.Fatalf("wanted one %s sub with %d refs, got %+v", tc.kind, len(releases), active)
+			}
+
+			for i, r := range releases {
+				if err := r(ctx); err != nil {
+					tt.Fatalf("failed releasing: %v", err)
+				}
+				r(ctx) // again, should be a no-op
+
+				n, last := len(srv.RequestsFor(tc.unsub))-unsubs, i == len(releases)-1
+				if last && n != 1 || !last && n != 0 {
+					tt.Errorf("after %d releases wanted unsubscribe only on the last, got %d", i+1, n)
+				}
+			}
+
+			if a := m.Active(); len(a) != 0 {
+				tt.Errorf("everything's released, got %+v", a)
+			}
+		})
+	}
+}
+
+func TestSubscriptionReleaseRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := tradovatetest.NewServer(tradovatetest.WithHandler("md/unsubscribeDOM", func(*tradovatetest.Request) tradovatetest.Response {
+		if calls.Add(1) == 1 {
+			return tradovatetest.Response{Status: http.StatusInternalServerError, Body: "try again"}
+		}
+		return tradovatetest.Response{Status: http.StatusOK}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	m := tradovate.NewSubscriptionManager(ws)
+	release, err := m.AcquireID(ctx, tradovate.SubKindDOM, 1)
+	if err != nil {
+		t.Fatalf("failed acquiring: %v", err)
+	}
+
+	if err = release(ctx); err == nil {
+		t.Fatal("wanted the failed unsubscribe's error")
+	}
+
+	if a := m.Active(); len(a) != 1 || a[0].Refs != 1 {
+		t.Errorf("a failed release should keep the subscription, got %+v", a)
+	}
+
+	if err = release(ctx); err != nil {
+		t.Fatalf("retrying the release should unsubscribe, got %v", err)
+	}
+
+	if a := m.Active(); len(a) != 0 {
+		t.Errorf("everything's released, got %+v", a)
+	}
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("wanted the unsubscribe retried once, got %d calls", n)
+	}
+}
+
+func TestSubscriptionChurn(t *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := srv.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	// released entries are dropped while others are acquiring them
+	m := tradovate.NewSubscriptionManager(ws)
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				release, err := m.AcquireID(ctx, tradovate.SubKindDOM, i%2)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if err = release(ctx); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if a, subs := m.Active(), srv.Subscriptions(); len(a) != 0 || len(subs) != 0 {
+		t.Errorf("everything's released, got %+v and %+v upstream", a, subs)
+	}
+
+	subs, unsubs := len(srv.RequestsFor("md/subscribeDOM")), len(srv.RequestsFor("md/unsubscribeDOM"))
+	if subs != unsubs {
+		t.Errorf("every subscribe should be undone once, got %d subscribes and %d unsubscribes", subs, unsubs)
+	}
+
+	release, err := m.AcquireID(ctx, tradovate.SubKindDOM, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release(ctx)
+
+	if a := m.Active(); len(a) != 1 || a[0].Refs != 1 {
+		t.Errorf("acquiring after everything's released should subscribe again, got %+v", a)
+	}
+}
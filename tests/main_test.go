@@ -26,7 +26,7 @@ type config struct {
 
 type account struct {
 	Spec string `yaml:"spec"`
-	ID   int    `yaml:"id"`
+	ID   uint   `yaml:"id"`
 }
 
 type creds struct {
@@ -45,7 +45,7 @@ type client struct {
 	ctx          context.Context
 	md, api      *tradovate.WS
 	spec         string
-	id           int
+	id           uint
 	chartChannel chan *tradovate.Chart
 }
 
@@ -0,0 +1,100 @@
+// Code generated by "enumer -type TickRounding -trimprefix TickRounding -json"; DO NOT EDIT.
+
+package tradovate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const _TickRoundingName = "RejectNearestPassive"
+
+var _TickRoundingIndex = [...]uint8{0, 6, 13, 20}
+
+const _TickRoundingLowerName = "rejectnearestpassive"
+
+func (i TickRounding) String() string {
+	if i >= TickRounding(len(_TickRoundingIndex)-1) {
+		return fmt.Sprintf("TickRounding(%d)", i)
+	}
+	return _TickRoundingName[_TickRoundingIndex[i]:_TickRoundingIndex[i+1]]
+}
+
+// An "invalid array index" compiler error signifies that the constant values have changed.
+// Re-run the stringer command to generate them again.
+func _TickRoundingNoOp() {
+	var x [1]struct{}
+	_ = x[TickRoundingReject-(0)]
+	_ = x[TickRoundingNearest-(1)]
+	_ = x[TickRoundingPassive-(2)]
+}
+
+var _TickRoundingValues = []TickRounding{TickRoundingReject, TickRoundingNearest, TickRoundingPassive}
+
+var _TickRoundingNameToValueMap = map[string]TickRounding{
+	_TickRoundingName[0:6]:        TickRoundingReject,
+	_TickRoundingLowerName[0:6]:   TickRoundingReject,
+	_TickRoundingName[6:13]:       TickRoundingNearest,
+	_TickRoundingLowerName[6:13]:  TickRoundingNearest,
+	_TickRoundingName[13:20]:      TickRoundingPassive,
+	_TickRoundingLowerName[13:20]: TickRoundingPassive,
+}
+
+var _TickRoundingNames = []string{
+	_TickRoundingName[0:6],
+	_TickRoundingName[6:13],
+	_TickRoundingName[13:20],
+}
+
+// TickRoundingString retrieves an enum value from the enum constants string name.
+// Throws an error if the param is not part of the enum.
+func TickRoundingString(s string) (TickRounding, error) {
+	if val, ok := _TickRoundingNameToValueMap[s]; ok {
+		return val, nil
+	}
+
+	if val, ok := _TickRoundingNameToValueMap[strings.ToLower(s)]; ok {
+		return val, nil
+	}
+	return 0, fmt.Errorf("%s does not belong to TickRounding values", s)
+}
+
+// TickRoundingValues returns all values of the enum
+func TickRoundingValues() []TickRounding {
+	return _TickRoundingValues
+}
+
+// TickRoundingStrings returns a slice of all String values of the enum
+func TickRoundingStrings() []string {
+	strs := make([]string, len(_TickRoundingNames))
+	copy(strs, _TickRoundingNames)
+	return strs
+}
+
+// IsATickRounding returns "true" if the value is listed in the enum definition. "false" otherwise
+func (i TickRounding) IsATickRounding() bool {
+	for _, v := range _TickRoundingValues {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON implements the json.Marshaler interface for TickRounding
+func (i TickRounding) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for TickRounding
+func (i *TickRounding) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("TickRounding should be a string, got %s", data)
+	}
+
+	var err error
+	*i, err = TickRoundingString(s)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -26,6 +27,16 @@ type Token struct {
 	HasLive                bool      `json:"hasLive"`
 }
 
+// Keeps the access token out of logs
+func (t *Token) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("accessToken", "<redacted>"),
+		slog.Time("expirationTime", t.ExpirationTime),
+		slog.Int("userId", t.UserID),
+		slog.String("name", t.Name),
+	)
+}
+
 // Check if token is expired
 func (t *Token) Expired() bool {
 	return time.Now().After(t.ExpirationTime)
@@ -46,6 +57,13 @@ func (t *tokenManager) SetToken(token *Token) {
 	t.token = token
 }
 
+// The token the client has now, if any, without fetching or refreshing
+func (t *tokenManager) CachedToken() *Token {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.token
+}
+
 // Fetches a token using the following steps:
 //  1. If no token is set: return a new, fresh token (you can
 //     avoid this by SetToken if you already have one)
@@ -97,7 +115,9 @@ func (t *tokenResp) toToken() *Token {
 	}
 }
 
-func (r *REST) newToken(ctx context.Context) (*Token, error) {
+func (r *REST) newToken(ctx context.Context) (t *Token, err error) {
+	defer func() { r.observeToken(ctx, "fetch", t, err) }()
+
 	buf, err := json.Marshal(r.tokenManager.creds)
 	if err != nil {
 		return nil, err
@@ -125,16 +145,16 @@ func (r *REST) newToken(ctx context.Context) (*Token, error) {
 		return nil, newRespErrFromREST(resp)
 	}
 
-	var t tokenResp
-	if err = json.NewDecoder(resp.Body).Decode(&t); err != nil {
+	var tr tokenResp
+	if err = json.NewDecoder(resp.Body).Decode(&tr); err != nil {
 		return nil, err
 	}
 
-	if t.ErrorText != "" {
-		return nil, &RespErr{Status: 200, Body: t.ErrorText}
+	if tr.ErrorText != "" {
+		return nil, &RespErr{Status: 200, Body: tr.ErrorText}
 	}
 
-	newToken := t.toToken()
+	newToken := tr.toToken()
 	r.tokenManager.mu.Lock()
 	defer r.tokenManager.mu.Unlock()
 
@@ -142,7 +162,9 @@ func (r *REST) newToken(ctx context.Context) (*Token, error) {
 	return newToken, nil
 }
 
-func (r *REST) refreshToken(ctx context.Context) (*Token, error) {
+func (r *REST) refreshToken(ctx context.Context) (t *Token, err error) {
+	defer func() { r.observeToken(ctx, "refresh", t, err) }()
+
 	req, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodGet,
@@ -176,3 +198,13 @@ func (r *REST) refreshToken(ctx context.Context) (*Token, error) {
 	r.tokenManager.token = newToken
 	return newToken, nil
 }
+
+func (r *REST) observeToken(ctx context.Context, action string, t *Token, err error) {
+	r.metrics.TokenRefresh(action, err)
+	if err != nil {
+		r.log.LogAttrs(ctx, slog.LevelError, "failed to "+action+" access token", slog.Any("err", err))
+		return
+	}
+
+	r.log.LogAttrs(ctx, slog.LevelInfo, action+"ed access token", slog.Any("token", t))
+}
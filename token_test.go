@@ -100,7 +100,9 @@ func TestToken(mainTest *testing.T) {
 					forceRefreshDeadline: time.Hour,
 					token:                tc.start,
 				},
-				h: &http.Client{},
+				h:       &http.Client{},
+				log:     discardLogger,
+				metrics: nopMetrics{},
 			}
 
 			actual, actualErr := r.Token(context.Background())
@@ -124,3 +126,17 @@ func TestToken(mainTest *testing.T) {
 		})
 	}
 }
+
+func TestCachedToken(t *testing.T) {
+	var r REST
+	if r.CachedToken() != nil {
+		t.Error("no token should be cached yet")
+	}
+
+	// expired, so Token would fetch; CachedToken never does
+	expired := &Token{AccessToken: "old", ExpirationTime: time.Now().Add(-time.Hour)}
+	r.SetToken(expired)
+	if r.CachedToken() != expired {
+		t.Error("should return the token as is")
+	}
+}
@@ -0,0 +1,384 @@
+package tradovate
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Tracer starts a span for each order request. It's shaped after
+// OpenTelemetry's tracer so adapting one takes a few lines, without
+// this package depending on it. Implementations must be safe for
+// concurrent use and must not call back into the WS
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// Span for a single order request. After the response it stays open,
+// collecting an event for every Order, ExecutionReport, Fill and Command
+// that arrives for its orders, so placement-to-ack-to-fill timing shows
+// up in one place
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	AddEvent(name string, at time.Time, attrs ...slog.Attr)
+	End(err error)
+}
+
+// How long a span waits for its orders to finish after the response.
+// Spans end sooner when every order they watch is done, or when a
+// cancel or modify is acknowledged
+const traceLinger = time.Minute
+
+// How many unmatched order events to keep, for spans whose order ID
+// arrives after its first events do
+const traceBacklog = 256
+
+// Trace PlaceOrder, OCO, OSO, CancelOrder and ModifyOrder calls with t.
+// Spans are named after the method, e.g. tradovate.PlaceOrder, and
+// correlate entity events to the call by order ID and clOrdId
+func WithTracer(t Tracer) WSOpt {
+	return func(s *WS) {
+		if t != nil {
+			s.trace = &orderTracer{
+				t:       t,
+				byOrder: map[uint][]*orderSpan{},
+				byClOrd: map[string]*orderSpan{},
+			}
+		}
+	}
+}
+
+type spanKey struct{}
+
+// tag the span in ctx, if any, with the request ID
+func traceRequestID(ctx context.Context, id int) {
+	if sp, ok := ctx.Value(spanKey{}).(Span); ok {
+		sp.SetAttributes(slog.Int("request.id", id))
+	}
+}
+
+type orderTracer struct {
+	t Tracer
+
+	mu      sync.Mutex
+	byOrder map[uint][]*orderSpan
+	byClOrd map[string]*orderSpan
+	backlog []*traceEvent // events no span wanted yet, oldest first
+}
+
+type orderSpan struct {
+	tr      *orderTracer
+	span    Span
+	start   time.Time
+	clOrdID string
+	ackExec []string // exec types that finish the span, e.g. Replaced for a modify
+
+	orders    map[uint]bool // watched order -> done
+	responded bool
+	acked     bool
+	err       error
+	timer     *time.Timer
+	ended     bool
+}
+
+type traceEvent struct {
+	at       time.Time
+	name     string
+	orderID  uint
+	clOrdID  string
+	execType string
+	done     bool
+	attrs    []slog.Attr
+}
+
+func (s *WS) traceOrder(ctx context.Context, name, clOrdID string, attrs ...slog.Attr) (context.Context, *orderSpan) {
+	if s.trace == nil {
+		return ctx, nil
+	}
+
+	if clOrdID != "" {
+		attrs = append(attrs, slog.String("clOrdId", clOrdID))
+	}
+
+	ctx, span := s.trace.t.Start(ctx, "tradovate."+name, attrs...)
+	sp := &orderSpan{
+		tr:      s.trace,
+		span:    span,
+		start:   time.Now(),
+		clOrdID: clOrdID,
+		orders:  map[uint]bool{},
+	}
+
+	if clOrdID != "" {
+		s.trace.mu.Lock()
+		s.trace.byClOrd[clOrdID] = sp
+		s.trace.mu.Unlock()
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), sp
+}
+
+func orderAttrs(accountID uint, symbol string, action Action, qty uint32, t OrderType) []slog.Attr {
+	return []slog.Attr{
+		slog.Uint64("account.id", uint64(accountID)),
+		slog.String("symbol", symbol),
+		slog.String("action", action.String()),
+		slog.Uint64("qty", uint64(qty)),
+		slog.String("order.type", t.String()),
+	}
+}
+
+// finish the span once one of these exec types arrives
+func (sp *orderSpan) ackOn(execTypes ...string) {
+	if sp != nil {
+		sp.ackExec = execTypes
+	}
+}
+
+// collect events for these orders, including any that arrived before
+// the span knew about them
+func (sp *orderSpan) watch(ids ...uint) {
+	if sp == nil {
+		return
+	}
+
+	sp.tr.mu.Lock()
+	defer sp.tr.mu.Unlock()
+	sp.watchLocked(ids...)
+}
+
+func (sp *orderSpan) watchLocked(ids ...uint) {
+	for _, id := range ids {
+		if _, ok := sp.orders[id]; ok || id == 0 || sp.ended {
+			continue
+		}
+
+		sp.orders[id] = false
+		sp.tr.byOrder[id] = append(sp.tr.byOrder[id], sp)
+
+		for _, e := range sp.tr.backlog {
+			if e.orderID == id && !e.at.Before(sp.start) {
+				sp.event(e)
+			}
+		}
+	}
+}
+
+// record the response. Order rejections and errors end the span, unless
+// the error left it unclear whether the order exists and there's a
+// clOrdId to find it by
+func (sp *orderSpan) done(err error, attrs ...slog.Attr) {
+	if sp == nil {
+		return
+	}
+
+	sp.tr.mu.Lock()
+	defer sp.tr.mu.Unlock()
+
+	var oe *OrderErr
+	rejected := errors.As(err, &oe)
+
+	result := OrderErrReasonSuccess.String()
+	switch {
+	case rejected:
+		result = oe.Reason.String()
+	case err != nil:
+		result = "error"
+	}
+
+	sp.span.SetAttributes(append(attrs, slog.String("result", result))...)
+	sp.span.AddEvent("response", time.Now(), slog.String("result", result))
+	sp.responded, sp.err = true, err
+
+	switch {
+	case rejected, err != nil && sp.clOrdID == "":
+		sp.end(err)
+	case sp.finished():
+		sp.end(nil)
+	default:
+		sp.timer = time.AfterFunc(traceLinger, sp.expire)
+	}
+}
+
+func (sp *orderSpan) expire() {
+	sp.tr.mu.Lock()
+	defer sp.tr.mu.Unlock()
+	sp.end(sp.err)
+}
+
+func (sp *orderSpan) finished() bool {
+	if !sp.responded {
+		return false
+	}
+
+	if sp.acked {
+		return true
+	}
+
+	for _, done := range sp.orders {
+		if !done {
+			return false
+		}
+	}
+
+	return len(sp.orders) > 0
+}
+
+func (sp *orderSpan) event(e *traceEvent) {
+	if sp.ended {
+		return
+	}
+
+	sp.span.AddEvent(e.name, e.at, e.attrs...)
+
+	if e.done {
+		sp.orders[e.orderID] = true
+	}
+
+	if slices.Contains(sp.ackExec, e.execType) {
+		sp.acked = true
+	}
+
+	if sp.finished() {
+		sp.end(nil)
+	}
+}
+
+func (sp *orderSpan) end(err error) {
+	if sp.ended {
+		return
+	}
+	sp.ended = true
+
+	if sp.timer != nil {
+		sp.timer.Stop()
+	}
+
+	for id := range sp.orders {
+		x := slices.DeleteFunc(sp.tr.byOrder[id], func(v *orderSpan) bool { return v == sp })
+		if len(x) == 0 {
+			delete(sp.tr.byOrder, id)
+		} else {
+			sp.tr.byOrder[id] = x
+		}
+	}
+
+	if sp.tr.byClOrd[sp.clOrdID] == sp {
+		delete(sp.tr.byClOrd, sp.clOrdID)
+	}
+
+	sp.span.End(err)
+}
+
+// pass an entity to the spans watching its order
+func (t *orderTracer) entity(msg *EntityMsg) {
+	if t == nil {
+		return
+	}
+
+	e := newTraceEvent(msg)
+	if e == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// a command is the only link from a clOrdId to its order
+	if sp, ok := t.byClOrd[e.clOrdID]; ok && e.clOrdID != "" {
+		sp.watchLocked(e.orderID)
+	}
+
+	spans := slices.Clone(t.byOrder[e.orderID])
+	for _, sp := range spans {
+		sp.event(e)
+	}
+
+	if len(spans) == 0 {
+		if len(t.backlog) == traceBacklog {
+			t.backlog = t.backlog[1:]
+		}
+		t.backlog = append(t.backlog, e)
+	}
+}
+
+// nil for entities that aren't about orders, or don't decode
+func newTraceEvent(msg *EntityMsg) *traceEvent {
+	e := &traceEvent{at: time.Now()}
+
+	switch msg.Type {
+	case EntityTypeOrder:
+		o, err := msg.Order()
+		if err != nil {
+			return nil
+		}
+
+		e.name, e.orderID, e.done = "order", o.ID, finalStatus(o.Status)
+		e.attrs = []slog.Attr{slog.String("status", o.Status.String())}
+	case EntityTypeExecutionReport:
+		r, err := msg.ExecutionReport()
+		if err != nil {
+			return nil
+		}
+
+		// the order entity, not the report, marks the order done: the
+		// fill for a report comes after it
+		e.name, e.orderID, e.execType = "executionReport", r.OrderID, r.ExecType
+		e.attrs = []slog.Attr{
+			slog.String("exec.type", r.ExecType),
+			slog.String("status", r.Status.String()),
+			slog.Uint64("cum.qty", uint64(r.CumQty)),
+			slog.Float64("avg.px", r.AvgPx),
+		}
+
+		if r.LastQty > 0 {
+			e.attrs = append(e.attrs, slog.Uint64("last.qty", uint64(r.LastQty)), slog.Float64("last.px", r.LastPx))
+		}
+
+		if r.RejectReason != "" {
+			e.attrs = append(e.attrs, slog.String("reject.reason", r.RejectReason))
+		}
+	case EntityTypeFill:
+		f, err := msg.Fill()
+		if err != nil {
+			return nil
+		}
+
+		e.name, e.orderID = "fill", f.OrderID
+		e.attrs = []slog.Attr{
+			slog.Uint64("fill.id", uint64(f.ID)),
+			slog.Uint64("qty", uint64(f.Qty)),
+			slog.Float64("price", f.Price),
+		}
+	case EntityTypeCommand:
+		c, err := msg.Command()
+		if err != nil {
+			return nil
+		}
+
+		e.name, e.orderID, e.clOrdID = "command", c.OrderID, c.ClOrdID
+		e.attrs = []slog.Attr{
+			slog.Uint64("command.id", uint64(c.ID)),
+			slog.String("command.type", c.CommandType),
+			slog.String("command.status", c.CommandStatus),
+		}
+	default:
+		return nil
+	}
+
+	e.attrs = append(e.attrs, slog.Uint64("order.id", uint64(e.orderID)))
+	return e
+}
+
+// the order won't change again
+func finalStatus(s OrderStatus) bool {
+	switch s {
+	case OrderStatusCanceled, OrderStatusCompleted, OrderStatusExpired, OrderStatusFilled, OrderStatusRejected:
+		return true
+	default:
+		return false
+	}
+}
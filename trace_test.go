@@ -0,0 +1,201 @@
+package tradovate_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/AnthonyHewins/tradovate/tradovatetest"
+)
+
+type testTracer struct {
+	ended chan *testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, tradovate.Span) {
+	sp := &testSpan{t: t, name: name, attrs: map[string]string{}}
+	sp.SetAttributes(attrs...)
+	return ctx, sp
+}
+
+type testSpan struct {
+	t *testTracer
+
+	mu     sync.Mutex
+	name   string
+	attrs  map[string]string
+	events []string
+	err    error
+}
+
+func (s *testSpan) SetAttributes(attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range attrs {
+		s.attrs[v.Key] = v.Value.String()
+	}
+}
+
+func (s *testSpan) AddEvent(name string, at time.Time, attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, name)
+}
+
+func (s *testSpan) End(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	s.t.ended <- s
+}
+
+func TestTraceOrders(mainTest *testing.T) {
+	srv := tradovatetest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tracer := &testTracer{ended: make(chan *testSpan, 1)}
+	ws, err := srv.NewSocket(ctx, tradovate.WithTracer(tracer))
+	if err != nil {
+		mainTest.Fatalf("failed connecting: %v", err)
+	}
+	defer ws.Close()
+
+	order := func(id uint, status tradovate.OrderStatus) func() {
+		return func() {
+			srv.PushEntity(tradovate.EntityTypeOrder, tradovate.EventTypeUpdated, &tradovate.Order{ID: id, Status: status})
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		path           string
+		before         []func() // pushed before the response
+		resp           any
+		call           func() error
+		after          []func()
+		expectedName   string
+		expectedAttrs  map[string]string
+		expectedEvents []string
+		expectedErr    bool
+	}{
+		{
+			name: "place correlates by clOrdId, then order ID, until the order's done",
+			path: "order/placeorder",
+			before: []func(){
+				func() {
+					srv.PushEntity(tradovate.EntityTypeCommand, tradovate.EventTypeCreated, &tradovate.Command{ID: 1, OrderID: 5, ClOrdID: "abc"})
+				},
+				order(5, tradovate.OrderStatusWorking),
+			},
+			resp: map[string]any{"orderId": 5},
+			call: func() error {
+				_, err := ws.PlaceOrder(ctx, &tradovate.OrderReq{ClientOrderID: "abc", Symbol: "ESZ5", OrderQty: 1, OrderType: tradovate.OrderTypeMarket})
+				return err
+			},
+			after: []func(){
+				order(99, tradovate.OrderStatusFilled), // someone else's
+				func() {
+					srv.PushEntity(tradovate.EntityTypeExecutionReport, tradovate.EventTypeCreated, &tradovate.ExecutionReport{OrderID: 5, ExecType: "Trade", Status: tradovate.OrderStatusFilled, LastQty: 1})
+				},
+				func() {
+					srv.PushEntity(tradovate.EntityTypeFill, tradovate.EventTypeCreated, &tradovate.Fill{OrderID: 5, Qty: 1})
+				},
+				order(5, tradovate.OrderStatusFilled),
+			},
+			expectedName:   "tradovate.PlaceOrder",
+			expectedAttrs:  map[string]string{"symbol": "ESZ5", "clOrdId": "abc", "order.id": "5", "result": "Success", "request.id": "2"},
+			expectedEvents: []string{"command", "order", "response", "executionReport", "fill", "order"},
+		},
+		{
+			name:           "events before the order ID is known are replayed",
+			path:           "order/placeorder",
+			before:         []func(){order(6, tradovate.OrderStatusWorking)},
+			resp:           map[string]any{"orderId": 6},
+			call:           func() error { _, err := ws.PlaceOrder(ctx, &tradovate.OrderReq{Symbol: "ESZ5"}); return err },
+			after:          []func(){order(6, tradovate.OrderStatusCanceled)},
+			expectedName:   "tradovate.PlaceOrder",
+			expectedAttrs:  map[string]string{"order.id": "6", "result": "Success"},
+			expectedEvents: []string{"order", "response", "order"},
+		},
+		{
+			name:           "rejections end the span",
+			path:           "order/placeorder",
+			resp:           map[string]any{"failureReason": "NoQuote"},
+			call:           func() error { _, err := ws.PlaceOrder(ctx, &tradovate.OrderReq{Symbol: "ESZ5"}); return err },
+			expectedName:   "tradovate.PlaceOrder",
+			expectedAttrs:  map[string]string{"result": "NoQuote"},
+			expectedEvents: []string{"response"},
+			expectedErr:    true,
+		},
+		{
+			name: "cancel ends on its execution report",
+			path: "order/cancelorder",
+			resp: map[string]any{"commandId": 8},
+			call: func() error { _, err := ws.CancelOrder(ctx, 7); return err },
+			after: []func(){
+				func() {
+					srv.PushEntity(tradovate.EntityTypeExecutionReport, tradovate.EventTypeCreated, &tradovate.ExecutionReport{OrderID: 7, ExecType: "Canceled"})
+				},
+			},
+			expectedName:   "tradovate.CancelOrder",
+			expectedAttrs:  map[string]string{"order.id": "7", "command.id": "8", "result": "Success"},
+			expectedEvents: []string{"response", "executionReport"},
+		},
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			srv.Handle(tc.path, func(*tradovatetest.Request) tradovatetest.Response {
+				for _, fn := range tc.before {
+					fn()
+				}
+				return tradovatetest.Response{Body: tc.resp}
+			})
+
+			if err := tc.call(); (err != nil) != tc.expectedErr {
+				tt.Fatalf("wanted error: %v, got %v", tc.expectedErr, err)
+			}
+
+			for _, fn := range tc.after {
+				fn()
+			}
+
+			var sp *testSpan
+			select {
+			case sp = <-tracer.ended:
+			case <-ctx.Done():
+				tt.Fatal("span never ended")
+			}
+
+			sp.mu.Lock()
+			defer sp.mu.Unlock()
+
+			if sp.name != tc.expectedName {
+				tt.Errorf("wrong name: want %s got %s", tc.expectedName, sp.name)
+			}
+
+			for k, v := range tc.expectedAttrs {
+				if sp.attrs[k] != v {
+					tt.Errorf("attr %s: want %s got %s", k, v, sp.attrs[k])
+				}
+			}
+
+			if !reflect.DeepEqual(tc.expectedEvents, sp.events) {
+				tt.Errorf("wrong events: want %v got %v", tc.expectedEvents, sp.events)
+			}
+
+			var oe *tradovate.OrderErr
+			if tc.expectedErr != errors.As(sp.err, &oe) {
+				tt.Errorf("span should end with the order error, got %v", sp.err)
+			}
+		})
+	}
+}
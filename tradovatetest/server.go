@@ -0,0 +1,575 @@
+// Package tradovatetest provides an in-process fake of the tradovate
+// REST and websocket APIs, so code built on the tradovate package can be
+// tested without credentials or network access.
+//
+// The fake speaks the same SockJS style frames the real server does
+// ("o", "h", "a[...]", "c[...]"), issues tokens, authorizes sockets and
+// answers the account, order, position and market data requests out of the
+// box. Any path can be scripted with Handle, events can be pushed to every
+// connected socket, and every request received is recorded
+package tradovatetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+)
+
+// Path the websocket is served on. Use WSURL to get the full URL
+const WebsocketPath = "/websocket"
+
+// Request is a single request the server received, either over REST or
+// over the websocket
+type Request struct {
+	Path     string          // path without leading slash, e.g. "order/list"
+	ID       int             // socket request ID; 0 for REST requests
+	Query    url.Values      // query params, if any
+	Body     json.RawMessage // raw body, if any
+	Token    string          // access token the request was made with
+	Socket   bool            // true if the request came over the websocket
+	Received time.Time
+
+	conn *conn // the socket it came over
+}
+
+// Decode the request body into x
+func (r *Request) Decode(x any) error {
+	return json.Unmarshal(r.Body, x)
+}
+
+// Response to a request. Body is marshalled to JSON and sent as the
+// "d" field for socket requests, or as the HTTP body for REST requests.
+// A nil body is omitted
+type Response struct {
+	Status int
+	Body   any
+}
+
+// Handler answers a request
+type Handler func(*Request) Response
+
+type Opt func(s *Server)
+
+// Only accept these credentials when issuing tokens. By default any
+// credentials are accepted
+func WithCreds(c *tradovate.Creds) Opt {
+	return func(s *Server) { s.creds = c }
+}
+
+// Lifespan of the tokens the server issues. Defaults to 90 minutes,
+// the same as tradovate
+func WithTokenTTL(d time.Duration) Opt {
+	return func(s *Server) { s.tokenTTL = d }
+}
+
+// Script a path's response on startup. Same as calling Handle
+func WithHandler(path string, h Handler) Opt {
+	return func(s *Server) { s.handlers[path] = h }
+}
+
+// Fake tradovate server
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	creds    *tradovate.Creds
+	tokenTTL time.Duration
+	tokenSeq int
+	tokens   map[string]time.Time // token -> expiration
+	handlers map[string]Handler
+	builtin  map[string]Handler
+	requests []Request
+	conns    map[*conn]struct{}
+
+	accounts  []*tradovate.Account
+	orders    []*tradovate.Order
+	positions []*tradovate.Position
+	fills     []*tradovate.Fill
+	contracts []*tradovate.Contract
+	commands  []*tradovate.Command
+	versions  []*tradovate.OrderVersion
+	subs      map[connSub]struct{}
+	chartSeq  int
+	charts    map[int]connSub
+}
+
+// subscriptions belong to the socket that made them
+type connSub struct {
+	conn *conn
+	Subscription
+}
+
+// Start a new fake server. Close it when done
+func NewServer(opts ...Opt) *Server {
+	s := &Server{
+		tokenTTL: 90 * time.Minute,
+		tokens:   map[string]time.Time{},
+		handlers: map[string]Handler{},
+		conns:    map[*conn]struct{}{},
+		subs:     map[connSub]struct{}{},
+		charts:   map[int]connSub{},
+	}
+
+	s.builtin = s.defaults()
+	for _, v := range opts {
+		v(s)
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close all sockets and shut the server down
+func (s *Server) Close() {
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.ws.Close(websocket.StatusGoingAway, "server shutting down")
+	}
+
+	s.srv.Close()
+}
+
+// Base URL to use as the REST URL
+func (s *Server) URL() string { return s.srv.URL }
+
+// URL to dial for the websocket
+func (s *Server) WSURL() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http") + WebsocketPath
+}
+
+// Credentials the server accepts
+func (s *Server) Creds() *tradovate.Creds {
+	if s.creds != nil {
+		return s.creds
+	}
+
+	return &tradovate.Creds{
+		Name:       "tradovatetest",
+		Password:   "tradovatetest",
+		AppID:      "tradovatetest",
+		AppVersion: "1.0",
+		ClientID:   "1",
+		DeviceID:   uuid.Nil,
+		Secret:     uuid.Nil,
+	}
+}
+
+// REST client pointed at this server, using Creds
+func (s *Server) NewREST(opts ...tradovate.RESTOpt) *tradovate.REST {
+	return tradovate.NewREST(s.URL(), s.srv.Client(), s.Creds(), opts...)
+}
+
+// Socket client connected and authorized against this server
+func (s *Server) NewSocket(ctx context.Context, opts ...tradovate.WSOpt) (*tradovate.WS, error) {
+	return tradovate.NewSocket(ctx, s.WSURL(), nil, s.NewREST(), opts...)
+}
+
+// Script the response for a path, replacing the server's default
+// behavior for it. Path has no leading slash, e.g. "order/placeorder"
+func (s *Server) Handle(path string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[path] = h
+}
+
+// Always answer path with status and body
+func (s *Server) Respond(path string, status int, body any) {
+	s.Handle(path, func(*Request) Response { return Response{Status: status, Body: body} })
+}
+
+// Every request received so far, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := make([]Request, len(s.requests))
+	copy(r, s.requests)
+	return r
+}
+
+// Every request received so far for a path, in order
+func (s *Server) RequestsFor(path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var r []Request
+	for _, v := range s.requests {
+		if v.Path == path {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// Invalidate every token issued so far. Sockets already authorized stay
+// connected, but new requests with those tokens are denied
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.tokens)
+}
+
+func (s *Server) record(r *Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, *r)
+}
+
+func (s *Server) handler(path string) (Handler, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if h, ok := s.handlers[path]; ok {
+		return h, true
+	}
+
+	h, ok := s.builtin[path]
+	return h, ok
+}
+
+func (s *Server) validToken(t string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exp, ok := s.tokens[t]
+	return ok && time.Now().Before(exp)
+}
+
+func (s *Server) issueToken() *tradovate.Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokenSeq++
+	t := &tradovate.Token{
+		AccessToken:    "token-" + strconv.Itoa(s.tokenSeq),
+		ExpirationTime: time.Now().Add(s.tokenTTL).UTC(),
+		UserStatus:     "Active",
+		UserID:         1,
+		Name:           s.Creds().Name,
+	}
+
+	s.tokens[t.AccessToken] = t.ExpirationTime
+	return t
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == WebsocketPath {
+		s.serveWS(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &Request{
+		Path:     strings.TrimPrefix(r.URL.Path, "/"),
+		Query:    r.URL.Query(),
+		Body:     body,
+		Token:    strings.TrimPrefix(r.Header.Get("authorization"), "Bearer "),
+		Received: time.Now(),
+	}
+	s.record(req)
+
+	var resp Response
+	switch h, ok := s.handler(req.Path); {
+	case req.Path == accessTokenPath && !ok:
+		resp = s.accessToken(req)
+	case req.Path == renewTokenPath && !ok:
+		resp = s.renewToken(req)
+	case !s.validToken(req.Token):
+		resp = Response{Status: http.StatusUnauthorized, Body: "Access is denied"}
+	case ok:
+		resp = h(req)
+	default:
+		resp = Response{Status: http.StatusNotFound, Body: "Not found: " + req.Path}
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	w.WriteHeader(resp.Status)
+
+	if resp.Body != nil {
+		json.NewEncoder(w).Encode(resp.Body)
+	}
+}
+
+func (s *Server) accessToken(r *Request) Response {
+	var c tradovate.Creds
+	if err := r.Decode(&c); err != nil {
+		return Response{Status: http.StatusBadRequest, Body: err.Error()}
+	}
+
+	if s.creds != nil && (c.Name != s.creds.Name || c.Password != s.creds.Password) {
+		// tradovate returns 200 with errorText on bad credentials
+		return Response{Status: http.StatusOK, Body: map[string]string{
+			"errorText": "Incorrect username or password. Please try again.",
+		}}
+	}
+
+	return Response{Status: http.StatusOK, Body: s.issueToken()}
+}
+
+func (s *Server) renewToken(r *Request) Response {
+	if !s.validToken(r.Token) {
+		return Response{Status: http.StatusUnauthorized, Body: "Access is denied"}
+	}
+
+	return Response{Status: http.StatusOK, Body: s.issueToken()}
+}
+
+// Subscription is an active market data subscription on the server.
+// Symbol is the symbol or contract ID it was requested with
+type Subscription struct {
+	Kind   string // one of quote, dom, histogram or chart
+	Symbol string
+}
+
+// Active market data subscriptions. Like on the real server, they end
+// with the socket that made them
+func (s *Server) Subscriptions() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	x := make([]Subscription, 0, len(s.subs)+len(s.charts))
+	for k := range s.subs {
+		x = append(x, k.Subscription)
+	}
+
+	for _, v := range s.charts {
+		x = append(x, v.Subscription)
+	}
+
+	return x
+}
+
+// forget everything a socket subscribed to
+func (s *Server) dropSubscriptions(c *conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k := range s.subs {
+		if k.conn == c {
+			delete(s.subs, k)
+		}
+	}
+
+	for id, v := range s.charts {
+		if v.conn == c {
+			delete(s.charts, id)
+		}
+	}
+}
+
+// Set the accounts returned by account/list
+func (s *Server) SetAccounts(a ...*tradovate.Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts = a
+}
+
+// Set the orders returned by order/list
+func (s *Server) SetOrders(o ...*tradovate.Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders = o
+}
+
+// Set the positions returned by position/list
+func (s *Server) SetPositions(p ...*tradovate.Position) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.positions = p
+}
+
+// Set the fills returned by fill/list
+func (s *Server) SetFills(f ...*tradovate.Fill) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fills = f
+}
+
+// Set the commands returned by command/list
+func (s *Server) SetCommands(c ...*tradovate.Command) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = c
+}
+
+// Set the order versions returned by orderVersion/list
+func (s *Server) SetOrderVersions(v ...*tradovate.OrderVersion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions = v
+}
+
+// Set the contracts contract/find and contract/item look up
+func (s *Server) SetContracts(c ...*tradovate.Contract) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contracts = c
+}
+
+// the server's behavior for any path not scripted with Handle
+func (s *Server) defaults() map[string]Handler {
+	return map[string]Handler{
+		"account/list": func(*Request) Response {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return ok(nonNil(s.accounts))
+		},
+		"order/list": func(*Request) Response {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return ok(nonNil(s.orders))
+		},
+		"position/list": func(*Request) Response {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			x := make([]position, len(s.positions))
+			for i, v := range s.positions {
+				x[i] = newPosition(v)
+			}
+			return ok(x)
+		},
+		"fill/list": func(*Request) Response {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			x := make([]fill, len(s.fills))
+			for i, v := range s.fills {
+				x[i] = newFill(v)
+			}
+			return ok(x)
+		},
+		"command/list": func(*Request) Response {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return ok(nonNil(s.commands))
+		},
+		"orderVersion/list": func(*Request) Response {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return ok(nonNil(s.versions))
+		},
+		"contract/find": s.findContract(func(c *tradovate.Contract, r *Request) bool {
+			return c.Name == r.Query.Get("name")
+		}),
+		"contract/item": s.findContract(func(c *tradovate.Contract, r *Request) bool {
+			return strconv.Itoa(c.ID) == r.Query.Get("id")
+		}),
+		"md/subscribeQuote":       s.subscribe("quote", true),
+		"md/unsubscribeQuote":     s.subscribe("quote", false),
+		"md/subscribeDOM":         s.subscribe("dom", true),
+		"md/unsubscribeDOM":       s.subscribe("dom", false),
+		"md/subscribeHistogram":   s.subscribe("histogram", true),
+		"md/unsubscribeHistogram": s.subscribe("histogram", false),
+		"md/getchart":             s.getChart,
+		"md/cancelchart":          s.cancelChart,
+	}
+}
+
+func (s *Server) findContract(match func(*tradovate.Contract, *Request) bool) Handler {
+	return func(r *Request) Response {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for _, v := range s.contracts {
+			if match(v, r) {
+				return ok(v)
+			}
+		}
+
+		return Response{Status: http.StatusNotFound, Body: "Contract not found"}
+	}
+}
+
+func (s *Server) subscribe(kind string, on bool) Handler {
+	return func(r *Request) Response {
+		var x struct {
+			Symbol any `json:"symbol"`
+		}
+
+		if err := r.Decode(&x); err != nil || x.Symbol == nil {
+			return Response{Status: http.StatusBadRequest, Body: "symbol is required"}
+		}
+
+		sub := connSub{conn: r.conn, Subscription: Subscription{Kind: kind, Symbol: fmt.Sprint(x.Symbol)}}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if on {
+			s.subs[sub] = struct{}{}
+		} else {
+			delete(s.subs, sub)
+		}
+
+		return Response{Status: http.StatusOK}
+	}
+}
+
+func (s *Server) getChart(r *Request) Response {
+	var x struct {
+		Symbol string `json:"symbol"`
+	}
+
+	if err := r.Decode(&x); err != nil || x.Symbol == "" {
+		return Response{Status: http.StatusBadRequest, Body: "symbol is required"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chartSeq++
+	s.charts[s.chartSeq] = connSub{conn: r.conn, Subscription: Subscription{Kind: "chart", Symbol: x.Symbol}}
+	return ok(map[string]int{"historicalId": s.chartSeq, "realtimeId": s.chartSeq})
+}
+
+func (s *Server) cancelChart(r *Request) Response {
+	var x struct {
+		ID int `json:"subscriptionId"`
+	}
+
+	if err := r.Decode(&x); err != nil {
+		return Response{Status: http.StatusBadRequest, Body: err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.charts, x.ID)
+	return Response{Status: http.StatusOK}
+}
+
+func ok(body any) Response { return Response{Status: http.StatusOK, Body: body} }
+
+// marshal empty lists as [] rather than null
+func nonNil[X any](x []X) []X {
+	if x == nil {
+		return []X{}
+	}
+	return x
+}
@@ -0,0 +1,206 @@
+package tradovatetest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+func TestServerLists(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	td := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	accounts := []*tradovate.Account{{ID: 1, Name: "DEMO1", Active: true}}
+	orders := []*tradovate.Order{{ID: 10, AccountID: 1, Action: tradovate.ActionBuy, Status: tradovate.OrderStatusWorking}}
+	positions := []*tradovate.Position{{ID: 3, AccountID: 1, ContractID: 7, NetPos: 2, NetPrice: 5000.25, TradeDate: td}}
+
+	s.SetAccounts(accounts...)
+	s.SetOrders(orders...)
+	s.SetPositions(positions...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := s.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting to fake: %v", err)
+	}
+	defer ws.Close()
+
+	a, err := ws.ListAccounts(ctx)
+	if err != nil || !reflect.DeepEqual(a, accounts) {
+		t.Errorf("account list wrong: want %+v got %+v (err %v)", accounts, a, err)
+	}
+
+	o, err := ws.ListOrders(ctx)
+	if err != nil || !reflect.DeepEqual(o, orders) {
+		t.Errorf("order list wrong: want %+v got %+v (err %v)", orders, o, err)
+	}
+
+	p, err := ws.ListPositions(ctx)
+	if err != nil || len(p) != 1 {
+		t.Fatalf("position list wrong: got %+v (err %v)", p, err)
+	}
+
+	if y, m, d := p[0].TradeDate.Date(); y != 2025 || m != time.March || d != 4 || p[0].NetPos != 2 {
+		t.Errorf("position didn't survive the round trip: %+v", p[0])
+	}
+
+	want := []string{"authorize", "account/list", "order/list", "position/list"}
+	var got []string
+	for _, v := range s.Requests() {
+		if v.Socket {
+			got = append(got, v.Path)
+		}
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("recorded requests wrong\nwant %v\n got %v", want, got)
+	}
+}
+
+func TestServerScripted(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.Respond("order/placeorder", http.StatusOK, map[string]any{
+		"failureReason": tradovate.OrderErrReasonSessionClosed,
+		"failureText":   "closed",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ws, err := s.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("failed connecting to fake: %v", err)
+	}
+	defer ws.Close()
+
+	_, err = ws.PlaceOrder(ctx, &tradovate.OrderReq{Symbol: "ESZ5", OrderQty: 1})
+
+	var oe *tradovate.OrderErr
+	if !errors.As(err, &oe) || oe.Reason != tradovate.OrderErrReasonSessionClosed {
+		t.Errorf("wanted scripted order err, got %v", err)
+	}
+
+	reqs := s.RequestsFor("order/placeorder")
+	if len(reqs) != 1 {
+		t.Fatalf("wanted 1 recorded order, got %d", len(reqs))
+	}
+
+	var body tradovate.OrderReq
+	if err = reqs[0].Decode(&body); err != nil || body.Symbol != "ESZ5" {
+		t.Errorf("recorded body wrong: %+v (err %v)", body, err)
+	}
+
+	s.Respond("account/list", http.StatusInternalServerError, "boom")
+	if _, err = ws.ListAccounts(ctx); !errors.Is(err, &tradovate.RespErr{}) {
+		t.Errorf("wanted RespErr, got %v", err)
+	}
+}
+
+func TestServerPush(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entities := make(chan *tradovate.EntityMsg, 1)
+	md := make(chan *tradovate.MarketData, 1)
+	charts := make(chan *tradovate.Chart, 1)
+
+	ws, err := s.NewSocket(ctx,
+		tradovate.WithEntityHandler(func(e *tradovate.EntityMsg) { entities <- e }),
+		tradovate.WithMarketDataHandler(func(m *tradovate.MarketData) { md <- m }),
+		tradovate.WithChartHandler(func(c *tradovate.Chart) { charts <- c }),
+	)
+	if err != nil {
+		t.Fatalf("failed connecting to fake: %v", err)
+	}
+	defer ws.Close()
+
+	if _, err = ws.SubscribeQuoteSymbol(ctx, "ESZ5"); err != nil {
+		t.Fatalf("failed subscribing: %v", err)
+	}
+
+	if subs := s.Subscriptions(); !reflect.DeepEqual(subs, []Subscription{{Kind: "quote", Symbol: "ESZ5"}}) {
+		t.Errorf("subscription not tracked: %+v", subs)
+	}
+
+	order := &tradovate.Order{ID: 5, Action: tradovate.ActionSell, Status: tradovate.OrderStatusFilled}
+	if err = s.PushEntity(tradovate.EntityTypeOrder, tradovate.EventTypeUpdated, order); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("never received entity")
+	case e := <-entities:
+		if e.Type != tradovate.EntityTypeOrder || e.Event != tradovate.EventTypeUpdated {
+			t.Errorf("wrong entity msg %+v", e)
+		}
+
+		if o, err := e.Order(); err != nil || !reflect.DeepEqual(o, order) {
+			t.Errorf("wrong order decoded: %+v (err %v)", o, err)
+		}
+	}
+
+	q := &tradovate.Quote{ContractID: 7, Bid: tradovate.PriceQty{Price: 5000, Size: 3}, Entries: tradovate.QuoteEntryBid}
+	if err = s.PushQuotes(q); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("never received quote")
+	case m := <-md:
+		if len(m.Quotes) != 1 || !reflect.DeepEqual(m.Quotes[0], q) {
+			t.Errorf("wrong quote: %+v", m.Quotes)
+		}
+	}
+
+	c := &tradovate.Chart{
+		ID:   4,
+		Td:   time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC),
+		Bars: []tradovate.Bar{{Open: 1, High: 2, Low: 0.5, Close: 1.5}},
+	}
+	if err = s.PushChart(c); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("never received chart")
+	case x := <-charts:
+		if !reflect.DeepEqual(x, c) {
+			t.Errorf("wrong chart\nwant %+v\n got %+v", c, x)
+		}
+	}
+}
+
+func TestServerUnauthorized(t *testing.T) {
+	s := NewServer(WithCreds(&tradovate.Creds{Name: "a", Password: "b"}))
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r := tradovate.NewREST(s.URL(), http.DefaultClient, &tradovate.Creds{Name: "a", Password: "wrong"})
+	if _, err := tradovate.NewSocket(ctx, s.WSURL(), nil, r); err == nil {
+		t.Error("should have failed with bad creds")
+	}
+
+	ws, err := s.NewSocket(ctx)
+	if err != nil {
+		t.Fatalf("should connect with the right creds, got %v", err)
+	}
+	ws.Close()
+}
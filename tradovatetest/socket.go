@@ -0,0 +1,267 @@
+package tradovatetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+	"github.com/coder/websocket"
+)
+
+const (
+	authorizePath   = "authorize"
+	accessTokenPath = "auth/accessTokenRequest"
+	renewTokenPath  = "auth/renewAccessToken"
+)
+
+type conn struct {
+	ws *websocket.Conn
+
+	mu    sync.Mutex
+	token string // set once the socket is authorized
+}
+
+func (c *conn) authorized() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// a single message inside an "a" frame
+type msg struct {
+	Event  string `json:"e,omitempty"`
+	ID     int    `json:"i,omitempty"`
+	Status int    `json:"s,omitempty"`
+	Data   any    `json:"d,omitempty"`
+}
+
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	ws, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{ws: ws}
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		s.dropSubscriptions(c)
+		ws.CloseNow()
+	}()
+
+	ctx := r.Context()
+	if err = ws.Write(ctx, websocket.MessageText, []byte("o")); err != nil {
+		return
+	}
+
+	for {
+		_, buf, err := ws.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		// heartbeat response from the client
+		if string(buf) == "[]" {
+			continue
+		}
+
+		req, err := parseRequest(buf)
+		if err != nil {
+			ws.Close(websocket.StatusProtocolError, err.Error())
+			return
+		}
+
+		req.Token, req.conn = c.authorized(), c
+		s.record(req)
+
+		if err = s.write(ctx, c, s.answer(c, req)); err != nil {
+			return
+		}
+	}
+}
+
+// requests are of the form path\nid\nquery\nbody
+func parseRequest(buf []byte) (*Request, error) {
+	parts := strings.SplitN(string(buf), "\n", 4)
+	if len(parts) < 2 {
+		return nil, errors.New("malformed request: " + string(buf))
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed request ID: " + parts[1])
+	}
+
+	req := &Request{
+		Path:     parts[0],
+		ID:       id,
+		Socket:   true,
+		Received: time.Now(),
+	}
+
+	if len(parts) > 2 && parts[2] != "" {
+		if req.Query, err = url.ParseQuery(parts[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(parts) > 3 {
+		if b := strings.TrimSpace(parts[3]); b != "" {
+			req.Body = json.RawMessage(b)
+		}
+	}
+
+	return req, nil
+}
+
+func (s *Server) answer(c *conn, req *Request) msg {
+	var resp Response
+	switch h, ok := s.handler(req.Path); {
+	case req.Path == authorizePath && !ok:
+		resp = s.authorize(c, req)
+	case req.Token == "" || !s.validToken(req.Token):
+		resp = Response{Status: http.StatusUnauthorized, Body: "Access is denied"}
+	case ok:
+		resp = h(req)
+	default:
+		resp = Response{Status: http.StatusNotFound, Body: "Not found: " + req.Path}
+	}
+
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+
+	return msg{ID: req.ID, Status: resp.Status, Data: resp.Body}
+}
+
+func (s *Server) authorize(c *conn, req *Request) Response {
+	var t string
+	if err := req.Decode(&t); err != nil || !s.validToken(t) {
+		return Response{Status: http.StatusUnauthorized, Body: "Access is denied"}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = t
+	return Response{Status: http.StatusOK}
+}
+
+func (s *Server) write(ctx context.Context, c *conn, m ...msg) error {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return c.ws.Write(ctx, websocket.MessageText, append([]byte("a"), buf...))
+}
+
+func (s *Server) connections() []*conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	x := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		x = append(x, c)
+	}
+	return x
+}
+
+func (s *Server) broadcast(raw []byte) error {
+	var errs []error
+	for _, c := range s.connections() {
+		errs = append(errs, c.ws.Write(context.Background(), websocket.MessageText, raw))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Push an event to every connected socket. Event is the "e" field,
+// e.g. "props", "md", "chart" or "shutdown", and d is marshalled as-is.
+// Use the typed Push functions unless you need to send something the
+// client can't normally produce
+func (s *Server) PushEvent(event string, d any) error {
+	buf, err := json.Marshal([]msg{{Event: event, Data: d}})
+	if err != nil {
+		return err
+	}
+
+	return s.broadcast(append([]byte("a"), buf...))
+}
+
+// Push a props event, notifying clients an entity was created, updated or
+// deleted. Entity is marshalled as the "entity" field
+func (s *Server) PushEntity(t tradovate.EntityType, e tradovate.EventType, entity any) error {
+	switch x := entity.(type) {
+	case *tradovate.Position:
+		entity = newPosition(x)
+	case *tradovate.Fill:
+		entity = newFill(x)
+	}
+
+	return s.PushEvent("props", map[string]any{
+		"entityType": entityType(t),
+		"eventType":  e,
+		"entity":     entity,
+	})
+}
+
+// Push quote updates as a single md event
+func (s *Server) PushQuotes(q ...*tradovate.Quote) error {
+	x := make([]quote, len(q))
+	for i, v := range q {
+		x[i] = newQuote(v)
+	}
+
+	return s.PushEvent("md", map[string]any{"quotes": x})
+}
+
+// Push DOM updates as a single md event
+func (s *Server) PushDOMs(d ...*tradovate.DOM) error {
+	return s.PushEvent("md", map[string]any{"doms": d})
+}
+
+// Push histogram updates as a single md event
+func (s *Server) PushHistograms(h ...*tradovate.Histogram) error {
+	x := make([]histogram, len(h))
+	for i, v := range h {
+		x[i] = newHistogram(v)
+	}
+
+	return s.PushEvent("md", map[string]any{"histograms": x})
+}
+
+// Push a chart event
+func (s *Server) PushChart(c *tradovate.Chart) error {
+	return s.PushEvent("chart", newChart(c))
+}
+
+// Push a shutdown event. The server does not close the socket; follow up
+// with Disconnect if you want to simulate the server going away
+func (s *Server) PushShutdown(code tradovate.ShutdownCode, reason string) error {
+	return s.PushEvent("shutdown", &tradovate.ShutdownMsg{Code: code, Reason: reason})
+}
+
+// Send a heartbeat frame to every connected socket
+func (s *Server) Heartbeat() error {
+	return s.broadcast([]byte("h"))
+}
+
+// Send a close frame to every connected socket and drop the connections
+func (s *Server) Disconnect() {
+	for _, c := range s.connections() {
+		c.ws.Write(context.Background(), websocket.MessageText, []byte(`c[3000,"Go away!"]`))
+		c.ws.Close(websocket.StatusGoingAway, "server disconnect")
+	}
+}
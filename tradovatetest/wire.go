@@ -0,0 +1,240 @@
+package tradovatetest
+
+import (
+	"strings"
+	"time"
+
+	"github.com/AnthonyHewins/tradovate"
+)
+
+// The tradovate types flatten several of the server's payloads when
+// unmarshalling, so they can't be marshalled straight back. These
+// types put them back in the shape the server sends
+
+type tradeDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+func newTradeDate(t time.Time) tradeDate {
+	return tradeDate{Year: t.Year(), Month: int(t.Month()), Day: t.Day()}
+}
+
+// entity types are sent lower camel case, e.g. executionReport
+func entityType(t tradovate.EntityType) string {
+	s := t.String()
+	if s == "" {
+		return s
+	}
+
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+type position struct {
+	ID          int       `json:"id"`
+	AccountID   int       `json:"accountId"`
+	ContractID  int       `json:"contractId"`
+	Timestamp   time.Time `json:"timestamp"`
+	TradeDate   tradeDate `json:"tradeDate"`
+	NetPos      int       `json:"netPos"`
+	NetPrice    float64   `json:"netPrice,omitempty"`
+	Bought      int       `json:"bought"`
+	BoughtValue float64   `json:"boughtValue"`
+	Sold        int       `json:"sold"`
+	SoldValue   float64   `json:"soldValue"`
+	PrevPos     int       `json:"prevPos"`
+	PrevPrice   float64   `json:"prevPrice,omitempty"`
+}
+
+func newPosition(p *tradovate.Position) position {
+	return position{
+		ID:          p.ID,
+		AccountID:   p.AccountID,
+		ContractID:  p.ContractID,
+		Timestamp:   p.Timestamp,
+		TradeDate:   newTradeDate(p.TradeDate),
+		NetPos:      p.NetPos,
+		NetPrice:    p.NetPrice,
+		Bought:      p.Bought,
+		BoughtValue: p.BoughtValue,
+		Sold:        p.Sold,
+		SoldValue:   p.SoldValue,
+		PrevPos:     p.PrevPos,
+		PrevPrice:   p.PrevPrice,
+	}
+}
+
+type fill struct {
+	ID            uint             `json:"id"`
+	OrderID       uint             `json:"orderId"`
+	ContractID    int              `json:"contractId"`
+	Timestamp     time.Time        `json:"timestamp"`
+	TradeDate     tradeDate        `json:"tradeDate"`
+	Action        tradovate.Action `json:"action"`
+	Qty           uint32           `json:"qty"`
+	Price         float64          `json:"price"`
+	Active        bool             `json:"active"`
+	FinallyPaired uint32           `json:"finallyPaired"`
+}
+
+func newFill(f *tradovate.Fill) fill {
+	return fill{
+		ID:            f.ID,
+		OrderID:       f.OrderID,
+		ContractID:    f.ContractID,
+		Timestamp:     f.Timestamp,
+		TradeDate:     newTradeDate(f.TradeDate),
+		Action:        f.Action,
+		Qty:           f.Qty,
+		Price:         f.Price,
+		Active:        f.Active,
+		FinallyPaired: f.FinallyPaired,
+	}
+}
+
+type size struct {
+	Size float64 `json:"size"`
+}
+
+type price struct {
+	Price float64 `json:"price"`
+}
+
+type quote struct {
+	Timestamp  time.Time `json:"timestamp"`
+	ContractID int       `json:"contractId"`
+	Entries    struct {
+		Bid   *tradovate.PriceQty `json:"Bid,omitempty"`
+		Offer *tradovate.PriceQty `json:"Offer,omitempty"`
+		Trade *tradovate.PriceQty `json:"Trade,omitempty"`
+
+		TotalTradeVolume *size `json:"TotalTradeVolume,omitempty"`
+		OpenInterest     *size `json:"OpenInterest,omitempty"`
+
+		OpeningPrice    *price `json:"OpeningPrice,omitempty"`
+		LowPrice        *price `json:"LowPrice,omitempty"`
+		HighPrice       *price `json:"HighPrice,omitempty"`
+		SettlementPrice *price `json:"SettlementPrice,omitempty"`
+	} `json:"entries"`
+}
+
+// only the entries in q.Entries are sent, the same way the server only
+// sends the entries that changed. If q.Entries isn't set, every non-zero
+// field is sent
+func newQuote(q *tradovate.Quote) quote {
+	x := quote{Timestamp: q.Timestamp, ContractID: q.ContractID}
+
+	entries := q.Entries
+	if entries == 0 {
+		entries = nonZero(q)
+	}
+
+	pq := func(e tradovate.QuoteEntry, p tradovate.PriceQty) *tradovate.PriceQty {
+		if !entries.Has(e) {
+			return nil
+		}
+		return &p
+	}
+
+	sz := func(e tradovate.QuoteEntry, f float64) *size {
+		if !entries.Has(e) {
+			return nil
+		}
+		return &size{f}
+	}
+
+	pr := func(e tradovate.QuoteEntry, f float64) *price {
+		if !entries.Has(e) {
+			return nil
+		}
+		return &price{f}
+	}
+
+	e := &x.Entries
+	e.Bid = pq(tradovate.QuoteEntryBid, q.Bid)
+	e.Offer = pq(tradovate.QuoteEntryOffer, q.Offer)
+	e.Trade = pq(tradovate.QuoteEntryTrade, q.Trade)
+	e.TotalTradeVolume = sz(tradovate.QuoteEntryTotalTradeVolume, q.TotalTradeVolume)
+	e.OpenInterest = sz(tradovate.QuoteEntryOpenInterest, q.OpenInterest)
+	e.OpeningPrice = pr(tradovate.QuoteEntryOpeningPrice, q.OpeningPrice)
+	e.LowPrice = pr(tradovate.QuoteEntryLowPrice, q.LowPrice)
+	e.HighPrice = pr(tradovate.QuoteEntryHighPrice, q.HighPrice)
+	e.SettlementPrice = pr(tradovate.QuoteEntrySettlementPrice, q.SettlementPrice)
+	return x
+}
+
+func nonZero(q *tradovate.Quote) tradovate.QuoteEntry {
+	var e tradovate.QuoteEntry
+	for _, v := range []struct {
+		entry tradovate.QuoteEntry
+		set   bool
+	}{
+		{tradovate.QuoteEntryBid, q.Bid != tradovate.PriceQty{}},
+		{tradovate.QuoteEntryOffer, q.Offer != tradovate.PriceQty{}},
+		{tradovate.QuoteEntryTrade, q.Trade != tradovate.PriceQty{}},
+		{tradovate.QuoteEntryTotalTradeVolume, q.TotalTradeVolume != 0},
+		{tradovate.QuoteEntryOpenInterest, q.OpenInterest != 0},
+		{tradovate.QuoteEntryOpeningPrice, q.OpeningPrice != 0},
+		{tradovate.QuoteEntryLowPrice, q.LowPrice != 0},
+		{tradovate.QuoteEntryHighPrice, q.HighPrice != 0},
+		{tradovate.QuoteEntrySettlementPrice, q.SettlementPrice != 0},
+	} {
+		if v.set {
+			e |= v.entry
+		}
+	}
+	return e
+}
+
+type histogram struct {
+	ContractID int                `json:"contractId"`
+	Timestamp  time.Time          `json:"timestamp"`
+	TradeDate  tradeDate          `json:"tradeDate"`
+	Base       float64            `json:"base"`
+	Items      map[string]float64 `json:"items"`
+	Refresh    bool               `json:"refresh"`
+}
+
+func newHistogram(h *tradovate.Histogram) histogram {
+	return histogram{
+		ContractID: h.ContractID,
+		Timestamp:  h.Timestamp,
+		TradeDate:  newTradeDate(h.TradeDate),
+		Base:       h.Base,
+		Items:      h.Items,
+		Refresh:    h.Refresh,
+	}
+}
+
+type chart struct {
+	ID   int             `json:"id"`
+	Td   int             `json:"td"` // YYYYMMDD
+	Bars []tradovate.Bar `json:"bars,omitempty"`
+	Eoh  bool            `json:"eoh,omitempty"`
+
+	Source   string           `json:"s,omitempty"`
+	Bp       int              `json:"bp,omitempty"`
+	Bt       int64            `json:"bt,omitempty"` // unix millis
+	TickSize float64          `json:"ts,omitempty"`
+	Ticks    []tradovate.Tick `json:"tks,omitempty"`
+}
+
+func newChart(c *tradovate.Chart) chart {
+	x := chart{
+		ID:   c.ID,
+		Td:   c.Td.Year()*10000 + int(c.Td.Month())*100 + c.Td.Day(),
+		Bars: c.Bars,
+		Eoh:  c.EndOfHistory,
+
+		Source:   c.Source,
+		Bp:       c.BasePrice,
+		TickSize: c.TickSize,
+		Ticks:    c.Ticks,
+	}
+
+	if !c.BaseTimestamp.IsZero() {
+		x.Bt = c.BaseTimestamp.UnixMilli()
+	}
+	return x
+}
@@ -0,0 +1,250 @@
+package tradovate
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PriceLevel is the volume traded at a price
+type PriceLevel struct {
+	Price  float64
+	Volume float64
+}
+
+// ValueArea is the price range around the point of control holding a
+// share of the session's volume
+type ValueArea struct {
+	Low    float64
+	High   float64
+	Volume float64
+}
+
+// VolumeProfile is a contract's volume at price for the session, built
+// from its Histogram messages. Histogram keys are tick offsets from Base;
+// the profile turns them into absolute prices
+type VolumeProfile struct {
+	contractID int
+	tickSize   float64
+
+	mu        sync.Mutex
+	tradeDate time.Time
+	updated   time.Time
+	levels    map[int64]float64 // volume by price in ticks
+}
+
+func NewVolumeProfile(contractID int, tickSize float64) (*VolumeProfile, error) {
+	if tickSize <= 0 {
+		return nil, fmt.Errorf("tick size must be positive, got %v", tickSize)
+	}
+
+	return &VolumeProfile{contractID: contractID, tickSize: tickSize, levels: map[int64]float64{}}, nil
+}
+
+// Apply every histogram for this contract, skipping any that don't
+// parse. Matches the signature of WithMarketDataHandler
+func (p *VolumeProfile) Handle(md *MarketData) {
+	for _, v := range md.Histograms {
+		p.Update(v)
+	}
+}
+
+// Apply a histogram, reporting whether it was used. A refresh replaces the
+// profile; anything else sets the volume at the levels it has. A new
+// trade date starts a new session; histograms from older ones and other
+// contracts are ignored
+func (p *VolumeProfile) Update(h *Histogram) (bool, error) {
+	if h.ContractID != p.contractID {
+		return false, nil
+	}
+
+	// parse before touching anything so a bad key doesn't leave half an update
+	x := make(map[int64]float64, len(h.Items))
+	base := math.Round(h.Base / p.tickSize)
+	for k, v := range h.Items {
+		offset, err := strconv.ParseFloat(k, 64)
+		if err != nil {
+			return false, fmt.Errorf("bad histogram key %q: %w", k, err)
+		}
+		x[int64(base+math.Round(offset))] = v
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case h.TradeDate.Before(p.tradeDate):
+		return false, nil
+	case h.TradeDate.After(p.tradeDate):
+		p.tradeDate = h.TradeDate
+		clear(p.levels)
+	case h.Refresh:
+		clear(p.levels)
+	}
+
+	for k, v := range x {
+		if v == 0 {
+			delete(p.levels, k)
+			continue
+		}
+		p.levels[k] = v
+	}
+
+	p.updated = h.Timestamp
+	return true, nil
+}
+
+// Session the profile's for and when it was last updated
+func (p *VolumeProfile) Session() (tradeDate, updated time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tradeDate, p.updated
+}
+
+// Every level with volume, lowest price first
+func (p *VolumeProfile) Levels() []PriceLevel {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	x := make([]PriceLevel, 0, len(p.levels))
+	for _, k := range p.sortedTicks() {
+		x = append(x, PriceLevel{Price: p.price(k), Volume: p.levels[k]})
+	}
+	return x
+}
+
+// Total volume in the session
+func (p *VolumeProfile) Volume() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var total float64
+	for _, v := range p.levels {
+		total += v
+	}
+	return total
+}
+
+// Point of control: the level with the most volume, the lower one on a
+// tie. False if the profile's empty
+func (p *VolumeProfile) POC() (PriceLevel, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k, ok := p.poc(p.sortedTicks())
+	if !ok {
+		return PriceLevel{}, false
+	}
+
+	return PriceLevel{Price: p.price(k), Volume: p.levels[k]}, true
+}
+
+// The smallest range around the point of control holding pct of the
+// volume, e.g. 0.7 for the usual 70%. It grows a level at a time toward
+// whichever side has more volume next
+func (p *VolumeProfile) ValueArea(pct float64) (ValueArea, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ticks := p.sortedTicks()
+	poc, ok := p.poc(ticks)
+	if !ok {
+		return ValueArea{}, false
+	}
+
+	var total float64
+	for _, v := range p.levels {
+		total += v
+	}
+
+	lo, hi := poc, poc
+	volume, target := p.levels[poc], total*min(max(pct, 0), 1)
+	for volume < target {
+		below, okBelow := p.levels[lo-1], lo > ticks[0]
+		above, okAbove := p.levels[hi+1], hi < ticks[len(ticks)-1]
+
+		switch {
+		case okAbove && (!okBelow || above >= below):
+			hi++
+			volume += above
+		case okBelow:
+			lo--
+			volume += below
+		default:
+			return ValueArea{Low: p.price(lo), High: p.price(hi), Volume: volume}, true
+		}
+	}
+
+	return ValueArea{Low: p.price(lo), High: p.price(hi), Volume: volume}, true
+}
+
+// High and low volume nodes: levels with more (or less) volume than every
+// other level within window ticks either side. Empty prices in the range
+// count as zero volume. Lowest price first
+func (p *VolumeProfile) Nodes(window int) (hvn, lvn []PriceLevel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ticks := p.sortedTicks()
+	if len(ticks) == 0 || window < 1 {
+		return nil, nil
+	}
+
+	first, last := ticks[0], ticks[len(ticks)-1]
+	for k := first; k <= last; k++ {
+		v := p.levels[k]
+		high, low := true, true
+		for i := k - int64(window); i <= k+int64(window); i++ {
+			if i == k || i < first || i > last {
+				continue
+			}
+
+			n := p.levels[i]
+			high = high && v > n
+			low = low && v < n
+		}
+
+		// a low node needs volume on both sides, or it's just the edge
+		inside := k-int64(window) >= first && k+int64(window) <= last
+		switch {
+		case high:
+			hvn = append(hvn, PriceLevel{Price: p.price(k), Volume: v})
+		case low && inside:
+			lvn = append(lvn, PriceLevel{Price: p.price(k), Volume: v})
+		}
+	}
+
+	return hvn, lvn
+}
+
+// p.mu held
+func (p *VolumeProfile) sortedTicks() []int64 {
+	x := make([]int64, 0, len(p.levels))
+	for k := range p.levels {
+		x = append(x, k)
+	}
+	slices.Sort(x)
+	return x
+}
+
+func (p *VolumeProfile) poc(ticks []int64) (int64, bool) {
+	if len(ticks) == 0 {
+		return 0, false
+	}
+
+	best := ticks[0]
+	for _, k := range ticks[1:] {
+		if p.levels[k] > p.levels[best] {
+			best = k
+		}
+	}
+	return best, true
+}
+
+func (p *VolumeProfile) price(ticks int64) float64 {
+	// round off the float noise from multiplying back out
+	return math.Round(float64(ticks)*p.tickSize*1e9) / 1e9
+}
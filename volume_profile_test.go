@@ -0,0 +1,141 @@
+package tradovate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVolumeProfile(mainTest *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, nyseTimezone)
+	snapshot := &Histogram{
+		ContractID: 1,
+		TradeDate:  day,
+		Base:       100,
+		Refresh:    true,
+		Items:      map[string]float64{"-1": 15, "0": 10, "1": 30, "2": 50, "3": 20, "4": 5},
+	}
+
+	testCases := []struct {
+		name            string
+		updates         []*Histogram
+		expectedLevels  []PriceLevel
+		expectedPOC     float64
+		expectedVA      ValueArea
+		expectedHVN     []float64
+		expectedLVN     []float64
+		expectedApplied bool
+	}{
+		{
+			name:    "snapshot",
+			updates: []*Histogram{snapshot},
+			expectedLevels: []PriceLevel{
+				{99.75, 15}, {100, 10}, {100.25, 30}, {100.5, 50}, {100.75, 20}, {101, 5},
+			},
+			expectedPOC:     100.5,
+			expectedVA:      ValueArea{Low: 100.25, High: 100.75, Volume: 100},
+			expectedHVN:     []float64{99.75, 100.5},
+			expectedLVN:     []float64{100},
+			expectedApplied: true,
+		},
+		{
+			name: "incremental updates set their levels",
+			updates: []*Histogram{
+				snapshot,
+				{ContractID: 1, TradeDate: day, Base: 100.5, Items: map[string]float64{"2": 60, "-1": 0}},
+			},
+			expectedLevels: []PriceLevel{
+				{99.75, 15}, {100, 10}, {100.5, 50}, {100.75, 20}, {101, 60},
+			},
+			expectedPOC:     101,
+			expectedVA:      ValueArea{Low: 100.5, High: 101, Volume: 130},
+			expectedHVN:     []float64{99.75, 100.5, 101},
+			expectedLVN:     []float64{100.25, 100.75},
+			expectedApplied: true,
+		},
+		{
+			name: "refresh replaces",
+			updates: []*Histogram{
+				snapshot,
+				{ContractID: 1, TradeDate: day, Base: 100, Refresh: true, Items: map[string]float64{"0": 5}},
+			},
+			expectedLevels:  []PriceLevel{{100, 5}},
+			expectedPOC:     100,
+			expectedVA:      ValueArea{Low: 100, High: 100, Volume: 5},
+			expectedHVN:     []float64{100},
+			expectedApplied: true,
+		},
+		{
+			name: "new session resets",
+			updates: []*Histogram{
+				snapshot,
+				{ContractID: 1, TradeDate: day.AddDate(0, 0, 1), Base: 102, Items: map[string]float64{"0": 7}},
+			},
+			expectedLevels:  []PriceLevel{{102, 7}},
+			expectedPOC:     102,
+			expectedVA:      ValueArea{Low: 102, High: 102, Volume: 7},
+			expectedHVN:     []float64{102},
+			expectedApplied: true,
+		},
+		{
+			name: "old sessions and other contracts are ignored",
+			updates: []*Histogram{
+				snapshot,
+				{ContractID: 1, TradeDate: day.AddDate(0, 0, -1), Base: 90, Refresh: true, Items: map[string]float64{"0": 1}},
+				{ContractID: 2, TradeDate: day, Base: 90, Refresh: true, Items: map[string]float64{"0": 1}},
+			},
+			expectedLevels: []PriceLevel{
+				{99.75, 15}, {100, 10}, {100.25, 30}, {100.5, 50}, {100.75, 20}, {101, 5},
+			},
+			expectedPOC: 100.5,
+			expectedVA:  ValueArea{Low: 100.25, High: 100.75, Volume: 100},
+			expectedHVN: []float64{99.75, 100.5},
+			expectedLVN: []float64{100},
+		},
+	}
+
+	prices := func(x []PriceLevel) []float64 {
+		var p []float64
+		for _, v := range x {
+			p = append(p, v.Price)
+		}
+		return p
+	}
+
+	for _, tc := range testCases {
+		mainTest.Run(tc.name, func(tt *testing.T) {
+			p, err := NewVolumeProfile(1, 0.25)
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			var applied bool
+			for _, v := range tc.updates {
+				if applied, err = p.Update(v); err != nil {
+					tt.Fatalf("failed updating: %v", err)
+				}
+			}
+
+			if applied != tc.expectedApplied {
+				tt.Errorf("last update applied: want %v got %v", tc.expectedApplied, applied)
+			}
+
+			if levels := p.Levels(); !reflect.DeepEqual(tc.expectedLevels, levels) {
+				tt.Errorf("wrong levels:\nwant %v\ngot  %v", tc.expectedLevels, levels)
+			}
+
+			if poc, _ := p.POC(); poc.Price != tc.expectedPOC {
+				tt.Errorf("wrong POC: want %v got %v", tc.expectedPOC, poc.Price)
+			}
+
+			if va, _ := p.ValueArea(0.7); va != tc.expectedVA {
+				tt.Errorf("wrong value area: want %+v got %+v", tc.expectedVA, va)
+			}
+
+			hvn, lvn := p.Nodes(1)
+			if !reflect.DeepEqual(tc.expectedHVN, prices(hvn)) || !reflect.DeepEqual(tc.expectedLVN, prices(lvn)) {
+				tt.Errorf("wrong nodes: want %v/%v got %v/%v", tc.expectedHVN, tc.expectedLVN, prices(hvn), prices(lvn))
+			}
+		})
+	}
+}